@@ -2,172 +2,206 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
+	// コンテナにタイムゾーンデータベースが無くてもtzを解釈できるようにする
+	_ "time/tzdata"
 
-	firebase "firebase.google.com/go/v4"
-	"google.golang.org/api/option"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	"github.com/penglongli/gin-metrics/ginmetrics"
 
-	"github.com/vsrecorder/vsr-apiserver/pkg/controllers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
 	"github.com/vsrecorder/vsr-apiserver/pkg/infrastructures"
-	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
-	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+	"github.com/vsrecorder/vsr-apiserver/pkg/logging"
+	"github.com/vsrecorder/vsr-apiserver/pkg/server"
+	"github.com/vsrecorder/vsr-apiserver/pkg/tracing"
 )
 
-func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Printf("failed to load .env file: %v", err)
-	}
-
-	userName := os.Getenv("DB_USER_NAME")
-	password := os.Getenv("DB_PASSWORD")
-	dbHostname := os.Getenv("DB_HOSTNAME")
-	dbPort := os.Getenv("DB_PORT")
-	dbName := os.Getenv("DB_NAME")
-	firebaseProjectId := os.Getenv("FIREBASE_PROJECT_ID")
-	firebaseCredentialsFilePath := os.Getenv("FIREBASE_CREDENTIALS_FILE_PATH")
-
-	r := gin.Default()
-	m := ginmetrics.GetMonitor()
-
-	m.SetMetricPath("/api/v1alpha/metrics")
-	m.SetSlowTime(10)
-	m.SetDuration([]float64{0.1, 0.25, 0.5, 0.75, 1.0, 2.5, 5.0, 7.5, 10.0})
-	m.Use(r)
-
-	r.Use(cors.New(cors.Config{
-		AllowMethods: []string{
-			"GET",
-			"POST",
-			"PUT",
-			"DELETE",
-		},
-		AllowHeaders: []string{
-			"Authorization",
-			"Content-Type",
-			"Content-Length",
-			"Access-Control-Allow-Origin",
-		},
-		AllowOrigins: []string{
-			"http://localhost:3000",
-			"https://local.vsrecorder.mobi",
-		},
-		MaxAge: 24 * time.Hour,
-	}))
-
-	{
-		opt := option.WithCredentialsFile(firebaseCredentialsFilePath)
-		config := &firebase.Config{ProjectID: firebaseProjectId}
-
-		app, err := firebase.NewApp(context.Background(), config, opt)
-		if err != nil {
-			log.Fatalf("error initializing app: %v\n", err)
-		}
+// 会場の座標はスケジューラでも求めるため、取り込んだ直後にすぐ反映したい場合に実行する
+func runGeocode(cfg *config.Config) error {
+	s, err := server.NewServer(cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
 
-		auth, err := app.Auth(context.Background())
-		if err != nil {
-			log.Fatalf("error initializing app: %v\n", err)
-		}
+	located, err := s.GeocodeOfficialEvents(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("located %d official events\n", located)
+
+	return nil
+}
+
+// 集計方法を変更した場合や、直近以外の対戦の変更を環境レポートに反映する場合に実行する
+func runRebuildMeta(cfg *config.Config) error {
+	s, err := server.NewServer(cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	aggregated, err := s.RebuildMeta(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("aggregated %d games\n", aggregated)
+
+	return nil
+}
 
-		db, err := infrastructures.NewMySQL(userName, password, dbHostname, dbPort, dbName)
-		if err != nil {
-			log.Fatalf("failed to connect database: %v", err)
+// migrate (status|up|down|to <version>)
+func runMigrate(dbConfig *config.DatabaseConfig, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate (status|up|down|to <version>)")
+	}
+
+	db, err := infrastructures.NewDatabase(dbConfig)
+	if err != nil {
+		return err
+	}
+	defer infrastructures.CloseDatabase(db)
+
+	migrator, err := infrastructures.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down()
+	case "to":
+		if len(args) < 2 {
+			return errors.New("usage: migrate to <version>")
 		}
 
-		controllers.NewUserController(
-			r,
-			services.NewUserService(
-				repositories.NewUserRepository(auth),
-				repositories.NewRecordRepository(db),
-				repositories.NewGameRepository(db),
-				repositories.NewDeckRepository(db),
-			),
-		).RegisterRoutes("/api/v1alpha")
-	}
-
-	{
-		db, err := infrastructures.NewMySQL(userName, password, dbHostname, dbPort, dbName)
-		if err != nil {
-			log.Fatalf("failed to connect database: %v", err)
+		version, parseErr := strconv.ParseUint(args[1], 10, 64)
+		if parseErr != nil {
+			return parseErr
 		}
 
-		controllers.NewOfficialEventController(
-			r,
-			services.NewOfficialEventService(
-				repositories.NewOfficialEventRepository(db),
-				repositories.NewRecordRepository(db),
-			),
-		).RegisterRoutes("/api/v1alpha")
+		err = migrator.To(uint(version))
+	default:
+		return errors.New("unknown migrate command: " + args[0])
 	}
 
-	{
-		db, err := infrastructures.NewMySQL(userName, password, dbHostname, dbPort, dbName)
-		if err != nil {
-			log.Fatalf("failed to connect database: %v", err)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
 		}
 
-		controllers.NewRecordController(
-			r,
-			services.NewRecordService(
-				repositories.NewRecordRepository(db),
-				repositories.NewGameRepository(db),
-				repositories.NewOfficialEventRepository(db),
-			),
-		).RegisterRoutes("/api/v1alpha")
+		fmt.Printf("%04d %-40s %s\n", status.Version, status.Name, appliedAt)
+	}
+
+	return nil
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
+}
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		slog.Info("failed to load .env file", slog.Any("error", err))
+	}
+
+	// apiserver [-config path] [migrate (status|up|down|to <version>) | geocode | rebuild-meta]
+	loader, args, err := config.NewLoader(os.Args[1:])
+	if err != nil {
+		fatal("failed to parse flags", err)
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fatal("invalid config", err)
 	}
 
-	{
-		db, err := infrastructures.NewMySQL(userName, password, dbHostname, dbPort, dbName)
-		if err != nil {
-			log.Fatalf("failed to connect database: %v", err)
+	logger, err := logging.New(os.Stderr, &cfg.Log)
+	if err != nil {
+		fatal("failed to initialize logger", err)
+	}
+
+	slog.SetDefault(logger)
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(&cfg.Database, args[1:]); err != nil {
+			fatal("failed to migrate", err)
 		}
+		return
+	}
 
-		controllers.NewGameController(
-			r,
-			services.NewGameService(
-				repositories.NewGameRepository(db),
-				repositories.NewRecordRepository(db),
-				repositories.NewBattleRepository(db),
-			),
-		).RegisterRoutes("/api/v1alpha")
+	if len(args) > 0 && args[0] == "geocode" {
+		if err := runGeocode(cfg); err != nil {
+			fatal("failed to geocode official events", err)
+		}
+		return
 	}
 
-	{
-		db, err := infrastructures.NewMySQL(userName, password, dbHostname, dbPort, dbName)
-		if err != nil {
-			log.Fatalf("failed to connect database: %v", err)
+	if len(args) > 0 && args[0] == "rebuild-meta" {
+		if err := runRebuildMeta(cfg); err != nil {
+			fatal("failed to rebuild meta", err)
 		}
+		return
+	}
 
-		controllers.NewDeckController(
-			r,
-			services.NewDeckService(
-				repositories.NewDeckRepository(db),
-				repositories.NewRecordRepository(db),
-			),
-		).RegisterRoutes("/api/v1alpha")
+	shutdownTracing, err := tracing.Setup(context.Background(), &cfg.Tracing)
+	if err != nil {
+		fatal("failed to initialize tracing", err)
 	}
 
-	{
-		db, err := infrastructures.NewMySQL(userName, password, dbHostname, dbPort, dbName)
-		if err != nil {
-			log.Fatalf("failed to connect database: %v", err)
+	s, err := server.NewServer(cfg)
+	if err != nil {
+		fatal("failed to initialize server", err)
+	}
+	defer s.Close()
+
+	// SIGINT・SIGTERMを受け取ると処理中のリクエストを待ってから終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// CORSの許可オリジン、レート制限、読み取り専用モードは設定ファイルを書き換えると再起動せずに反映される
+	go loader.Watch(ctx, config.WATCH_INTERVAL, func(cfg *config.Config) {
+		if err := s.Reload(cfg); err != nil {
+			slog.Error("failed to reload config", slog.Any("error", err))
+			return
 		}
 
-		controllers.NewBattleController(
-			r,
-			services.NewBattleService(
-				repositories.NewBattleRepository(db),
-			),
-		).RegisterRoutes("/api/v1alpha")
+		slog.Info("reloaded config", slog.String("path", loader.Path()))
+	})
+
+	go s.RunScheduler(ctx, server.SCHEDULER_INTERVAL)
+
+	if err := s.Run(ctx); err != nil {
+		fatal("failed to run server", err)
 	}
 
-	if err := r.Run(":8913"); err != nil {
-		log.Fatalf("failed to run server: %v", err)
+	// 未送信のスパンを送信してから終了する
+	flushCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("failed to shutdown tracing", slog.Any("error", err))
 	}
 }
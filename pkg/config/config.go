@@ -0,0 +1,464 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/logging"
+	"github.com/vsrecorder/vsr-apiserver/pkg/tracing"
+)
+
+const (
+	MODE_PRODUCTION  = "production"
+	MODE_DEVELOPMENT = "development"
+
+	DEFAULT_ADDR         = ":8913"
+	DEFAULT_METRICS_PATH = "/api/v1alpha/metrics"
+
+	DEFAULT_READ_TIMEOUT        = time.Duration(15) * time.Second
+	DEFAULT_READ_HEADER_TIMEOUT = time.Duration(5) * time.Second
+	DEFAULT_WRITE_TIMEOUT       = time.Duration(60) * time.Second
+	DEFAULT_IDLE_TIMEOUT        = time.Duration(120) * time.Second
+	DEFAULT_SHUTDOWN_TIMEOUT    = time.Duration(30) * time.Second
+
+	DEFAULT_PAGE_LIMIT = 20
+	MAX_PAGE_LIMIT     = 100
+)
+
+type HTTPConfig struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	// エクスポートのレスポンスには適用しない
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// 終了時に処理中のリクエストを待つ時間
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// X-Forwarded-Forからクライアントのアドレスを取得するロードバランサなどのIPアドレスまたはCIDR
+	// 指定しない場合は接続元のアドレスをクライアントのアドレスとして扱う
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// 再起動せずに反映できる
+type MaintenanceConfig struct {
+	ReadOnly bool `yaml:"read_only"`
+}
+
+type AuthConfig struct {
+	JWTSecret string `yaml:"jwt_secret"`
+	// roleクレームが無くても管理者として扱うユーザ
+	AdminUIDs []string `yaml:"admin_uids"`
+}
+
+type FirebaseConfig struct {
+	ProjectId           string `yaml:"project_id"`
+	CredentialsFilePath string `yaml:"credentials_file_path"`
+}
+
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins"`
+}
+
+type GeocoderConfig struct {
+	// 市区町村の代表点などを記述したJSONファイル
+	// 都道府県庁所在地の座標より住所に長く一致する地名を優先する
+	TablePath string `yaml:"table_path"`
+}
+
+// 開発モードでのみ使う
+type DevelopmentConfig struct {
+	UserFixturePath string `yaml:"user_fixture_path"`
+	SQLFixturePath  string `yaml:"sql_fixture_path"`
+}
+
+type Config struct {
+	Mode        string `yaml:"mode"`
+	Addr        string `yaml:"addr"`
+	MetricsPath string `yaml:"metrics_path"`
+	PageLimit   int    `yaml:"page_limit"`
+
+	Log         logging.Config    `yaml:"log"`
+	Tracing     tracing.Config    `yaml:"tracing"`
+	HTTP        HTTPConfig        `yaml:"http"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Firebase    FirebaseConfig    `yaml:"firebase"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Maintenance MaintenanceConfig `yaml:"maintenance"`
+	Geocoder    GeocoderConfig    `yaml:"geocoder"`
+	Development DevelopmentConfig `yaml:"development"`
+
+	// グループ名と"回数/期間"の形式の制限
+	RateLimits map[string]string `yaml:"rate_limits"`
+}
+
+func Default() *Config {
+	return &Config{
+		Mode:        MODE_PRODUCTION,
+		Addr:        DEFAULT_ADDR,
+		MetricsPath: DEFAULT_METRICS_PATH,
+		PageLimit:   DEFAULT_PAGE_LIMIT,
+		Log: logging.Config{
+			Format: logging.DEFAULT_FORMAT,
+			Level:  logging.DEFAULT_LEVEL,
+		},
+		Tracing: tracing.Config{
+			Exporter:    tracing.EXPORTER_NONE,
+			ServiceName: tracing.DEFAULT_SERVICE_NAME,
+			SampleRatio: tracing.DEFAULT_SAMPLE_RATIO,
+		},
+		HTTP: HTTPConfig{
+			ReadTimeout:       DEFAULT_READ_TIMEOUT,
+			ReadHeaderTimeout: DEFAULT_READ_HEADER_TIMEOUT,
+			WriteTimeout:      DEFAULT_WRITE_TIMEOUT,
+			IdleTimeout:       DEFAULT_IDLE_TIMEOUT,
+			ShutdownTimeout:   DEFAULT_SHUTDOWN_TIMEOUT,
+		},
+		Database: DatabaseConfig{
+			Driver:          DB_DRIVER_MYSQL,
+			Path:            SQLITE_IN_MEMORY,
+			MaxOpenConns:    DEFAULT_MAX_OPEN_CONNS,
+			MaxIdleConns:    DEFAULT_MAX_IDLE_CONNS,
+			ConnMaxLifetime: DEFAULT_CONN_MAX_LIFETIME,
+
+			QueryTimeout:       DEFAULT_QUERY_TIMEOUT,
+			SlowQueryThreshold: DEFAULT_SLOW_QUERY_THRESHOLD,
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{
+				"http://localhost:3000",
+				"https://local.vsrecorder.mobi",
+			},
+		},
+		Development: DevelopmentConfig{
+			UserFixturePath: "configs/users.example.json",
+		},
+		RateLimits: map[string]string{
+			RATE_LIMIT_GROUP_READ:  "300/1m",
+			RATE_LIMIT_GROUP_WRITE: "60/1m",
+			RATE_LIMIT_GROUP_USERS: "30/1m",
+		},
+	}
+}
+
+// 設定はデフォルト値、設定ファイル、環境変数、コマンドラインフラグの順に上書きする
+// 設定ファイルを読み直す場合も同じ環境変数とフラグを適用する
+type Loader struct {
+	path  string
+	flags map[string]string
+}
+
+// フラグ以降の引数(サブコマンド)を合わせて返す
+func NewLoader(args []string) (*Loader, []string, error) {
+	fs := flag.NewFlagSet("apiserver", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("VSRECORDER_CONFIG"), "path to the YAML config file")
+	fs.String("mode", "", "production or development")
+	fs.String("addr", "", "address to listen on")
+	fs.String("db-driver", "", "mysql or sqlite")
+	fs.String("db-path", "", "path to the SQLite database file")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	flags := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+
+	return &Loader{*path, flags}, fs.Args(), nil
+}
+
+func (l *Loader) Path() string {
+	return l.path
+}
+
+func (l *Loader) Load() (*Config, error) {
+	config := Default()
+
+	if l.path != "" {
+		b, err := os.ReadFile(l.path)
+		if err != nil {
+			return nil, err
+		}
+
+		// 未知のキーは設定ミスとして扱う
+		decoder := yaml.NewDecoder(bytes.NewReader(b))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse %s: %w", l.path, err)
+		}
+	}
+
+	if err := config.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	config.applyFlags(l.flags)
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+func (c *Config) applyEnv() error {
+	for key, dst := range map[string]*string{
+		"VSRECORDER_MODE":                &c.Mode,
+		"VSRECORDER_ADDR":                &c.Addr,
+		"VSRECORDER_METRICS_PATH":        &c.MetricsPath,
+		"VSRECORDER_LOG_FORMAT":          &c.Log.Format,
+		"VSRECORDER_LOG_LEVEL":           &c.Log.Level,
+		"VSRECORDER_TRACING_EXPORTER":    &c.Tracing.Exporter,
+		"VSRECORDER_TRACING_FILE_PATH":   &c.Tracing.FilePath,
+		"OTEL_SERVICE_NAME":              &c.Tracing.ServiceName,
+		"OTEL_EXPORTER_OTLP_ENDPOINT":    &c.Tracing.Endpoint,
+		"DB_DRIVER":                      &c.Database.Driver,
+		"DB_USER_NAME":                   &c.Database.UserName,
+		"DB_PASSWORD":                    &c.Database.Password,
+		"DB_HOSTNAME":                    &c.Database.Hostname,
+		"DB_PORT":                        &c.Database.Port,
+		"DB_NAME":                        &c.Database.Name,
+		"DB_PATH":                        &c.Database.Path,
+		"VSRECORDER_JWT_SECRET":          &c.Auth.JWTSecret,
+		"FIREBASE_PROJECT_ID":            &c.Firebase.ProjectId,
+		"FIREBASE_CREDENTIALS_FILE_PATH": &c.Firebase.CredentialsFilePath,
+		"VSRECORDER_USER_FIXTURE":        &c.Development.UserFixturePath,
+		"VSRECORDER_SQL_FIXTURE":         &c.Development.SQLFixturePath,
+		"VSRECORDER_GEOCODER_TABLE":      &c.Geocoder.TablePath,
+	} {
+		if value := os.Getenv(key); value != "" {
+			*dst = value
+		}
+	}
+
+	for key, dst := range map[string]*int{
+		"VSRECORDER_PAGE_LIMIT": &c.PageLimit,
+		"DB_MAX_OPEN_CONNS":     &c.Database.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS":     &c.Database.MaxIdleConns,
+	} {
+		if value := os.Getenv(key); value != "" {
+			i, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s", key, value)
+			}
+
+			*dst = i
+		}
+	}
+
+	for key, dst := range map[string]*time.Duration{
+		"DB_CONN_MAX_LIFETIME":        &c.Database.ConnMaxLifetime,
+		"DB_QUERY_TIMEOUT":            &c.Database.QueryTimeout,
+		"DB_SLOW_QUERY_THRESHOLD":     &c.Database.SlowQueryThreshold,
+		"HTTP_READ_TIMEOUT":           &c.HTTP.ReadTimeout,
+		"HTTP_READ_HEADER_TIMEOUT":    &c.HTTP.ReadHeaderTimeout,
+		"HTTP_WRITE_TIMEOUT":          &c.HTTP.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":           &c.HTTP.IdleTimeout,
+		"VSRECORDER_SHUTDOWN_TIMEOUT": &c.HTTP.ShutdownTimeout,
+	} {
+		if value := os.Getenv(key); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s", key, value)
+			}
+
+			*dst = d
+		}
+	}
+
+	if value := os.Getenv("VSRECORDER_TRACING_SAMPLE_RATIO"); value != "" {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid VSRECORDER_TRACING_SAMPLE_RATIO: %s", value)
+		}
+
+		c.Tracing.SampleRatio = f
+	}
+
+	if value := os.Getenv("VSRECORDER_READ_ONLY"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid VSRECORDER_READ_ONLY: %s", value)
+		}
+
+		c.Maintenance.ReadOnly = b
+	}
+
+	if value := os.Getenv("VSRECORDER_ADMIN_UIDS"); value != "" {
+		c.Auth.AdminUIDs = splitList(value)
+	}
+
+	if value := os.Getenv("VSRECORDER_CORS_ALLOW_ORIGINS"); value != "" {
+		c.CORS.AllowOrigins = splitList(value)
+	}
+
+	if value := os.Getenv("VSRECORDER_TRUSTED_PROXIES"); value != "" {
+		c.HTTP.TrustedProxies = splitList(value)
+	}
+
+	for group, key := range map[string]string{
+		RATE_LIMIT_GROUP_READ:  "RATE_LIMIT_READ",
+		RATE_LIMIT_GROUP_WRITE: "RATE_LIMIT_WRITE",
+		RATE_LIMIT_GROUP_USERS: "RATE_LIMIT_USERS",
+	} {
+		if value := os.Getenv(key); value != "" {
+			if c.RateLimits == nil {
+				c.RateLimits = map[string]string{}
+			}
+
+			c.RateLimits[group] = value
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) applyFlags(flags map[string]string) {
+	for name, dst := range map[string]*string{
+		"mode":      &c.Mode,
+		"addr":      &c.Addr,
+		"db-driver": &c.Database.Driver,
+		"db-path":   &c.Database.Path,
+	} {
+		if value, ok := flags[name]; ok {
+			*dst = value
+		}
+	}
+}
+
+// localhostまたはループバックアドレスのみで待ち受けるか
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// 起動時に全ての設定を検証し、不正な場合は起動しない
+func (c *Config) Validate() error {
+	errs := []error{}
+
+	if c.Mode != MODE_PRODUCTION && c.Mode != MODE_DEVELOPMENT {
+		errs = append(errs, fmt.Errorf("invalid mode: %s", c.Mode))
+	}
+
+	if c.Addr == "" {
+		errs = append(errs, errors.New("addr is required"))
+	}
+
+	// 開発モードは任意のユーザのJWTを発行できるため、ローカルのSQLiteでのみ起動する
+	if c.Mode == MODE_DEVELOPMENT {
+		if c.Database.Driver != DB_DRIVER_SQLITE {
+			errs = append(errs, errors.New("development mode requires database.driver sqlite"))
+		}
+
+		if !isLoopbackAddr(c.Addr) {
+			errs = append(errs, fmt.Errorf("development mode requires a loopback addr: %s", c.Addr))
+		}
+	}
+
+	if !strings.HasPrefix(c.MetricsPath, "/") {
+		errs = append(errs, fmt.Errorf("invalid metrics_path: %s", c.MetricsPath))
+	}
+
+	if c.PageLimit <= 0 || c.PageLimit > MAX_PAGE_LIMIT {
+		errs = append(errs, fmt.Errorf("page_limit must be between 1 and %d", MAX_PAGE_LIMIT))
+	}
+
+	if _, err := logging.New(io.Discard, &c.Log); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := c.Tracing.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.HTTP.ReadTimeout < 0 || c.HTTP.ReadHeaderTimeout < 0 || c.HTTP.WriteTimeout < 0 || c.HTTP.IdleTimeout < 0 {
+		errs = append(errs, errors.New("http timeouts must not be negative"))
+	}
+
+	if c.HTTP.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("http.shutdown_timeout must be positive"))
+	}
+
+	for _, proxy := range c.HTTP.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			errs = append(errs, fmt.Errorf("invalid http.trusted_proxies: %s", proxy))
+		}
+	}
+
+	switch c.Database.Driver {
+	case DB_DRIVER_MYSQL:
+		if c.Database.Hostname == "" || c.Database.Name == "" {
+			errs = append(errs, errors.New("database.hostname and database.name are required"))
+		}
+	case DB_DRIVER_SQLITE:
+		if c.Database.Path == "" {
+			errs = append(errs, errors.New("database.path is required"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid database.driver: %s", c.Database.Driver))
+	}
+
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.ConnMaxLifetime < 0 ||
+		c.Database.QueryTimeout < 0 || c.Database.SlowQueryThreshold < 0 {
+		errs = append(errs, errors.New("database pool and query settings must not be negative"))
+	}
+
+	if c.Auth.JWTSecret == "" {
+		errs = append(errs, errors.New("auth.jwt_secret is required"))
+	}
+
+	if c.Mode == MODE_PRODUCTION && c.Firebase.CredentialsFilePath == "" {
+		errs = append(errs, errors.New("firebase.credentials_file_path is required"))
+	}
+
+	for _, origin := range c.CORS.AllowOrigins {
+		if origin == "*" {
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("invalid cors.allow_origins: %s", origin))
+		}
+	}
+
+	for group, value := range c.RateLimits {
+		if _, err := ParseRateLimit(value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid rate_limits.%s: %w", group, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
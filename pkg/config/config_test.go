@@ -0,0 +1,150 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// 開発モードで起動できる最小限の設定
+const DEVELOPMENT_CONFIG = "mode: development\naddr: localhost:8913\nauth: {jwt_secret: secret}\ndatabase: {driver: sqlite}\n"
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func TestLoader(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"Example":    test_LoaderExample,
+		"Precedence": test_LoaderPrecedence,
+		"Invalid":    test_LoaderInvalid,
+		"Watch":      test_LoaderWatch,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func test_LoaderExample(t *testing.T) {
+	t.Setenv("VSRECORDER_JWT_SECRET", "secret")
+
+	loader, args, err := NewLoader([]string{"-config", "../../configs/config.example.yaml", "migrate", "up"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"migrate", "up"}, args)
+
+	config, err := loader.Load()
+	require.NoError(t, err)
+	require.Equal(t, ":8913", config.Addr)
+	require.Equal(t, 5*time.Minute, config.Database.ConnMaxLifetime)
+	require.Equal(t, "60/1m", config.RateLimits["write"])
+	require.Equal(t, 30*time.Second, config.HTTP.ShutdownTimeout)
+	require.False(t, config.Maintenance.ReadOnly)
+}
+
+func test_LoaderPrecedence(t *testing.T) {
+	path := writeConfig(t, `
+mode: development
+addr: "localhost:8080"
+page_limit: 50
+auth:
+  jwt_secret: secret
+database:
+  driver: sqlite
+  path: file.db
+cors:
+  allow_origins: ["https://vsrecorder.mobi"]
+`)
+
+	t.Setenv("VSRECORDER_ADDR", "127.0.0.1:9090")
+	t.Setenv("VSRECORDER_CORS_ALLOW_ORIGINS", "https://a.example, https://b.example")
+	t.Setenv("RATE_LIMIT_READ", "10/1s")
+
+	loader, _, err := NewLoader([]string{"-config", path, "-db-path", ":memory:"})
+	require.NoError(t, err)
+
+	config, err := loader.Load()
+	require.NoError(t, err)
+
+	// 設定ファイル
+	require.Equal(t, MODE_DEVELOPMENT, config.Mode)
+	require.Equal(t, 50, config.PageLimit)
+	// 環境変数
+	require.Equal(t, "127.0.0.1:9090", config.Addr)
+	require.Equal(t, []string{"https://a.example", "https://b.example"}, config.CORS.AllowOrigins)
+	require.Equal(t, "10/1s", config.RateLimits["read"])
+	require.Equal(t, "60/1m", config.RateLimits["write"])
+	// フラグ
+	require.Equal(t, ":memory:", config.Database.Path)
+}
+
+func test_LoaderInvalid(t *testing.T) {
+	for scenario, content := range map[string]string{
+		"UnknownKey":  DEVELOPMENT_CONFIG + "unknown: true\n",
+		"Mode":        "mode: staging\n",
+		"PageLimit":   DEVELOPMENT_CONFIG + "page_limit: 1000\n",
+		"Driver":      "database: {driver: postgres}\nauth: {jwt_secret: secret}\nfirebase: {credentials_file_path: credentials.json}\n",
+		"CORS":        DEVELOPMENT_CONFIG + "cors: {allow_origins: [localhost]}\n",
+		"RateLimit":   DEVELOPMENT_CONFIG + "rate_limits: {read: fast}\n",
+		"JWTSecret":   "database: {driver: sqlite}\nfirebase: {credentials_file_path: credentials.json}\n",
+		"Credentials": "database: {driver: sqlite}\nauth: {jwt_secret: secret}\n",
+		"LogLevel":    DEVELOPMENT_CONFIG + "log: {level: verbose}\n",
+		"Tracing":     DEVELOPMENT_CONFIG + "tracing: {exporter: otlp}\n",
+		"Shutdown":    DEVELOPMENT_CONFIG + "http: {shutdown_timeout: 0s}\n",
+		"Proxies":     DEVELOPMENT_CONFIG + "http: {trusted_proxies: [loadbalancer]}\n",
+		// 開発モードはローカルのSQLiteでのみ起動でき、JWTの署名鍵も省略できない
+		"DevelopmentMySQL":     "mode: development\naddr: localhost:8913\nauth: {jwt_secret: secret}\ndatabase: {driver: mysql, hostname: db, name: vsrecorder}\n",
+		"DevelopmentAddr":      "mode: development\naddr: \":8913\"\nauth: {jwt_secret: secret}\ndatabase: {driver: sqlite}\n",
+		"DevelopmentJWTSecret": "mode: development\naddr: localhost:8913\ndatabase: {driver: sqlite}\n",
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			loader, _, err := NewLoader([]string{"-config", writeConfig(t, content)})
+			require.NoError(t, err)
+
+			_, err = loader.Load()
+			require.Error(t, err)
+		})
+	}
+}
+
+func test_LoaderWatch(t *testing.T) {
+	path := writeConfig(t, DEVELOPMENT_CONFIG)
+
+	loader, _, err := NewLoader([]string{"-config", path})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan *Config, 1)
+	go loader.Watch(ctx, 10*time.Millisecond, func(config *Config) {
+		changed <- config
+	})
+
+	// 不正な設定は反映されない
+	require.NoError(t, os.WriteFile(path, []byte(DEVELOPMENT_CONFIG+"rate_limits: {read: fast}\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+
+	select {
+	case <-changed:
+		t.Fatal("invalid config must not be applied")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte(DEVELOPMENT_CONFIG+"rate_limits: {read: 1/1s}\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+
+	select {
+	case config := <-changed:
+		require.Equal(t, "1/1s", config.RateLimits["read"])
+	case <-time.After(time.Second):
+		t.Fatal("config was not reloaded")
+	}
+}
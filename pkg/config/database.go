@@ -0,0 +1,41 @@
+package config
+
+import (
+	"time"
+)
+
+const (
+	DB_DRIVER_MYSQL  = "mysql"
+	DB_DRIVER_SQLITE = "sqlite"
+
+	SQLITE_IN_MEMORY = ":memory:"
+
+	DEFAULT_MAX_OPEN_CONNS    = 25
+	DEFAULT_MAX_IDLE_CONNS    = 10
+	DEFAULT_CONN_MAX_LIFETIME = time.Duration(5) * time.Minute
+
+	DEFAULT_QUERY_TIMEOUT        = time.Duration(30) * time.Second
+	DEFAULT_SLOW_QUERY_THRESHOLD = time.Duration(200) * time.Millisecond
+)
+
+type DatabaseConfig struct {
+	Driver   string `yaml:"driver"`
+	UserName string `yaml:"user_name"`
+	Password string `yaml:"password"`
+	Hostname string `yaml:"hostname"`
+	Port     string `yaml:"port"`
+	Name     string `yaml:"name"`
+	// SQLiteのデータベースファイルのパス(":memory:"の場合はインメモリ)
+	Path string `yaml:"path"`
+
+	// コネクションプールの設定(SQLiteでは使わない)
+	// 0の場合はデフォルト値を使う
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+
+	// 1リクエストで実行するクエリの制限時間(0の場合は制限しない)
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+	// これより時間のかかったクエリを警告として出力する
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RATE_LIMIT_GROUP_READ  = "read"
+	RATE_LIMIT_GROUP_WRITE = "write"
+	RATE_LIMIT_GROUP_USERS = "users"
+)
+
+// Period毎にRate回までリクエストを受け付ける(バースト可能な回数もRate回)
+type RateLimit struct {
+	Rate   int
+	Period time.Duration
+}
+
+// "60/1m" のような形式の文字列をRateLimitに変換する
+func ParseRateLimit(value string) (RateLimit, error) {
+	rate, period, found := strings.Cut(value, "/")
+	if !found {
+		return RateLimit{}, errors.New("invalid rate limit: " + value)
+	}
+
+	r, err := strconv.Atoi(rate)
+	if err != nil || r <= 0 {
+		return RateLimit{}, errors.New("invalid rate limit: " + value)
+	}
+
+	p, err := time.ParseDuration(period)
+	if err != nil || p <= 0 {
+		return RateLimit{}, errors.New("invalid rate limit: " + value)
+	}
+
+	return RateLimit{Rate: r, Period: p}, nil
+}
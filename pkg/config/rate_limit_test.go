@@ -0,0 +1,19 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	limit, err := ParseRateLimit("60/1m")
+	require.NoError(t, err)
+	require.Equal(t, RateLimit{Rate: 60, Period: time.Minute}, limit)
+
+	for _, value := range []string{"", "60", "0/1m", "a/1m", "60/a", "60/-1m"} {
+		_, err := ParseRateLimit(value)
+		require.Error(t, err)
+	}
+}
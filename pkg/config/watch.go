@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	WATCH_INTERVAL = time.Duration(10) * time.Second
+)
+
+// 設定ファイルの更新を定期的に確認し、読み直した設定をonChangeに渡す
+// 不正な設定に書き換えられた場合は何もしない
+func (l *Loader) Watch(ctx context.Context, interval time.Duration, onChange func(*Config)) {
+	if l.path == "" {
+		return
+	}
+
+	modTime := time.Time{}
+	if info, err := os.Stat(l.path); err == nil {
+		modTime = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(l.path)
+		if err != nil || info.ModTime().Equal(modTime) {
+			continue
+		}
+
+		modTime = info.ModTime()
+
+		config, err := l.Load()
+		if err != nil {
+			slog.Error("failed to reload config", slog.String("path", l.path), slog.Any("error", err))
+			continue
+		}
+
+		onChange(config)
+	}
+}
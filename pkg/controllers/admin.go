@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	ADMIN_PATH = "/admin"
+	STATS_PATH = "/stats"
+	OWNER_PATH = "/owner"
+)
+
+type AdminController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.AdminServiceInterface
+}
+
+func NewAdminController(
+	router *gin.Engine,
+	common *Common,
+	service services.AdminServiceInterface,
+) *AdminController {
+	return &AdminController{router, common, service}
+}
+
+func (c *AdminController) RegisterRoutes(relativePath string) {
+	// 運営者は参照と削除のみ行える
+	{
+		r := c.router.Group(relativePath + ADMIN_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredSessionAuthorization)
+		r.Use(c.common.Authorization.RequiredRole(helpers.ROLE_ADMIN, helpers.ROLE_MODERATOR))
+		r.GET(USERS_PATH+"/:id"+RECORDS_PATH, c.GetRecordsByUserId)
+		r.GET(USERS_PATH+"/:id"+GAMES_PATH, c.GetGamesByUserId)
+		r.GET(USERS_PATH+"/:id"+DECKS_PATH, c.GetDecksByUserId)
+		r.GET(RECORDS_PATH+"/:id", c.GetRecordById)
+		r.GET(GAMES_PATH+"/:id", c.GetGameById)
+		r.GET(DECKS_PATH+"/:id", c.GetDeckById)
+		r.DELETE(RECORDS_PATH+"/:id", c.DeleteRecord)
+		r.DELETE(GAMES_PATH+"/:id", c.DeleteGame)
+		r.DELETE(DECKS_PATH+"/:id", c.DeleteDeck)
+	}
+
+	{
+		r := c.router.Group(relativePath + ADMIN_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredSessionAuthorization)
+		r.Use(c.common.Authorization.RequiredRole(helpers.ROLE_ADMIN))
+		r.PUT(RECORDS_PATH+"/:id"+OWNER_PATH, c.ReassignRecord)
+		r.PUT(DECKS_PATH+"/:id"+OWNER_PATH, c.ReassignDeck)
+		r.GET(STATS_PATH, c.GetStats)
+	}
+}
+
+func (c *AdminController) GetRecordsByUserId(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindRecordsByUserId(ctx, id, uid)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *AdminController) GetGamesByUserId(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindGamesByUserId(ctx, id, uid)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *AdminController) GetDecksByUserId(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindDecksByUserId(ctx, id, uid)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *AdminController) GetRecordById(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindRecordById(ctx, id, uid)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *AdminController) GetGameById(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindGameById(ctx, id, uid)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *AdminController) GetDeckById(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindDeckById(ctx, id, uid)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *AdminController) DeleteRecord(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	if err := c.service.DeleteRecord(ctx, id, uid); err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "accepted",
+	})
+}
+
+func (c *AdminController) DeleteGame(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	if err := c.service.DeleteGame(ctx, id, uid); err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "accepted",
+	})
+}
+
+func (c *AdminController) DeleteDeck(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	if err := c.service.DeleteDeck(ctx, id, uid); err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "accepted",
+	})
+}
+
+func (c *AdminController) ReassignRecord(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+	dto := dtos.Owner{}
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.ReassignRecord(ctx, id, uid, &dto)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *AdminController) ReassignDeck(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+	dto := dtos.Owner{}
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.ReassignDeck(ctx, id, uid, &dto)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *AdminController) GetStats(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindStats(ctx, uid)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	AUDIT_PATH = "/audit"
+)
+
+type AuditLogController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.AuditLogServiceInterface
+}
+
+func NewAuditLogController(
+	router *gin.Engine,
+	common *Common,
+	service services.AuditLogServiceInterface,
+) *AuditLogController {
+	return &AuditLogController{router, common, service}
+}
+
+func (c *AuditLogController) RegisterRoutes(relativePath string) {
+	{
+		r := c.router.Group(relativePath + USERS_PATH + "/me" + AUDIT_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredSessionAuthorization)
+		r.GET("", c.GetMe)
+	}
+
+	{
+		r := c.router.Group(relativePath + AUDIT_PATH)
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.Authorization.RequiredSessionAuthorization)
+		r.Use(c.common.Authorization.RequiredAdministrator)
+		r.GET("", c.Get)
+	}
+}
+
+func (c *AuditLogController) GetMe(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	page, err := ParsePage(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	offset := c.common.PageLimit * (page - 1)
+
+	startDate, endDate, err := c.common.ParseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.FindByUID(ctx, uid, startDate, endDate, c.common.PageLimit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"page":       page,
+		"limit":      c.common.PageLimit,
+		"offset":     offset,
+		"audit_logs": ret,
+	})
+}
+
+func (c *AuditLogController) Get(ctx *gin.Context) {
+	page, err := ParsePage(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	offset := c.common.PageLimit * (page - 1)
+
+	startDate, endDate, err := c.common.ParseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.Find(
+		ctx,
+		helpers.GetEntity(ctx),
+		helpers.GetEntityId(ctx),
+		helpers.GetActorUID(ctx),
+		startDate,
+		endDate,
+		c.common.PageLimit,
+		offset,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"page":       page,
+		"limit":      c.common.PageLimit,
+		"offset":     offset,
+		"audit_logs": ret,
+	})
+}
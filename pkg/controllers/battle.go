@@ -4,9 +4,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
-	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/middlewares"
 	"github.com/vsrecorder/vsr-apiserver/pkg/services"
 )
 
@@ -16,20 +16,25 @@ const (
 
 type BattleController struct {
 	router  *gin.Engine
+	common  *Common
 	service services.BattleServiceInterface
 }
 
 func NewBattleController(
 	router *gin.Engine,
+	common *Common,
 	service services.BattleServiceInterface,
 ) *BattleController {
-	return &BattleController{router, service}
+	return &BattleController{router, common, service}
 }
 
 func (c *BattleController) RegisterRoutes(relativePath string) {
 	{
 		r := c.router.Group(relativePath + BATTLES_PATH)
-		r.Use(middlewares.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_WRITE))
 		r.POST("", c.Create)
 		r.PUT("/:id", c.Update)
 		r.DELETE("/:id", c.Delete)
@@ -37,17 +42,21 @@ func (c *BattleController) RegisterRoutes(relativePath string) {
 
 	{
 		r := c.router.Group(relativePath + BATTLES_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_READ))
 		r.GET("/:id", c.GetById)
 	}
 }
 
 func (c *BattleController) GetById(ctx *gin.Context) {
 	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
 
-	ret, err := c.service.FindById(ctx, id)
-
+	ret, err := c.service.FindByIdWithUID(ctx, id, uid)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		ctx.AbortWithStatusJSON(findErrorStatus(err), gin.H{
 			"message": err.Error(),
 		})
 		return
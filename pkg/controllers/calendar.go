@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	ICALENDAR_EXTENSION = ".ics"
+
+	CALENDAR_PATH       = "/calendar" + ICALENDAR_EXTENSION
+	CALENDAR_TOKEN_PATH = "/calendar_token"
+
+	// 1つのフィードに載せる大会数の上限
+	ICALENDAR_EVENT_LIMIT = 500
+
+	// RFC 5545で1行の長さの上限とされているオクテット数
+	ICALENDAR_LINE_OCTETS = 75
+
+	ICALENDAR_DATE_LAYOUT     = "20060102"
+	ICALENDAR_DATETIME_LAYOUT = "20060102T150405Z"
+
+	OFFICIAL_EVENTS_CALENDAR_NAME = "バトレコ 公式大会"
+	USER_CALENDAR_NAME            = "バトレコ 参加予定"
+)
+
+var (
+	iCalendarEscaper = strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+	)
+
+	attendanceStatusLabels = map[string]string{
+		services.ATTENDANCE_STATUS_PLANNED:  "参加予定",
+		services.ATTENDANCE_STATUS_ENTERED:  "エントリー済み",
+		services.ATTENDANCE_STATUS_ATTENDED: "参加済み",
+	}
+)
+
+type iCalendarEvent struct {
+	officialEvent *oem.OfficialEvent
+	description   string
+}
+
+// 1行が75オクテットを超える場合は、マルチバイト文字の途中で区切らないように折り返す
+func foldICalendarLine(line string) string {
+	b := strings.Builder{}
+
+	octets := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if octets+size > ICALENDAR_LINE_OCTETS {
+			b.WriteString("\r\n ")
+			octets = 1
+		}
+
+		b.WriteRune(r)
+		octets += size
+	}
+
+	return b.String()
+}
+
+// 開始時刻が分からない大会は終日の予定にする
+// 取り込み元によっては時刻のみが入っているため、日付は開催日から取る
+func iCalendarEventTimes(officialEvent *oem.OfficialEvent) []string {
+	date := officialEvent.Date
+
+	if officialEvent.StartedAt.IsZero() {
+		return []string{
+			"DTSTART;VALUE=DATE:" + date.Format(ICALENDAR_DATE_LAYOUT),
+			"DTEND;VALUE=DATE:" + date.AddDate(0, 0, 1).Format(ICALENDAR_DATE_LAYOUT),
+		}
+	}
+
+	at := func(t time.Time) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, date.Location())
+	}
+
+	startedAt := at(officialEvent.StartedAt)
+	lines := []string{
+		"DTSTART:" + startedAt.UTC().Format(ICALENDAR_DATETIME_LAYOUT),
+	}
+
+	if !officialEvent.EndedAt.IsZero() {
+		if endedAt := at(officialEvent.EndedAt); endedAt.After(startedAt) {
+			lines = append(lines, "DTEND:"+endedAt.UTC().Format(ICALENDAR_DATETIME_LAYOUT))
+		}
+	}
+
+	return lines
+}
+
+func renderICalendar(name string, events []*iCalendarEvent, now time.Time) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//vsrecorder//vsr-apiserver//JA",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + iCalendarEscaper.Replace(name),
+		"X-WR-TIMEZONE:" + services.DEFAULT_TIME_ZONE,
+	}
+
+	dtstamp := now.UTC().Format(ICALENDAR_DATETIME_LAYOUT)
+
+	for _, event := range events {
+		officialEvent := event.officialEvent
+
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:official-event-%d@vsrecorder", officialEvent.Id),
+			"DTSTAMP:"+dtstamp,
+		)
+		lines = append(lines, iCalendarEventTimes(officialEvent)...)
+		lines = append(lines, "SUMMARY:"+iCalendarEscaper.Replace(officialEvent.Title))
+
+		if location := strings.TrimSpace(officialEvent.Venue + " " + officialEvent.Address); location != "" {
+			lines = append(lines, "LOCATION:"+iCalendarEscaper.Replace(location))
+		}
+
+		if event.description != "" {
+			lines = append(lines, "DESCRIPTION:"+iCalendarEscaper.Replace(event.description))
+		}
+
+		lines = append(lines, "END:VEVENT")
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	b := strings.Builder{}
+	for _, line := range lines {
+		b.WriteString(foldICalendarLine(line))
+		b.WriteString("\r\n")
+	}
+
+	return b.String()
+}
+
+func writeICalendar(ctx *gin.Context, name string, events []*iCalendarEvent) {
+	ctx.Header("Content-Disposition", "inline; filename=\"calendar"+ICALENDAR_EXTENSION+"\"")
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(renderICalendar(name, events, time.Now())))
+}
+
+type CalendarController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.CalendarServiceInterface
+}
+
+func NewCalendarController(
+	router *gin.Engine,
+	common *Common,
+	service services.CalendarServiceInterface,
+) *CalendarController {
+	return &CalendarController{router, common, service}
+}
+
+func (c *CalendarController) RegisterRoutes(relativePath string) {
+	// カレンダーアプリはAuthorizationヘッダを送れないため、URLに含めたトークンで認証する
+	c.router.GET(
+		relativePath+USERS_PATH+"/me"+CALENDAR_PATH,
+		c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ),
+		c.GetMe,
+	)
+
+	r := c.router.Group(relativePath + USERS_PATH + "/me" + CALENDAR_TOKEN_PATH)
+	r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+	r.Use(c.common.Authorization.RequiredAuthorization)
+	r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+	r.Use(c.common.Authorization.RequiredSessionAuthorization)
+	r.POST("", c.CreateToken)
+	r.DELETE("", c.DeleteToken)
+}
+
+func (c *CalendarController) GetMe(ctx *gin.Context) {
+	uid, err := c.service.Verify(ctx, helpers.GetToken(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	ret, err := c.service.FindEventsByUID(ctx, uid)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	events := []*iCalendarEvent{}
+	for _, event := range ret {
+		descriptions := []string{}
+		if label, ok := attendanceStatusLabels[event.Status]; ok {
+			descriptions = append(descriptions, label)
+		}
+		if event.RecordId != "" {
+			descriptions = append(descriptions, "Record: "+event.RecordId)
+		}
+
+		events = append(events, &iCalendarEvent{
+			officialEvent: event.OfficialEvent,
+			description:   strings.Join(descriptions, "\n"),
+		})
+	}
+
+	writeICalendar(ctx, USER_CALENDAR_NAME, events)
+}
+
+// 購読URL(calendar.ics?token=...)のトークンを発行し直し、以前のトークンを無効にする
+// トークンを返すのは発行時の1回のみ
+func (c *CalendarController) CreateToken(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	token, err := c.service.CreateToken(ctx, uid)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"token": token,
+	})
+}
+
+func (c *CalendarController) DeleteToken(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	if err := c.service.DeleteToken(ctx, uid); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "accepted",
+	})
+}
@@ -4,9 +4,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
-	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/middlewares"
 	"github.com/vsrecorder/vsr-apiserver/pkg/services"
 )
 
@@ -16,34 +16,42 @@ const (
 
 type DeckController struct {
 	router  *gin.Engine
+	common  *Common
 	service services.DeckServiceInterface
 }
 
 func NewDeckController(
 	router *gin.Engine,
+	common *Common,
 	service services.DeckServiceInterface,
 ) *DeckController {
-	return &DeckController{router, service}
+	return &DeckController{router, common, service}
 }
 
 func (c *DeckController) RegisterRoutes(relativePath string) {
 	{
 		r := c.router.Group(relativePath + DECKS_PATH)
-		r.Use(middlewares.RequiredAuthorization)
-		r.GET("", c.Get)
-		r.POST("", c.Create)
-		r.PUT("/:id", c.Update)
-		r.DELETE("/:id", c.Delete)
+		r.GET("", c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ), c.common.Authorization.RequiredAuthorization, c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ), c.common.Authorization.RequiredScope(helpers.SCOPE_DECKS_READ), c.Get)
+		r.POST("", c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE), c.common.Authorization.RequiredAuthorization, c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE), c.common.Authorization.RequiredScope(helpers.SCOPE_DECKS_WRITE), c.Create)
+		r.PUT("/:id", c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE), c.common.Authorization.RequiredAuthorization, c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE), c.common.Authorization.RequiredScope(helpers.SCOPE_DECKS_WRITE), c.Update)
+		r.DELETE("/:id", c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE), c.common.Authorization.RequiredAuthorization, c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE), c.common.Authorization.RequiredScope(helpers.SCOPE_DECKS_WRITE), c.Delete)
 	}
 
 	{
 		r := c.router.Group(relativePath + DECKS_PATH)
-		r.Use(middlewares.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_DECKS_READ))
 		r.GET("/:id", c.GetById)
 	}
 
 	{
 		r := c.router.Group(relativePath + DECKS_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_READ))
 		r.GET("/:id"+RECORDS_PATH, c.GetRecordById)
 	}
 }
@@ -59,6 +67,19 @@ func (c *DeckController) Get(ctx *gin.Context) {
 		return
 	}
 
+	startDate, endDate, err := c.common.ParseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	// 日付で絞り込む場合は1ページ目を返す
+	if page == 0 && (!startDate.IsZero() || !endDate.IsZero()) {
+		page = 1
+	}
+
 	if page == 0 {
 		ret, err := c.service.FindAllByUID(ctx, uid)
 
@@ -71,9 +92,9 @@ func (c *DeckController) Get(ctx *gin.Context) {
 
 		ctx.JSON(http.StatusOK, ret)
 	} else {
-		offset := PAGE_LIMIT * (page - 1)
+		offset := c.common.PageLimit * (page - 1)
 
-		ret, err := c.service.FindByUID(ctx, uid, PAGE_LIMIT, offset)
+		ret, err := c.service.FindByUID(ctx, uid, startDate, endDate, c.common.PageLimit, offset)
 
 		if err != nil {
 			ctx.JSON(http.StatusNotFound, gin.H{
@@ -84,7 +105,7 @@ func (c *DeckController) Get(ctx *gin.Context) {
 
 		ctx.JSON(http.StatusOK, gin.H{
 			"page":   page,
-			"limit":  PAGE_LIMIT,
+			"limit":  c.common.PageLimit,
 			"offset": offset,
 			"decks":  ret,
 		})
@@ -110,10 +131,11 @@ func (c *DeckController) GetById(ctx *gin.Context) {
 
 func (c *DeckController) GetRecordById(ctx *gin.Context) {
 	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
 
-	ret, err := c.service.FindRecordById(ctx, id)
+	ret, err := c.service.FindRecordByIdWithUID(ctx, id, uid)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(findErrorStatus(err), gin.H{
 			"message": err.Error(),
 		})
 		return
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	DEVELOPMENT_PATH = "/dev"
+	TOKENS_PATH      = "/tokens"
+)
+
+// 開発モードでのみ登録する
+// フィクスチャに登録されたユーザとしてテスト用のJWTを発行する
+type DevelopmentController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.DevelopmentServiceInterface
+}
+
+func NewDevelopmentController(
+	router *gin.Engine,
+	common *Common,
+	service services.DevelopmentServiceInterface,
+) *DevelopmentController {
+	return &DevelopmentController{router, common, service}
+}
+
+func (c *DevelopmentController) RegisterRoutes(relativePath string) {
+	r := c.router.Group(relativePath + DEVELOPMENT_PATH)
+	r.POST(TOKENS_PATH, c.CreateToken)
+}
+
+func (c *DevelopmentController) CreateToken(ctx *gin.Context) {
+	dto := dtos.DevelopmentToken{}
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if dto.Role != "" && !helpers.IsValidRole(dto.Role) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	// 指定されたdto.UIDのユーザがフィクスチャに存在するか確認
+	if _, err := c.service.FindFixtureUser(ctx, dto.UID); errors.Is(err, services.ErrUserNotFound) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	token, expiresAt, err := c.common.Authorization.GenerateDevelopmentToken(dto.UID, dto.Role)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
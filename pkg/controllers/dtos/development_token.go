@@ -0,0 +1,6 @@
+package dtos
+
+type DevelopmentToken struct {
+	UID  string `json:"uid"`
+	Role string `json:"role"`
+}
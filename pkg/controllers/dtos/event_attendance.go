@@ -0,0 +1,5 @@
+package dtos
+
+type EventAttendance struct {
+	Status string `json:"status"`
+}
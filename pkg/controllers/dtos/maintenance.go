@@ -0,0 +1,5 @@
+package dtos
+
+type Maintenance struct {
+	ReadOnly *bool `json:"read_only" binding:"required"`
+}
@@ -0,0 +1,17 @@
+package dtos
+
+import "time"
+
+// 大会の検索条件
+// 指定しない項目では絞り込まない
+type OfficialEventSearch struct {
+	Keyword      string
+	Prefecture   string
+	TypeId       uint
+	LeagueId     uint
+	RegulationId uint
+	ShopId       uint
+	StartDate    time.Time
+	EndDate      time.Time
+	Sort         string
+}
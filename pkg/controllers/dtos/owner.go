@@ -0,0 +1,5 @@
+package dtos
+
+type Owner struct {
+	UserId string `json:"user_id" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package dtos
+
+type PersonalAccessToken struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays uint     `json:"expires_in_days"`
+}
@@ -3,4 +3,6 @@ package dtos
 type Record struct {
 	OfficialEventId uint   `json:"official_event_id"`
 	DeckId          string `json:"deck_id"`
+	Visibility      string `json:"visibility"`
+	MemoVisibility  string `json:"memo_visibility"`
 }
@@ -0,0 +1,9 @@
+package dtos
+
+type UserProfile struct {
+	PTCGLName      string `json:"ptcgl_name"`
+	PlayerId       string `json:"player_id"`
+	Prefecture     string `json:"prefecture"`
+	Bio            string `json:"bio"`
+	FavoriteDeckId string `json:"favorite_deck_id"`
+}
@@ -0,0 +1,8 @@
+package dtos
+
+type UserSetting struct {
+	DefaultVisibility     string `json:"default_visibility"`
+	DefaultMemoVisibility string `json:"default_memo_visibility"`
+	// 指定しない場合は変更しない
+	TimeZone *string `json:"time_zone"`
+}
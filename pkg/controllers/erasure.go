@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	ERASURE_RECEIPTS_PATH = "/erasure_receipts"
+)
+
+type ErasureController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.ErasureServiceInterface
+}
+
+func NewErasureController(
+	router *gin.Engine,
+	common *Common,
+	service services.ErasureServiceInterface,
+) *ErasureController {
+	return &ErasureController{router, common, service}
+}
+
+func (c *ErasureController) RegisterRoutes(relativePath string) {
+	{
+		r := c.router.Group(relativePath + USERS_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredSessionAuthorization)
+		r.DELETE("/me", c.Delete)
+	}
+
+	// 受領証は削除時に返したsecretをtokenに指定して参照する
+	{
+		r := c.router.Group(relativePath + ERASURE_RECEIPTS_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.GET("/:id", c.GetReceiptById)
+	}
+
+	{
+		r := c.router.Group(relativePath + ADMIN_PATH + ERASURE_RECEIPTS_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredSessionAuthorization)
+		r.Use(c.common.Authorization.RequiredAdministrator)
+		r.GET("/:id", c.GetReceiptByIdAsAdministrator)
+	}
+}
+
+func (c *ErasureController) Delete(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.Erase(ctx, uid)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *ErasureController) GetReceiptById(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+
+	// 受領証が存在するかどうかを推測できないように、secretが一致しない場合も同じレスポンスを返す
+	ret, err := c.service.FindReceiptById(ctx, id, helpers.GetToken(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": ErrErasureReceiptNotFound.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *ErasureController) GetReceiptByIdAsAdministrator(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindReceiptByIdAsAdministrator(ctx, helpers.GetId(ctx), uid)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": ErrErasureReceiptNotFound.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
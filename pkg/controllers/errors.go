@@ -3,7 +3,8 @@ package controllers
 import "errors"
 
 var (
-	ErrInvalidParameter      = errors.New("invalid parameter")
-	ErrOfficialEventNotFound = errors.New("official event not found")
-	RecordNotFound           = errors.New("record not found")
+	ErrInvalidParameter       = errors.New("invalid parameter")
+	ErrOfficialEventNotFound  = errors.New("official event not found")
+	ErrErasureReceiptNotFound = errors.New("erasure receipt not found")
+	RecordNotFound            = errors.New("record not found")
 )
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	EVENTS_PATH = "/events"
+)
+
+type EventAttendanceController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.EventAttendanceServiceInterface
+}
+
+func NewEventAttendanceController(
+	router *gin.Engine,
+	common *Common,
+	service services.EventAttendanceServiceInterface,
+) *EventAttendanceController {
+	return &EventAttendanceController{router, common, service}
+}
+
+func (c *EventAttendanceController) RegisterRoutes(relativePath string) {
+	{
+		r := c.router.Group(relativePath + USERS_PATH + "/me" + EVENTS_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_READ))
+		r.GET("", c.GetMe)
+	}
+
+	// 開催日に参加予定の大会のRecordを作成するため、Recordの書き込み権限を求める
+	{
+		r := c.router.Group(relativePath + USERS_PATH + "/me" + EVENTS_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_WRITE))
+		r.PUT("/:id", c.Update)
+		r.DELETE("/:id", c.Delete)
+	}
+}
+
+func (c *EventAttendanceController) GetMe(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	startDate, endDate, err := c.common.ParseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.FindByUID(ctx, uid, helpers.GetStatus(ctx), startDate, endDate)
+	if errors.Is(err, services.ErrInvalidAttendanceStatus) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *EventAttendanceController) Update(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	id, err := ParseId(helpers.GetId(ctx))
+	if err != nil || id == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	dto := dtos.EventAttendance{}
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.Save(ctx, id, uid, &dto)
+	if errors.Is(err, services.ErrInvalidAttendanceStatus) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": ErrOfficialEventNotFound.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *EventAttendanceController) Delete(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	id, err := ParseId(helpers.GetId(ctx))
+	if err != nil || id == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	if err := c.service.Delete(ctx, id, uid); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "accepted",
+	})
+}
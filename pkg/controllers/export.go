@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	EXPORT_PATH = "/export"
+)
+
+type ExportController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.ExportServiceInterface
+}
+
+func NewExportController(
+	router *gin.Engine,
+	common *Common,
+	service services.ExportServiceInterface,
+) *ExportController {
+	return &ExportController{router, common, service}
+}
+
+func (c *ExportController) RegisterRoutes(relativePath string) {
+	r := c.router.Group(relativePath + USERS_PATH + "/me" + EXPORT_PATH)
+	r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+	r.Use(c.common.Authorization.RequiredAuthorization)
+	r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+	r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_READ))
+	r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_DECKS_READ))
+	r.GET("", c.Get)
+}
+
+func (c *ExportController) Get(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	// 件数が多いと書き出しにサーバの書き込みの制限時間より時間がかかるため解除する
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		ctx.Error(err)
+	}
+
+	w := &exportResponseWriter{ctx: ctx}
+
+	if err := c.service.Export(ctx, uid, helpers.GetFormat(ctx), w); err != nil {
+		// 書き出しを始める前であればエラーを返せる
+		if !w.started {
+			if errors.Is(err, services.ErrInvalidExportFormat) {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"message": ErrInvalidParameter.Error(),
+				})
+				return
+			}
+
+			ctx.Error(err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"message": http.StatusText(http.StatusInternalServerError),
+			})
+			return
+		}
+
+		// ヘッダ送信後はステータスコードを変更できないため、エラーが発生した場合は中断のみ行う
+		ctx.Error(err)
+		ctx.Abort()
+		return
+	}
+}
+
+// 最初に書き込むときにレスポンスのヘッダを送信する
+type exportResponseWriter struct {
+	ctx     *gin.Context
+	started bool
+}
+
+func (w *exportResponseWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		filename := fmt.Sprintf("vsrecorder-export-%s.zip", time.Now().Format("20060102150405"))
+
+		w.ctx.Header("Content-Type", "application/zip")
+		w.ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		w.ctx.Status(http.StatusOK)
+
+		w.started = true
+	}
+
+	return w.ctx.Writer.Write(p)
+}
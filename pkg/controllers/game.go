@@ -4,9 +4,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
-	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/middlewares"
 	"github.com/vsrecorder/vsr-apiserver/pkg/services"
 )
 
@@ -16,20 +16,25 @@ const (
 
 type GameController struct {
 	router  *gin.Engine
+	common  *Common
 	service services.GameServiceInterface
 }
 
 func NewGameController(
 	router *gin.Engine,
+	common *Common,
 	service services.GameServiceInterface,
 ) *GameController {
-	return &GameController{router, service}
+	return &GameController{router, common, service}
 }
 
 func (c *GameController) RegisterRoutes(relativePath string) {
 	{
 		r := c.router.Group(relativePath + GAMES_PATH)
-		r.Use(middlewares.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_WRITE))
 		r.POST("", c.Create)
 		r.PUT("/:id", c.Update)
 		r.DELETE("/:id", c.Delete)
@@ -37,6 +42,10 @@ func (c *GameController) RegisterRoutes(relativePath string) {
 
 	{
 		r := c.router.Group(relativePath + GAMES_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_READ))
 		r.GET("/:id", c.GetById)
 		r.GET("/:id"+BATTLES_PATH, c.GetBattleById)
 	}
@@ -44,11 +53,11 @@ func (c *GameController) RegisterRoutes(relativePath string) {
 
 func (c *GameController) GetById(ctx *gin.Context) {
 	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
 
-	ret, err := c.service.FindById(ctx, id)
-
+	ret, err := c.service.FindByIdWithUID(ctx, id, uid)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(findErrorStatus(err), gin.H{
 			"message": err.Error(),
 		})
 		return
@@ -59,10 +68,11 @@ func (c *GameController) GetById(ctx *gin.Context) {
 
 func (c *GameController) GetBattleById(ctx *gin.Context) {
 	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
 
-	ret, err := c.service.FindBattleById(ctx, id)
+	ret, err := c.service.FindBattleByIdWithUID(ctx, id, uid)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(findErrorStatus(err), gin.H{
 			"message": err.Error(),
 		})
 		return
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	HEALTHZ_PATH = "/healthz"
+	READYZ_PATH  = "/readyz"
+)
+
+// コンテナオーケストレータ向けのため認証・レート制限を行わない
+type HealthController struct {
+	router  *gin.Engine
+	service services.HealthServiceInterface
+}
+
+func NewHealthController(
+	router *gin.Engine,
+	service services.HealthServiceInterface,
+) *HealthController {
+	return &HealthController{router, service}
+}
+
+func (c *HealthController) RegisterRoutes(relativePath string) {
+	r := c.router.Group(relativePath)
+	r.GET(HEALTHZ_PATH, c.GetHealthz)
+	r.GET(READYZ_PATH, c.GetReadyz)
+}
+
+// プロセスが応答できれば常に200を返す
+func (c *HealthController) GetHealthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "ok",
+	})
+}
+
+func (c *HealthController) GetReadyz(ctx *gin.Context) {
+	ret := c.service.Readiness(ctx)
+	if !ret.Ready {
+		ctx.JSON(http.StatusServiceUnavailable, ret)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
@@ -1,6 +1,10 @@
 package helpers
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
 
 func SetUID(ctx *gin.Context, value string) {
 	ctx.Set("uid", value)
@@ -18,3 +22,73 @@ func GetUID(ctx *gin.Context) (uid string, exists bool) {
 
 	return uid, exists
 }
+
+func SetScopes(ctx *gin.Context, value []string) {
+	ctx.Set("scopes", value)
+}
+
+// パーソナルアクセストークンで認証された場合のみexistsがtrueになる
+func GetScopes(ctx *gin.Context) (scopes []string, exists bool) {
+	value, exists := ctx.Get("scopes")
+
+	switch v := value.(type) {
+	case []string:
+		scopes = v
+	default:
+		scopes = []string{}
+	}
+
+	return scopes, exists
+}
+
+func SetRole(ctx *gin.Context, value string) {
+	ctx.Set("role", value)
+}
+
+// JWTのroleクレームに管理者または運営者が指定されている場合のみ設定される
+func GetRole(ctx *gin.Context) (role string, exists bool) {
+	value, exists := ctx.Get("role")
+
+	switch v := value.(type) {
+	case string:
+		role = v
+	default:
+		role = ""
+	}
+
+	return role, exists
+}
+
+func SetRequestId(ctx *gin.Context, value string) {
+	ctx.Set("request_id", value)
+}
+
+func GetRequestId(ctx *gin.Context) (requestId string, exists bool) {
+	value, exists := ctx.Get("request_id")
+
+	switch v := value.(type) {
+	case string:
+		requestId = v
+	default:
+		requestId = ""
+	}
+
+	return requestId, exists
+}
+
+// サービス層など*gin.Contextを直接扱わない箇所から参照するためのもの
+func RequestIdFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value("request_id").(string); ok {
+		return v
+	}
+
+	return ""
+}
+
+func UIDFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value("uid").(string); ok {
+		return v
+	}
+
+	return ""
+}
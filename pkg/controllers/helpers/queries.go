@@ -1,6 +1,8 @@
 package helpers
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,6 +14,94 @@ func GetEndDate(ctx *gin.Context) (end_date string) {
 	return ctx.Query("end_date")
 }
 
+// IANAのタイムゾーン名(例: Asia/Tokyo)
+func GetTimeZone(ctx *gin.Context) (tz string) {
+	return ctx.Query("tz")
+}
+
+func GetKeyword(ctx *gin.Context) (keyword string) {
+	return ctx.Query("keyword")
+}
+
+func GetPrefecture(ctx *gin.Context) (prefecture string) {
+	return ctx.Query("prefecture")
+}
+
+func GetTypeId(ctx *gin.Context) (typeId string) {
+	return ctx.Query("type_id")
+}
+
+func GetLeagueId(ctx *gin.Context) (leagueId string) {
+	return ctx.Query("league_id")
+}
+
+// 大会のレギュレーション(フォーマット)
+func GetRegulationId(ctx *gin.Context) (regulationId string) {
+	return ctx.Query("regulation_id")
+}
+
+// 大会を主催する店舗
+func GetShopId(ctx *gin.Context) (shopId string) {
+	return ctx.Query("shop_id")
+}
+
+func GetLatitude(ctx *gin.Context) (lat string) {
+	return ctx.Query("lat")
+}
+
+func GetLongitude(ctx *gin.Context) (lng string) {
+	return ctx.Query("lng")
+}
+
+func GetRadiusKm(ctx *gin.Context) (radiusKm string) {
+	return ctx.Query("radius_km")
+}
+
+func GetStatus(ctx *gin.Context) (status string) {
+	return ctx.Query("status")
+}
+
+func GetSort(ctx *gin.Context) (sort string) {
+	return ctx.Query("sort")
+}
+
 func GetPage(ctx *gin.Context) (page string) {
 	return ctx.Query("page")
 }
+
+func GetEntity(ctx *gin.Context) (entity string) {
+	return ctx.Query("entity")
+}
+
+func GetEntityId(ctx *gin.Context) (entityId string) {
+	return ctx.Query("entity_id")
+}
+
+func GetActorUID(ctx *gin.Context) (actorUID string) {
+	return ctx.Query("actor_uid")
+}
+
+func GetFormat(ctx *gin.Context) (format string) {
+	return ctx.Query("format")
+}
+
+// カレンダーの購読URLに含めるトークン
+func GetToken(ctx *gin.Context) (token string) {
+	return ctx.Query("token")
+}
+
+func GetDryRun(ctx *gin.Context) (dryRun string) {
+	return ctx.Query("dry_run")
+}
+
+// カンマ区切りで指定されたidを返す
+func GetIds(ctx *gin.Context) (ids []string) {
+	ids = []string{}
+	for _, id := range strings.Split(ctx.Query("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
@@ -0,0 +1,10 @@
+package helpers
+
+const (
+	ROLE_ADMIN     = "admin"
+	ROLE_MODERATOR = "moderator"
+)
+
+func IsValidRole(role string) bool {
+	return role == ROLE_ADMIN || role == ROLE_MODERATOR
+}
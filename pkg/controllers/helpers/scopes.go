@@ -0,0 +1,39 @@
+package helpers
+
+const (
+	PERSONAL_ACCESS_TOKEN_PREFIX = "vsrp_"
+
+	SCOPE_RECORDS_READ  = "records:read"
+	SCOPE_RECORDS_WRITE = "records:write"
+	SCOPE_DECKS_READ    = "decks:read"
+	SCOPE_DECKS_WRITE   = "decks:write"
+)
+
+var (
+	Scopes = []string{
+		SCOPE_RECORDS_READ,
+		SCOPE_RECORDS_WRITE,
+		SCOPE_DECKS_READ,
+		SCOPE_DECKS_WRITE,
+	}
+)
+
+func IsValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	IMPORT_PATH = "/import"
+
+	IMPORT_MAX_BYTES = 2 << 20
+)
+
+type ImportController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.ImportServiceInterface
+}
+
+func NewImportController(
+	router *gin.Engine,
+	common *Common,
+	service services.ImportServiceInterface,
+) *ImportController {
+	return &ImportController{router, common, service}
+}
+
+func (c *ImportController) RegisterRoutes(relativePath string) {
+	r := c.router.Group(relativePath + USERS_PATH + "/me" + IMPORT_PATH)
+	r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+	r.Use(c.common.Authorization.RequiredAuthorization)
+	r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+	r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_WRITE))
+	r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_DECKS_WRITE))
+	r.POST("", c.Create)
+}
+
+// CSVはmultipart/form-dataのfileフィールド、またはリクエストボディそのもので受け付ける
+// dry_run=falseが指定されない限り検証結果のみを返す
+func (c *ImportController) Create(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	dryRun := true
+	if v := helpers.GetDryRun(ctx); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": ErrInvalidParameter.Error(),
+			})
+			return
+		}
+
+		dryRun = b
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, IMPORT_MAX_BYTES)
+
+	// FormFileはapplication/x-www-form-urlencodedのボディも読み込んでしまうため、multipart/form-dataの場合のみ呼び出す
+	var reader io.Reader = ctx.Request.Body
+	if ctx.ContentType() == gin.MIMEMultipartPOSTForm {
+		// multipartの形式が不正な場合もCSVの形式が不正な場合と同様に扱う
+		fileHeader, err := ctx.FormFile("file")
+		if err != nil {
+			c.writeError(ctx, fmt.Errorf("%w: %w", services.ErrInvalidImportFile, err))
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.writeError(ctx, err)
+			return
+		}
+		defer file.Close()
+
+		reader = file
+	}
+
+	ret, err := c.service.Import(ctx, uid, reader, dryRun)
+	if err != nil {
+		c.writeError(ctx, err)
+		return
+	}
+
+	if len(ret.Errors) > 0 {
+		ctx.JSON(http.StatusUnprocessableEntity, ret)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *ImportController) writeError(ctx *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"message": http.StatusText(http.StatusRequestEntityTooLarge),
+		})
+	case errors.Is(err, services.ErrInvalidImportFile):
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+	default:
+		ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": http.StatusText(http.StatusInternalServerError),
+		})
+	}
+}
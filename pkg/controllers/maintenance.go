@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	MAINTENANCE_PATH = "/maintenance"
+)
+
+type MaintenanceController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.MaintenanceServiceInterface
+}
+
+func NewMaintenanceController(
+	router *gin.Engine,
+	common *Common,
+	service services.MaintenanceServiceInterface,
+) *MaintenanceController {
+	return &MaintenanceController{router, common, service}
+}
+
+func (c *MaintenanceController) RegisterRoutes(relativePath string) {
+	r := c.router.Group(relativePath + ADMIN_PATH + MAINTENANCE_PATH)
+	r.Use(c.common.Authorization.RequiredAuthorization)
+	r.Use(c.common.Authorization.RequiredSessionAuthorization)
+	r.Use(c.common.Authorization.RequiredRole(helpers.ROLE_ADMIN))
+	r.GET("", c.Get)
+	r.PUT("", c.Update)
+}
+
+func (c *MaintenanceController) Get(ctx *gin.Context) {
+	ret, err := c.service.Find(ctx)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *MaintenanceController) Update(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+	dto := dtos.Maintenance{}
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.Update(ctx, uid, &dto)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	META_PATH = "/meta"
+
+	// 期間を指定しない場合に集計する日数
+	META_DEFAULT_DAYS = 28
+)
+
+type MetaController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.MetaServiceInterface
+}
+
+func NewMetaController(
+	router *gin.Engine,
+	common *Common,
+	service services.MetaServiceInterface,
+) *MetaController {
+	return &MetaController{router, common, service}
+}
+
+func (c *MetaController) RegisterRoutes(relativePath string) {
+	r := c.router.Group(relativePath + META_PATH)
+	r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+	r.GET("", c.Get)
+}
+
+// formatにはレギュレーション(regulation_id)を指定する
+// 期間を指定しない場合は今日までの28日間を集計する
+func (c *MetaController) Get(ctx *gin.Context) {
+	startDate, endDate, err := c.common.ParseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	if startDate.IsZero() && endDate.IsZero() {
+		today, err := c.common.ParseToday(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": ErrInvalidParameter.Error(),
+			})
+			return
+		}
+
+		endDate = today.AddDate(0, 0, 1)
+		startDate = endDate.AddDate(0, 0, -META_DEFAULT_DAYS)
+	}
+
+	regulationId, err := ParseId(helpers.GetFormat(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.Find(ctx, startDate, endDate, regulationId)
+	if errors.Is(err, services.ErrInvalidMetaRange) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+)
+
+// 設定されたユーザはroleクレームが無くても管理者として扱う
+func (a *Authorization) isAdministratorUID(uid string) bool {
+	for _, adminUID := range a.administratorUIDs {
+		if adminUID = strings.TrimSpace(adminUID); adminUID != "" && adminUID == uid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 指定されたいずれかのロールを持つユーザのみ許可する
+// RequiredAuthorizationより後に設定すること
+func (a *Authorization) RequiredRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		uid, exists := helpers.GetUID(ctx)
+		if !exists {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+			return
+		}
+
+		role, _ := helpers.GetRole(ctx)
+		if a.isAdministratorUID(uid) {
+			role = helpers.ROLE_ADMIN
+			helpers.SetRole(ctx, role)
+		}
+
+		for _, r := range roles {
+			if role != "" && role == r {
+				return
+			}
+		}
+
+		a.observeAuthorizationFailure(AUTHORIZATION_FAILURE_FORBIDDEN_ROLE)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Forbidden"})
+	}
+}
+
+// RequiredAuthorizationより後に設定すること
+func (a *Authorization) RequiredAdministrator(ctx *gin.Context) {
+	a.RequiredRole(helpers.ROLE_ADMIN)(ctx)
+}
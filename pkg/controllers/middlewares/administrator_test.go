@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+)
+
+func TestRequiredRole(t *testing.T) {
+	setup()
+	authorization := NewAuthorization(authorization.jwtSecret, []string{"config-admin"}, nil, nil, nil)
+
+	for scenario, tc := range map[string]struct {
+		uid      string
+		role     string
+		roles    []string
+		expected int
+	}{
+		"AdminClaim":        {"admin", helpers.ROLE_ADMIN, []string{helpers.ROLE_ADMIN}, http.StatusOK},
+		"ModeratorAllowed":  {"moderator", helpers.ROLE_MODERATOR, []string{helpers.ROLE_ADMIN, helpers.ROLE_MODERATOR}, http.StatusOK},
+		"ModeratorRejected": {"moderator", helpers.ROLE_MODERATOR, []string{helpers.ROLE_ADMIN}, http.StatusForbidden},
+		"UnknownRole":       {"user", "superuser", []string{helpers.ROLE_ADMIN}, http.StatusForbidden},
+		"NoRole":            {"user", "", []string{helpers.ROLE_ADMIN}, http.StatusForbidden},
+		"AdminUIDs":         {"config-admin", "", []string{helpers.ROLE_ADMIN}, http.StatusOK},
+	} {
+		tc := tc
+		t.Run(scenario, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+			tokenString, err := generateTokenWithRole(tc.uid, tc.role, authorization.jwtSecret)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+
+			req.Header.Add("Authorization", "Bearer "+tokenString)
+			ctx.Request = req
+
+			authorization.RequiredAuthorization(ctx)
+			authorization.RequiredRole(tc.roles...)(ctx)
+
+			require.Equal(t, tc.expected, ctx.Writer.Status())
+		})
+	}
+}
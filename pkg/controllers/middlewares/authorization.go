@@ -1,9 +1,9 @@
 package middlewares
 
 import (
+	"context"
 	"errors"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -14,17 +14,136 @@ import (
 
 const (
 	TOKEN_LIFETIME_SECOND = time.Duration(15) * time.Second
+
+	// 開発モードで発行するテスト用JWTの有効期間
+	DEVELOPMENT_TOKEN_LIFETIME = time.Duration(24) * time.Hour
+
+	AUTHORIZATION_FAILURE_MISSING_TOKEN         = "missing_token"
+	AUTHORIZATION_FAILURE_EXPIRED_TOKEN         = "expired_token"
+	AUTHORIZATION_FAILURE_MALFORMED_TOKEN       = "malformed_token"
+	AUTHORIZATION_FAILURE_INVALID_SIGNATURE     = "invalid_signature"
+	AUTHORIZATION_FAILURE_INVALID_TOKEN         = "invalid_token"
+	AUTHORIZATION_FAILURE_PERSONAL_ACCESS_TOKEN = "invalid_personal_access_token"
+	AUTHORIZATION_FAILURE_INSUFFICIENT_SCOPE    = "insufficient_scope"
+	AUTHORIZATION_FAILURE_SESSION_REQUIRED      = "session_required"
+	AUTHORIZATION_FAILURE_FORBIDDEN_ROLE        = "forbidden_role"
 )
 
 type VSRClaims struct {
 	jwt.RegisteredClaims
-	UID string `json:"uid"`
+	UID  string `json:"uid"`
+	Role string `json:"role,omitempty"`
+}
+
+// 認証の成否をメトリクスとして記録する
+type AuthorizationObserver interface {
+	ObserveAuthorized(uid string)
+	ObserveAuthorizationFailure(reason string)
+}
+
+// 認証したユーザをusersテーブルに登録する
+type UserRegisterer interface {
+	Register(
+		ctx context.Context,
+		uid string,
+	) error
+}
+
+type PersonalAccessTokenVerifier interface {
+	Verify(
+		ctx context.Context,
+		token string,
+	) (string, []string, error)
+}
+
+// 認証・認可を行うミドルウェア
+// 起動時に設定とサービスから作成し、各コントローラで共有する
+type Authorization struct {
+	// 起動時に設定ファイルから読み込んだJWTの署名鍵
+	jwtSecret string
+	// roleクレームが無くても管理者として扱うユーザ
+	administratorUIDs []string
+
+	personalAccessTokenVerifier PersonalAccessTokenVerifier
+	userRegisterer              UserRegisterer
+	observer                    AuthorizationObserver
+}
+
+// personalAccessTokenVerifierがnilの場合はパーソナルアクセストークンでの認証を拒否し、
+// userRegistererがnilの場合はユーザを登録せず、observerがnilの場合はメトリクスを記録しない
+func NewAuthorization(
+	jwtSecret string,
+	administratorUIDs []string,
+	personalAccessTokenVerifier PersonalAccessTokenVerifier,
+	userRegisterer UserRegisterer,
+	observer AuthorizationObserver,
+) *Authorization {
+	return &Authorization{
+		jwtSecret:                   jwtSecret,
+		administratorUIDs:           administratorUIDs,
+		personalAccessTokenVerifier: personalAccessTokenVerifier,
+		userRegisterer:              userRegisterer,
+		observer:                    observer,
+	}
+}
+
+func (a *Authorization) observeAuthorized(uid string) {
+	if a.observer != nil {
+		a.observer.ObserveAuthorized(uid)
+	}
+}
+
+func (a *Authorization) observeAuthorizationFailure(reason string) {
+	if a.observer != nil {
+		a.observer.ObserveAuthorizationFailure(reason)
+	}
+}
+
+func authorizationFailureReason(tokenString string, err error) string {
+	switch {
+	case tokenString == "":
+		return AUTHORIZATION_FAILURE_MISSING_TOKEN
+	case strings.HasPrefix(tokenString, helpers.PERSONAL_ACCESS_TOKEN_PREFIX):
+		return AUTHORIZATION_FAILURE_PERSONAL_ACCESS_TOKEN
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return AUTHORIZATION_FAILURE_EXPIRED_TOKEN
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return AUTHORIZATION_FAILURE_MALFORMED_TOKEN
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return AUTHORIZATION_FAILURE_INVALID_SIGNATURE
+	default:
+		return AUTHORIZATION_FAILURE_INVALID_TOKEN
+	}
 }
 
 func generateToken(uid string, secretKey string) (string, error) {
+	return generateTokenWithRole(uid, "", secretKey)
+}
+
+func generateTokenWithRole(uid string, role string, secretKey string) (string, error) {
+	return signToken(uid, role, time.Now().Add(TOKEN_LIFETIME_SECOND), secretKey)
+}
+
+// 開発モードでのみ使用し、本番環境ではJWTを発行しないこと
+func (a *Authorization) GenerateDevelopmentToken(uid string, role string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(DEVELOPMENT_TOKEN_LIFETIME)
+
+	tokenString, err := signToken(uid, role, expiresAt, a.jwtSecret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+func signToken(uid string, role string, expiresAt time.Time, secretKey string) (string, error) {
 	claims := jwt.MapClaims{
 		"uid": uid,
-		"exp": time.Now().Add(TOKEN_LIFETIME_SECOND).Unix(),
+		"exp": expiresAt.Unix(),
+	}
+
+	if role != "" {
+		claims["role"] = role
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -51,26 +170,62 @@ func parseToken(tokenString string, secretKey string) (*jwt.Token, error) {
 	return token, nil
 }
 
-func RequiredAuthorization(ctx *gin.Context) {
-	secretKey := os.Getenv("VSRECORDER_JWT_SECRET")
+func (a *Authorization) authorize(ctx *gin.Context, tokenString string) error {
+	// パーソナルアクセストークンの場合はスコープも設定する
+	if strings.HasPrefix(tokenString, helpers.PERSONAL_ACCESS_TOKEN_PREFIX) {
+		if a.personalAccessTokenVerifier == nil {
+			return errors.New("personal access token is not supported")
+		}
 
-	header := http.Header{}
-	header.Add("Authorization", ctx.GetHeader("Authorization"))
-	tokenString := strings.TrimPrefix(header.Get("Authorization"), "Bearer ")
+		uid, scopes, err := a.personalAccessTokenVerifier.Verify(ctx, tokenString)
+		if err != nil {
+			return err
+		}
 
-	token, err := parseToken(tokenString, secretKey)
+		helpers.SetUID(ctx, uid)
+		helpers.SetScopes(ctx, scopes)
+		a.observeAuthorized(uid)
+		return nil
+	}
+
+	token, err := parseToken(tokenString, a.jwtSecret)
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
+		return err
 	}
 
 	claims := token.Claims.(*VSRClaims)
 	helpers.SetUID(ctx, claims.UID)
+	a.observeAuthorized(claims.UID)
+
+	// パーソナルアクセストークンは登録済みのユーザのみ発行できるため、JWTの場合のみ登録する
+	// 登録に失敗しても認証は成功させ、次のリクエストで登録し直す
+	if a.userRegisterer != nil {
+		if err := a.userRegisterer.Register(ctx, claims.UID); err != nil {
+			ctx.Error(err)
+		}
+	}
+
+	// 未知のロールは一般ユーザとして扱う
+	if helpers.IsValidRole(claims.Role) {
+		helpers.SetRole(ctx, claims.Role)
+	}
+
+	return nil
 }
 
-func OptionalAuthorization(ctx *gin.Context) {
-	secretKey := os.Getenv("VSRECORDER_JWT_SECRET")
+func (a *Authorization) RequiredAuthorization(ctx *gin.Context) {
+	header := http.Header{}
+	header.Add("Authorization", ctx.GetHeader("Authorization"))
+	tokenString := strings.TrimPrefix(header.Get("Authorization"), "Bearer ")
 
+	if err := a.authorize(ctx, tokenString); err != nil {
+		a.observeAuthorizationFailure(authorizationFailureReason(tokenString, err))
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+		return
+	}
+}
+
+func (a *Authorization) OptionalAuthorization(ctx *gin.Context) {
 	header := http.Header{}
 	header.Add("Authorization", ctx.GetHeader("Authorization"))
 
@@ -79,12 +234,35 @@ func OptionalAuthorization(ctx *gin.Context) {
 	}
 
 	tokenString := strings.TrimPrefix(header.Get("Authorization"), "Bearer ")
-	token, err := parseToken(tokenString, secretKey)
-	if err != nil {
+	if err := a.authorize(ctx, tokenString); err != nil {
+		a.observeAuthorizationFailure(authorizationFailureReason(tokenString, err))
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
 		return
 	}
+}
 
-	claims := token.Claims.(*VSRClaims)
-	helpers.SetUID(ctx, claims.UID)
+// パーソナルアクセストークンで認証された場合は指定されたスコープを持っているか確認する
+// JWTで認証された場合(Webからのリクエスト)は全てのスコープを持っているものとして扱う
+func (a *Authorization) RequiredScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		scopes, exists := helpers.GetScopes(ctx)
+		if !exists {
+			return
+		}
+
+		if !helpers.HasScope(scopes, scope) {
+			a.observeAuthorizationFailure(AUTHORIZATION_FAILURE_INSUFFICIENT_SCOPE)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Forbidden"})
+			return
+		}
+	}
+}
+
+// パーソナルアクセストークンでの認証を拒否する
+func (a *Authorization) RequiredSessionAuthorization(ctx *gin.Context) {
+	if _, exists := helpers.GetScopes(ctx); exists {
+		a.observeAuthorizationFailure(AUTHORIZATION_FAILURE_SESSION_REQUIRED)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Forbidden"})
+		return
+	}
 }
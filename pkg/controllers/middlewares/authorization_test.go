@@ -1,11 +1,12 @@
 package middlewares
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
 
@@ -17,6 +18,8 @@ import (
 
 var (
 	seed = time.Now().UnixNano()
+
+	authorization *Authorization
 )
 
 func setup() {
@@ -25,7 +28,7 @@ func setup() {
 	r.Read(src)
 
 	secretKey := base64.StdEncoding.EncodeToString(src)
-	os.Setenv("VSRECORDER_JWT_SECRET", secretKey)
+	authorization = NewAuthorization(secretKey, nil, nil, nil, nil)
 }
 
 func TestRequiredAuthorization(t *testing.T) {
@@ -61,6 +64,75 @@ func TestOptionalAuthorization(t *testing.T) {
 	}
 }
 
+type personalAccessTokenVerifierMock struct {
+	token  string
+	uid    string
+	scopes []string
+}
+
+func (v *personalAccessTokenVerifierMock) Verify(
+	ctx context.Context,
+	token string,
+) (string, []string, error) {
+	if token != v.token {
+		return "", nil, errors.New("invalid token")
+	}
+
+	return v.uid, v.scopes, nil
+}
+
+type userRegistererMock struct {
+	uids []string
+	err  error
+}
+
+func (r *userRegistererMock) Register(
+	ctx context.Context,
+	uid string,
+) error {
+	r.uids = append(r.uids, uid)
+	return r.err
+}
+
+// JWTで認証したユーザを登録し、登録に失敗しても認証は成功させる
+func TestUserRegistration(t *testing.T) {
+	setup()
+
+	registerer := &userRegistererMock{err: errors.New("unavailable")}
+	authorization := NewAuthorization(authorization.jwtSecret, nil, nil, registerer, nil)
+
+	tokenString, err := generateToken("uid", authorization.jwtSecret)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx.Request.Header.Add("Authorization", "Bearer "+tokenString)
+
+	authorization.RequiredAuthorization(ctx)
+
+	require.Equal(t, false, ctx.IsAborted())
+	require.Equal(t, []string{"uid"}, registerer.uids)
+	require.Len(t, ctx.Errors, 1)
+}
+
+func TestPersonalAccessTokenAuthorization(t *testing.T) {
+	setup()
+
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+	){
+		"ValidPersonalAccessToken":   test_ValidPersonalAccessToken,
+		"InvalidPersonalAccessToken": test_InvalidPersonalAccessToken,
+		"RequiredScope":              test_RequiredScope,
+	} {
+		fn := fn // ↑引数の順番通りに実行するための設定(https://github.com/golang/go/wiki/CommonMistakes)
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
 func test_ValidRequiredAuthorization(t *testing.T) {
 	entropy := rand.New(rand.NewSource(seed))
 	ms := ulid.Timestamp(time.Now())
@@ -69,7 +141,7 @@ func test_ValidRequiredAuthorization(t *testing.T) {
 
 	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
 
-	secretKey := os.Getenv("VSRECORDER_JWT_SECRET")
+	secretKey := authorization.jwtSecret
 	tokenString, err := generateToken(id.String(), secretKey)
 	require.NoError(t, err)
 
@@ -79,7 +151,7 @@ func test_ValidRequiredAuthorization(t *testing.T) {
 	req.Header.Add("Authorization", "Bearer "+tokenString)
 	ctx.Request = req
 
-	RequiredAuthorization(ctx)
+	authorization.RequiredAuthorization(ctx)
 
 	expectedUID := id.String()
 	expectedExists := true
@@ -99,7 +171,7 @@ func test_InvalidRequiredAuthorization(t *testing.T) {
 	{
 		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
 
-		secretKey := os.Getenv("VSRECORDER_JWT_SECRET")
+		secretKey := authorization.jwtSecret
 		tokenString, err := generateToken(id.String(), secretKey)
 		require.NoError(t, err)
 
@@ -111,7 +183,7 @@ func test_InvalidRequiredAuthorization(t *testing.T) {
 		req.Header.Add("Authorization", "Bearer "+invaliedTokenString)
 		ctx.Request = req
 
-		RequiredAuthorization(ctx)
+		authorization.RequiredAuthorization(ctx)
 
 		expectedUID := ""
 		expectedExists := false
@@ -131,7 +203,7 @@ func test_InvalidRequiredAuthorization(t *testing.T) {
 		require.NoError(t, err)
 		ctx.Request = req
 
-		RequiredAuthorization(ctx)
+		authorization.RequiredAuthorization(ctx)
 
 		expectedUID := ""
 		expectedExists := false
@@ -156,7 +228,7 @@ func test_ValidOptionalAuthorization(t *testing.T) {
 
 		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
 
-		secretKey := os.Getenv("VSRECORDER_JWT_SECRET")
+		secretKey := authorization.jwtSecret
 		tokenString, err := generateToken(id.String(), secretKey)
 		require.NoError(t, err)
 
@@ -166,7 +238,7 @@ func test_ValidOptionalAuthorization(t *testing.T) {
 		req.Header.Add("Authorization", "Bearer "+tokenString)
 		ctx.Request = req
 
-		RequiredAuthorization(ctx)
+		authorization.RequiredAuthorization(ctx)
 
 		expectedUID := id.String()
 		expectedExists := true
@@ -184,7 +256,7 @@ func test_ValidOptionalAuthorization(t *testing.T) {
 
 		ctx.Request = req
 
-		RequiredAuthorization(ctx)
+		authorization.RequiredAuthorization(ctx)
 
 		expectedUID := ""
 		expectedExists := false
@@ -203,7 +275,7 @@ func test_InvalidOptionalAuthorization(t *testing.T) {
 	{
 		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
 
-		secretKey := os.Getenv("VSRECORDER_JWT_SECRET")
+		secretKey := authorization.jwtSecret
 		tokenString, err := generateToken(id.String(), secretKey)
 		require.NoError(t, err)
 
@@ -215,7 +287,7 @@ func test_InvalidOptionalAuthorization(t *testing.T) {
 		req.Header.Add("Authorization", "Bearer "+invaliedTokenString)
 		ctx.Request = req
 
-		RequiredAuthorization(ctx)
+		authorization.RequiredAuthorization(ctx)
 
 		expectedUID := ""
 		expectedExists := false
@@ -228,3 +300,88 @@ func test_InvalidOptionalAuthorization(t *testing.T) {
 		require.Equal(t, expectedStatus, actualStatus)
 	}
 }
+
+func test_ValidPersonalAccessToken(t *testing.T) {
+	authorization := NewAuthorization(authorization.jwtSecret, nil, &personalAccessTokenVerifierMock{
+		token:  "vsrp_valid",
+		uid:    "uid",
+		scopes: []string{helpers.SCOPE_RECORDS_READ},
+	}, nil, nil)
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+
+	req.Header.Add("Authorization", "Bearer vsrp_valid")
+	ctx.Request = req
+
+	authorization.RequiredAuthorization(ctx)
+
+	actualUID, actualExists := helpers.GetUID(ctx)
+	require.Equal(t, "uid", actualUID)
+	require.Equal(t, true, actualExists)
+
+	actualScopes, actualExists := helpers.GetScopes(ctx)
+	require.Equal(t, []string{helpers.SCOPE_RECORDS_READ}, actualScopes)
+	require.Equal(t, true, actualExists)
+}
+
+func test_InvalidPersonalAccessToken(t *testing.T) {
+	authorization := NewAuthorization(authorization.jwtSecret, nil, &personalAccessTokenVerifierMock{
+		token:  "vsrp_valid",
+		uid:    "uid",
+		scopes: []string{helpers.SCOPE_RECORDS_READ},
+	}, nil, nil)
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+
+	req.Header.Add("Authorization", "Bearer vsrp_invalid")
+	ctx.Request = req
+
+	authorization.RequiredAuthorization(ctx)
+
+	actualUID, actualExists := helpers.GetUID(ctx)
+	require.Equal(t, "", actualUID)
+	require.Equal(t, false, actualExists)
+
+	expectedStatus := http.StatusUnauthorized
+	actualStatus := ctx.Writer.Status()
+	require.Equal(t, expectedStatus, actualStatus)
+}
+
+func test_RequiredScope(t *testing.T) {
+	// パーソナルアクセストークンが対象のスコープを持っている場合
+	{
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		helpers.SetScopes(ctx, []string{helpers.SCOPE_RECORDS_READ})
+
+		authorization.RequiredScope(helpers.SCOPE_RECORDS_READ)(ctx)
+
+		require.Equal(t, false, ctx.IsAborted())
+	}
+
+	// パーソナルアクセストークンが対象のスコープを持っていない場合
+	{
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		helpers.SetScopes(ctx, []string{helpers.SCOPE_RECORDS_READ})
+
+		authorization.RequiredScope(helpers.SCOPE_RECORDS_WRITE)(ctx)
+
+		require.Equal(t, true, ctx.IsAborted())
+		require.Equal(t, http.StatusForbidden, ctx.Writer.Status())
+	}
+
+	// JWTで認証された場合
+	{
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		helpers.SetUID(ctx, "uid")
+
+		authorization.RequiredScope(helpers.SCOPE_RECORDS_WRITE)(ctx)
+
+		require.Equal(t, false, ctx.IsAborted())
+	}
+}
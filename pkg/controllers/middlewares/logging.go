@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// リクエストID(トレースされている場合はトレースIDも)を付与したロガーをリクエストのコンテキストに設定し、
+// レスポンス後にアクセスログを出力する
+// RequestIdとトレースのミドルウェアの後に使う
+func Logging(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestId, _ := helpers.GetRequestId(ctx)
+		requestLogger := logger.With(slog.String("request_id", requestId))
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.IsValid() {
+			requestLogger = requestLogger.With(slog.String("trace_id", spanContext.TraceID().String()))
+		}
+		ctx.Request = ctx.Request.WithContext(logging.WithLogger(ctx.Request.Context(), requestLogger))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.String("route", ctx.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+		}
+
+		if uid, _ := helpers.GetUID(ctx); uid != "" {
+			attrs = append(attrs, slog.String("uid", uid))
+		}
+
+		if len(ctx.Errors) != 0 {
+			attrs = append(attrs, slog.String("error", ctx.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		requestLogger.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
+
+// リクエスト毎にクエリの制限時間を設定する
+// gin.EngineのContextWithFallbackを有効にし、*gin.Contextからリクエストのコンテキストを参照できるようにする必要がある
+// エクスポートなど制限時間内に終わらないことがあるパスはexemptPathsに指定する
+func QueryTimeout(timeout time.Duration, exemptPaths ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if timeout <= 0 {
+			return
+		}
+
+		for _, path := range exemptPaths {
+			if ctx.FullPath() == path {
+				return
+			}
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+
+		ctx.Request = ctx.Request.WithContext(timeoutCtx)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/vsrecorder/vsr-apiserver/pkg/logging"
+)
+
+func TestLogging(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+	){
+		"Logging":      test_Logging,
+		"QueryTimeout": test_QueryTimeout,
+	} {
+		fn := fn // ↑引数の順番通りに実行するための設定(https://github.com/golang/go/wiki/CommonMistakes)
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func test_Logging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	buf := &bytes.Buffer{}
+	logger, err := logging.New(buf, &logging.Config{Format: logging.FORMAT_JSON, Level: "debug"})
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(RequestId)
+	r.Use(Logging(logger))
+	r.GET("/", func(ctx *gin.Context) {
+		// サービス・リポジトリでもリクエストIDの付いたロガーを参照できる
+		logging.FromContext(ctx).InfoContext(ctx, "handler")
+		ctx.Status(http.StatusNoContent)
+	})
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	req.Header.Set(REQUEST_ID_HEADER, "request-id")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	for _, line := range lines {
+		entry := map[string]any{}
+		require.NoError(t, json.Unmarshal(line, &entry))
+		require.Equal(t, "request-id", entry["request_id"])
+	}
+
+	entry := map[string]any{}
+	require.NoError(t, json.Unmarshal(lines[1], &entry))
+	require.Equal(t, "request", entry["msg"])
+	require.EqualValues(t, http.StatusNoContent, entry["status"])
+}
+
+func test_QueryTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(QueryTimeout(time.Minute, "/export"))
+	r.GET("/", func(ctx *gin.Context) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		require.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+		ctx.Status(http.StatusNoContent)
+	})
+	r.GET("/export", func(ctx *gin.Context) {
+		_, ok := ctx.Deadline()
+		require.False(t, ok)
+		ctx.Status(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/", "/export"} {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNoContent, w.Code)
+	}
+}
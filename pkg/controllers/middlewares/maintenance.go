@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReadOnlyChecker interface {
+	IsReadOnly(
+		ctx context.Context,
+	) (bool, error)
+}
+
+// 読み取り専用モードの間はGET・HEAD・OPTIONS以外のリクエストを503で拒否する
+// 読み取り専用モードを解除するためのパスはexemptPathsに指定する
+// 読み取り専用モードか確認できない場合は書き込みを受け付ける
+func ReadOnly(checker ReadOnlyChecker, exemptPaths ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+
+		readOnly, err := checker.IsReadOnly(ctx)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		if !readOnly {
+			return
+		}
+
+		for _, path := range exemptPaths {
+			if ctx.FullPath() == path {
+				return
+			}
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"message": "Service Unavailable: read-only mode"})
+	}
+}
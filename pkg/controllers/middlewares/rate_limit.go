@@ -0,0 +1,190 @@
+package middlewares
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+)
+
+const (
+	// MemoryRateLimitStoreが使われなくなったバケットを掃除する間隔
+	RATE_LIMIT_SWEEP_INTERVAL = time.Duration(1) * time.Minute
+)
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// バケットが満タンになるまでの時間
+	Reset time.Duration
+	// 次のリクエストが受け付けられるまでの時間(Allowedがfalseの場合のみ)
+	RetryAfter time.Duration
+}
+
+// 複数台構成で共有するストア(Redisなど)を使う場合はこのインターフェースを実装する
+type RateLimitStore interface {
+	Take(
+		ctx context.Context,
+		key string,
+		limit config.RateLimit,
+	) (*RateLimitResult, error)
+}
+
+type rateLimitBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*rateLimitBucket
+	sweptAt   time.Time
+	timeNowFn func() time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   map[string]*rateLimitBucket{},
+		sweptAt:   time.Now(),
+		timeNowFn: time.Now,
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(
+	ctx context.Context,
+	key string,
+	limit config.RateLimit,
+) (*RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timeNowFn()
+	capacity := float64(limit.Rate)
+	perToken := limit.Period / time.Duration(limit.Rate)
+
+	if now.Sub(s.sweptAt) > RATE_LIMIT_SWEEP_INTERVAL {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &rateLimitBucket{tokens: capacity, updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	// 経過時間分のトークンを補充する
+	elapsed := now.Sub(b.updatedAt)
+	b.tokens = math.Min(capacity, b.tokens+float64(elapsed)/float64(perToken))
+	b.updatedAt = now
+
+	ret := &RateLimitResult{}
+
+	if b.tokens >= 1 {
+		b.tokens -= 1
+		ret.Allowed = true
+	} else {
+		ret.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+
+	ret.Remaining = int(math.Floor(b.tokens))
+	ret.Reset = time.Duration((capacity - b.tokens) * float64(perToken))
+
+	return ret, nil
+}
+
+// 満タンまで回復しきったバケットは初期状態と同じなので削除する
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.updatedAt) > RATE_LIMIT_SWEEP_INTERVAL && b.tokens >= 1 {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.sweptAt = now
+}
+
+type RateLimiter struct {
+	store  RateLimitStore
+	mu     sync.RWMutex
+	limits map[string]config.RateLimit
+}
+
+func NewRateLimiter(
+	store RateLimitStore,
+	limits map[string]config.RateLimit,
+) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		limits: limits,
+	}
+}
+
+func (l *RateLimiter) SetLimits(limits map[string]config.RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits = limits
+}
+
+func (l *RateLimiter) limit(group string) (config.RateLimit, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	limit, ok := l.limits[group]
+	return limit, ok
+}
+
+// 認証の処理に到達する前に制限するため、認証系のミドルウェアより前に設定すること
+// クライアントのIPアドレス(信頼するプロキシ経由の場合はX-Forwarded-Forのアドレス)毎に制限する
+// lがnilの場合は制限しない
+func (l *RateLimiter) RateLimiting(group string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		l.take(ctx, group, group+":ip:"+ctx.ClientIP())
+	}
+}
+
+// 同じユーザが複数のIPアドレスから送るリクエストを制限するため、認証系のミドルウェアより後に設定すること
+// UID毎に制限し、未ログインの場合は制限しない
+// lがnilの場合は制限しない
+func (l *RateLimiter) UserRateLimiting(group string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		uid, exists := helpers.GetUID(ctx)
+		if !exists {
+			return
+		}
+
+		l.take(ctx, group, group+":uid:"+uid)
+	}
+}
+
+func (l *RateLimiter) take(ctx *gin.Context, group string, key string) {
+	if l == nil {
+		return
+	}
+
+	limit, ok := l.limit(group)
+	if !ok {
+		return
+	}
+
+	ret, err := l.store.Take(ctx, key, limit)
+	if err != nil {
+		// ストアに障害が発生している場合はリクエストを通す
+		return
+	}
+
+	ctx.Header("RateLimit-Limit", strconv.Itoa(limit.Rate))
+	ctx.Header("RateLimit-Remaining", strconv.Itoa(ret.Remaining))
+	ctx.Header("RateLimit-Reset", strconv.Itoa(int(math.Ceil(ret.Reset.Seconds()))))
+
+	if !ret.Allowed {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(ret.RetryAfter.Seconds()))))
+		ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"message": "Too Many Requests"})
+	}
+}
@@ -0,0 +1,191 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+)
+
+func TestRateLimit(t *testing.T) {
+	setup()
+
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+	){
+		"MemoryRateLimitStore":   test_MemoryRateLimitStore,
+		"RateLimitByUID":         test_RateLimitByUID,
+		"RateLimitByIP":          test_RateLimitByIP,
+		"RateLimitNotConfigured": test_RateLimitNotConfigured,
+	} {
+		fn := fn // ↑引数の順番通りに実行するための設定(https://github.com/golang/go/wiki/CommonMistakes)
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func test_MemoryRateLimitStore(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryRateLimitStore()
+	store.timeNowFn = func() time.Time { return now }
+
+	limit := config.RateLimit{Rate: 2, Period: 2 * time.Second}
+
+	{
+		ret, err := store.Take(context.Background(), "key", limit)
+		require.NoError(t, err)
+		require.Equal(t, true, ret.Allowed)
+		require.Equal(t, 1, ret.Remaining)
+	}
+
+	{
+		ret, err := store.Take(context.Background(), "key", limit)
+		require.NoError(t, err)
+		require.Equal(t, true, ret.Allowed)
+		require.Equal(t, 0, ret.Remaining)
+		require.Equal(t, 2*time.Second, ret.Reset)
+	}
+
+	{
+		ret, err := store.Take(context.Background(), "key", limit)
+		require.NoError(t, err)
+		require.Equal(t, false, ret.Allowed)
+		require.Equal(t, time.Second, ret.RetryAfter)
+	}
+
+	// 別のキーは独立して制限される
+	{
+		ret, err := store.Take(context.Background(), "other", limit)
+		require.NoError(t, err)
+		require.Equal(t, true, ret.Allowed)
+	}
+
+	// 1秒経過すると1トークン回復する
+	now = now.Add(time.Second)
+	{
+		ret, err := store.Take(context.Background(), "key", limit)
+		require.NoError(t, err)
+		require.Equal(t, true, ret.Allowed)
+		require.Equal(t, 0, ret.Remaining)
+	}
+}
+
+func test_RateLimitByUID(t *testing.T) {
+	rateLimiter := NewRateLimiter(
+		NewMemoryRateLimitStore(),
+		map[string]config.RateLimit{
+			config.RATE_LIMIT_GROUP_WRITE: {Rate: 2, Period: time.Minute},
+		},
+	)
+
+	r := gin.New()
+	r.Use(rateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+	r.Use(authorization.RequiredAuthorization)
+	r.Use(rateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+	r.POST("/", func(ctx *gin.Context) {
+		ctx.Status(http.StatusCreated)
+	})
+
+	do := func(remoteAddr string, uid string) *httptest.ResponseRecorder {
+		tokenString, err := generateToken(uid, authorization.jwtSecret)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	{
+		w := do("192.0.2.1:1234", "uid")
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		require.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	}
+
+	// IPアドレスを変えても同じユーザとして制限される
+	{
+		w := do("192.0.2.2:1234", "uid")
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	}
+
+	{
+		w := do("192.0.2.3:1234", "uid")
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.Equal(t, "30", w.Header().Get("Retry-After"))
+	}
+
+	// 別のユーザは制限されない
+	{
+		w := do("192.0.2.3:1234", "other")
+		require.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	// 認証に失敗したリクエストはIPアドレス毎にのみ制限される
+	{
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = "192.0.2.4:1234"
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	}
+}
+
+func test_RateLimitByIP(t *testing.T) {
+	rateLimiter := NewRateLimiter(
+		NewMemoryRateLimitStore(),
+		map[string]config.RateLimit{
+			config.RATE_LIMIT_GROUP_WRITE: {Rate: 1, Period: time.Minute},
+		},
+	)
+
+	r := gin.New()
+	require.NoError(t, r.SetTrustedProxies(nil))
+
+	// 認証に失敗するリクエストも認証の処理より前に制限する
+	r.POST("/", rateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE), func(ctx *gin.Context) {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+	})
+
+	do := func(remoteAddr string, forwardedFor string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, do("192.0.2.1:1234", "198.51.100.1"))
+
+	// 信頼するプロキシを経由していない場合、X-Forwarded-Forを変えても同じクライアントとして扱う
+	require.Equal(t, http.StatusTooManyRequests, do("192.0.2.1:1234", "198.51.100.2"))
+
+	require.Equal(t, http.StatusUnauthorized, do("192.0.2.2:1234", "198.51.100.1"))
+}
+
+func test_RateLimitNotConfigured(t *testing.T) {
+	rateLimiter := NewRateLimiter(
+		NewMemoryRateLimitStore(),
+		map[string]config.RateLimit{},
+	)
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	rateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ)(ctx)
+
+	require.Equal(t, false, ctx.IsAborted())
+	require.Equal(t, "", w.Header().Get("RateLimit-Limit"))
+}
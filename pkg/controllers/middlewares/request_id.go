@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+)
+
+const (
+	REQUEST_ID_HEADER     = "X-Request-Id"
+	REQUEST_ID_MAX_LENGTH = 128
+)
+
+func generateRequestId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// クライアントから指定されたリクエストIDがあればそれを使い、なければ生成する
+func RequestId(ctx *gin.Context) {
+	requestId := ctx.GetHeader(REQUEST_ID_HEADER)
+	if requestId == "" || len(requestId) > REQUEST_ID_MAX_LENGTH {
+		requestId = generateRequestId()
+	}
+
+	helpers.SetRequestId(ctx, requestId)
+	ctx.Header(REQUEST_ID_HEADER, requestId)
+}
@@ -1,36 +1,81 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
 	"github.com/vsrecorder/vsr-apiserver/pkg/services"
 )
 
+const (
+	NEARBY_PATH  = "/nearby"
+	SUMMARY_PATH = "/summary"
+)
+
 type OfficialEventController struct {
 	router  *gin.Engine
+	common  *Common
 	service services.OfficialEventServiceInterface
 }
 
 func NewOfficialEventController(
 	router *gin.Engine,
+	common *Common,
 	service services.OfficialEventServiceInterface,
 ) *OfficialEventController {
-	return &OfficialEventController{router, service}
+	return &OfficialEventController{router, common, service}
 }
 
 func (c *OfficialEventController) RegisterRoutes(relativePath string) {
 	r := c.router.Group(relativePath + "/official_events")
+	r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
 	r.GET("", c.Get)
+	r.GET(NEARBY_PATH, c.GetNearby)
 	r.GET("/:id", c.GetById)
-	r.GET("/:id"+RECORDS_PATH, c.GetRecordById)
+
+	// 公開範囲がフォロワーのRecordを返すか判定するため、ログインしている場合はユーザを特定する
+	r.GET("/:id"+RECORDS_PATH, c.common.Authorization.OptionalAuthorization, c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ), c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_READ), c.GetRecordById)
+	r.GET("/:id"+SUMMARY_PATH, c.common.Authorization.OptionalAuthorization, c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ), c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_READ), c.GetSummaryById)
+
+	c.router.GET(
+		relativePath+"/official_events"+ICALENDAR_EXTENSION,
+		c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ),
+		c.GetICalendar,
+	)
+}
+
+// 検索条件を指定した場合、または日付とページを同時に指定した場合は検索する
+func isOfficialEventSearch(ctx *gin.Context) bool {
+	for _, value := range []string{
+		helpers.GetKeyword(ctx),
+		helpers.GetPrefecture(ctx),
+		helpers.GetTypeId(ctx),
+		helpers.GetLeagueId(ctx),
+		helpers.GetRegulationId(ctx),
+		helpers.GetShopId(ctx),
+		helpers.GetSort(ctx),
+	} {
+		if value != "" {
+			return true
+		}
+	}
+
+	return helpers.GetPage(ctx) != "" && (helpers.GetStartDate(ctx) != "" || helpers.GetEndDate(ctx) != "")
 }
 
 func (c *OfficialEventController) Get(ctx *gin.Context) {
+	if isOfficialEventSearch(ctx) {
+		c.Search(ctx)
+		return
+	}
+
 	if helpers.GetStartDate(ctx) != "" || helpers.GetEndDate(ctx) != "" {
-		startDate, endDate, err := ParseDate(ctx)
+		startDate, endDate, err := c.common.ParseDate(ctx)
 		if err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{
 				"message": ErrInvalidParameter.Error(),
@@ -57,9 +102,9 @@ func (c *OfficialEventController) Get(ctx *gin.Context) {
 			return
 		}
 
-		offset := PAGE_LIMIT * (page - 1)
+		offset := c.common.PageLimit * (page - 1)
 
-		ret, err := c.service.Find(ctx, PAGE_LIMIT, offset)
+		ret, err := c.service.Find(ctx, c.common.PageLimit, offset)
 		if err != nil {
 			ctx.JSON(http.StatusNotFound, gin.H{
 				"message": ErrOfficialEventNotFound.Error(),
@@ -69,7 +114,7 @@ func (c *OfficialEventController) Get(ctx *gin.Context) {
 
 		ctx.JSON(http.StatusOK, gin.H{
 			"page":            page,
-			"limit":           PAGE_LIMIT,
+			"limit":           c.common.PageLimit,
 			"offset":          offset,
 			"official_events": ret,
 		})
@@ -77,6 +122,212 @@ func (c *OfficialEventController) Get(ctx *gin.Context) {
 	}
 }
 
+func (c *OfficialEventController) parseOfficialEventSearch(ctx *gin.Context) (*dtos.OfficialEventSearch, error) {
+	dto := &dtos.OfficialEventSearch{
+		Keyword:    helpers.GetKeyword(ctx),
+		Prefecture: helpers.GetPrefecture(ctx),
+		Sort:       helpers.GetSort(ctx),
+	}
+
+	var err error
+	for _, param := range []struct {
+		value string
+		id    *uint
+	}{
+		{helpers.GetTypeId(ctx), &dto.TypeId},
+		{helpers.GetLeagueId(ctx), &dto.LeagueId},
+		{helpers.GetRegulationId(ctx), &dto.RegulationId},
+		{helpers.GetShopId(ctx), &dto.ShopId},
+	} {
+		if *param.id, err = ParseId(param.value); err != nil {
+			return nil, err
+		}
+	}
+
+	if dto.StartDate, dto.EndDate, err = c.common.ParseDateRange(ctx); err != nil {
+		return nil, err
+	}
+
+	return dto, nil
+}
+
+func (c *OfficialEventController) Search(ctx *gin.Context) {
+	page, err := ParsePage(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	if page == 0 {
+		page = 1
+	}
+
+	offset := c.common.PageLimit * (page - 1)
+
+	dto, err := c.parseOfficialEventSearch(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.Search(ctx, dto, c.common.PageLimit, offset)
+	if errors.Is(err, services.ErrInvalidSearchCondition) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"page":            page,
+		"limit":           c.common.PageLimit,
+		"offset":          offset,
+		"official_events": ret,
+	})
+}
+
+// 一覧と同じ条件で絞り込んだ大会をiCalendar形式で返す
+// 日付を指定しない場合は今日以降に開催される大会を返す
+func (c *OfficialEventController) GetICalendar(ctx *gin.Context) {
+	page, err := ParsePage(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	if page == 0 {
+		page = 1
+	}
+
+	dto, err := c.parseOfficialEventSearch(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	if dto.StartDate.IsZero() && dto.EndDate.IsZero() {
+		if dto.StartDate, err = c.common.ParseToday(ctx); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": ErrInvalidParameter.Error(),
+			})
+			return
+		}
+	}
+
+	ret, err := c.service.Search(ctx, dto, ICALENDAR_EVENT_LIMIT, ICALENDAR_EVENT_LIMIT*(page-1))
+	if errors.Is(err, services.ErrInvalidSearchCondition) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	events := []*iCalendarEvent{}
+	for _, officialEvent := range ret {
+		events = append(events, &iCalendarEvent{officialEvent: officialEvent})
+	}
+
+	writeICalendar(ctx, OFFICIAL_EVENTS_CALENDAR_NAME, events)
+}
+
+// 日付を指定しない場合は今日以降に開催される大会を返す
+func (c *OfficialEventController) GetNearby(ctx *gin.Context) {
+	latitude, err := strconv.ParseFloat(helpers.GetLatitude(ctx), 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	longitude, err := strconv.ParseFloat(helpers.GetLongitude(ctx), 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	radiusKm := 0.0
+	if value := helpers.GetRadiusKm(ctx); value != "" {
+		if radiusKm, err = strconv.ParseFloat(value, 64); err != nil || radiusKm <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": ErrInvalidParameter.Error(),
+			})
+			return
+		}
+	}
+
+	startDate, endDate, err := c.common.ParseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	if startDate.IsZero() && endDate.IsZero() {
+		if startDate, err = c.common.ParseToday(ctx); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": ErrInvalidParameter.Error(),
+			})
+			return
+		}
+	}
+
+	page, err := ParsePage(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	if page == 0 {
+		page = 1
+	}
+
+	offset := c.common.PageLimit * (page - 1)
+
+	ret, err := c.service.FindNearby(ctx, latitude, longitude, radiusKm, startDate, endDate, c.common.PageLimit, offset)
+	if errors.Is(err, services.ErrInvalidSearchCondition) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	} else if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"page":            page,
+		"limit":           c.common.PageLimit,
+		"offset":          offset,
+		"official_events": ret,
+	})
+}
+
 func (c *OfficialEventController) GetById(ctx *gin.Context) {
 
 	// 取得したパラメータが数値か否か
@@ -128,8 +379,32 @@ func (c *OfficialEventController) GetRecordById(ctx *gin.Context) {
 
 	}
 
+	uid, _ := helpers.GetUID(ctx)
+
 	id := uint(tmpId)
-	ret, err := c.service.FindRecordById(ctx, id)
+	ret, err := c.service.FindRecordById(ctx, id, uid)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": ErrOfficialEventNotFound.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *OfficialEventController) GetSummaryById(ctx *gin.Context) {
+	id, err := ParseId(helpers.GetId(ctx))
+	if err != nil || id == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindSummaryById(ctx, id, uid)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"message": ErrOfficialEventNotFound.Error(),
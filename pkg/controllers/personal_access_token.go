@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	PERSONAL_ACCESS_TOKENS_PATH = "/tokens"
+)
+
+type PersonalAccessTokenController struct {
+	router  *gin.Engine
+	common  *Common
+	service services.PersonalAccessTokenServiceInterface
+}
+
+func NewPersonalAccessTokenController(
+	router *gin.Engine,
+	common *Common,
+	service services.PersonalAccessTokenServiceInterface,
+) *PersonalAccessTokenController {
+	return &PersonalAccessTokenController{router, common, service}
+}
+
+func (c *PersonalAccessTokenController) RegisterRoutes(relativePath string) {
+	r := c.router.Group(relativePath + USERS_PATH + "/me" + PERSONAL_ACCESS_TOKENS_PATH)
+	r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+	r.Use(c.common.Authorization.RequiredAuthorization)
+	r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+	r.Use(c.common.Authorization.RequiredSessionAuthorization)
+	r.GET("", c.Get)
+	r.POST("", c.Create)
+	r.DELETE("/:id", c.Delete)
+}
+
+func (c *PersonalAccessTokenController) Get(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindAllByUID(ctx, uid)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *PersonalAccessTokenController) Create(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+	dto := dtos.PersonalAccessToken{}
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.Create(ctx, uid, &dto)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *PersonalAccessTokenController) Delete(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	err := c.service.Delete(ctx, id, uid)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "accepted",
+	})
+}
@@ -4,9 +4,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
-	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/middlewares"
 	"github.com/vsrecorder/vsr-apiserver/pkg/services"
 )
 
@@ -16,20 +16,25 @@ const (
 
 type RecordController struct {
 	router  *gin.Engine
+	common  *Common
 	service services.RecordServiceInterface
 }
 
 func NewRecordController(
 	router *gin.Engine,
+	common *Common,
 	service services.RecordServiceInterface,
 ) *RecordController {
-	return &RecordController{router, service}
+	return &RecordController{router, common, service}
 }
 
 func (c *RecordController) RegisterRoutes(relativePath string) {
 	{
 		r := c.router.Group(relativePath + RECORDS_PATH)
-		r.Use(middlewares.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_WRITE))
 		r.POST("", c.Create)
 		r.PUT("/:id", c.Update)
 		r.DELETE("/:id", c.Delete)
@@ -37,12 +42,19 @@ func (c *RecordController) RegisterRoutes(relativePath string) {
 
 	{
 		r := c.router.Group(relativePath + RECORDS_PATH)
-		r.Use(middlewares.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_READ))
 		r.GET("", c.Get)
 	}
 
 	{
 		r := c.router.Group(relativePath + RECORDS_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_READ))
 		r.GET("/:id", c.GetById)
 		r.GET("/:id"+GAMES_PATH, c.GetGameById)
 	}
@@ -59,10 +71,18 @@ func (c *RecordController) Get(ctx *gin.Context) {
 		return
 	}
 
-	offset := PAGE_LIMIT * (page - 1)
+	offset := c.common.PageLimit * (page - 1)
+
+	startDate, endDate, err := c.common.ParseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
 
 	if existsUID {
-		ret, err := c.service.FindByUID(ctx, uid, PAGE_LIMIT, offset)
+		ret, err := c.service.FindByUID(ctx, uid, startDate, endDate, c.common.PageLimit, offset)
 
 		if err != nil {
 			ctx.JSON(http.StatusNotFound, gin.H{
@@ -74,14 +94,14 @@ func (c *RecordController) Get(ctx *gin.Context) {
 		//ctx.JSON(http.StatusOK, ret)
 		ctx.JSON(http.StatusOK, gin.H{
 			"page":    page,
-			"limit":   PAGE_LIMIT,
+			"limit":   c.common.PageLimit,
 			"offset":  offset,
 			"records": ret,
 		})
 
 		return
 	} else {
-		ret, err := c.service.Find(ctx, PAGE_LIMIT, offset)
+		ret, err := c.service.Find(ctx, startDate, endDate, c.common.PageLimit, offset)
 		if err != nil {
 			ctx.JSON(http.StatusNotFound, gin.H{
 				"message": RecordNotFound.Error(),
@@ -92,7 +112,7 @@ func (c *RecordController) Get(ctx *gin.Context) {
 		//ctx.JSON(http.StatusOK, ret)
 		ctx.JSON(http.StatusOK, gin.H{
 			"page":    page,
-			"limit":   PAGE_LIMIT,
+			"limit":   c.common.PageLimit,
 			"offset":  offset,
 			"records": ret,
 		})
@@ -103,11 +123,11 @@ func (c *RecordController) Get(ctx *gin.Context) {
 
 func (c *RecordController) GetById(ctx *gin.Context) {
 	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
 
-	ret, err := c.service.FindById(ctx, id)
-
+	ret, err := c.service.FindByIdWithUID(ctx, id, uid)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(findErrorStatus(err), gin.H{
 			"message": err.Error(),
 		})
 		return
@@ -118,10 +138,11 @@ func (c *RecordController) GetById(ctx *gin.Context) {
 
 func (c *RecordController) GetGameById(ctx *gin.Context) {
 	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
 
-	ret, err := c.service.FindGameById(ctx, id)
+	ret, err := c.service.FindGameByIdWithUID(ctx, id, uid)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(findErrorStatus(err), gin.H{
 			"message": err.Error(),
 		})
 		return
@@ -4,40 +4,103 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
-	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/middlewares"
 	"github.com/vsrecorder/vsr-apiserver/pkg/services"
 )
 
 const (
-	USERS_PATH = "/users"
+	USERS_PATH    = "/users"
+	SETTINGS_PATH = "/settings"
+	PROFILE_PATH  = "/profile"
+	SYNC_PATH     = "/sync"
+	FOLLOW_PATH   = "/follow"
+
+	// Firebase AuthenticationのUIDの最大長
+	USER_ID_MAX_LENGTH = 128
+	// idsは区切り文字を含めて、取得できる上限の数のUIDが収まる長さまで受け付ける
+	USER_IDS_QUERY_MAX_LENGTH = (USER_ID_MAX_LENGTH + 1) * services.USER_FIND_BY_IDS_LIMIT
 )
 
 type UserController struct {
 	router  *gin.Engine
+	common  *Common
 	service services.UserServiceInterface
 }
 
 func NewUserController(
 	router *gin.Engine,
+	common *Common,
 	service services.UserServiceInterface,
 ) *UserController {
-	return &UserController{router, service}
+	return &UserController{router, common, service}
 }
 
 func (c *UserController) RegisterRoutes(relativePath string) {
 	{
 		r := c.router.Group(relativePath + USERS_PATH)
-		r.GET("/:id", c.GetById)
+		r.GET("", c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_USERS), c.Get)
+		r.GET("/:id", c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_USERS), c.GetById)
+	}
+
+	{
+		r := c.router.Group(relativePath + USERS_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_RECORDS_READ))
 		r.GET("/:id"+RECORDS_PATH, c.GetRecordsById)
 		r.GET("/:id"+GAMES_PATH, c.GetGamesById)
 	}
 
 	{
 		r := c.router.Group(relativePath + USERS_PATH)
-		r.Use(middlewares.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.OptionalAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_READ))
+		r.Use(c.common.Authorization.RequiredScope(helpers.SCOPE_DECKS_READ))
 		r.GET("/:id"+DECKS_PATH, c.GetDecksById)
 	}
+
+	{
+		r := c.router.Group(relativePath + USERS_PATH)
+		r.Use(c.common.RateLimiter.RateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredAuthorization)
+		r.Use(c.common.RateLimiter.UserRateLimiting(config.RATE_LIMIT_GROUP_WRITE))
+		r.Use(c.common.Authorization.RequiredSessionAuthorization)
+		r.GET("/me"+SETTINGS_PATH, c.GetSetting)
+		r.PUT("/me"+SETTINGS_PATH, c.UpdateSetting)
+		r.PUT("/me"+PROFILE_PATH, c.UpdateProfile)
+		r.POST("/me"+SYNC_PATH, c.Sync)
+		r.PUT("/:id"+FOLLOW_PATH, c.Follow)
+		r.DELETE("/:id"+FOLLOW_PATH, c.Unfollow)
+	}
+}
+
+func (c *UserController) Get(ctx *gin.Context) {
+	if len(ctx.Query("ids")) > USER_IDS_QUERY_MAX_LENGTH {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ids := helpers.GetIds(ctx)
+	if len(ids) == 0 || len(ids) > services.USER_FIND_BY_IDS_LIMIT {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.FindByIds(ctx, ids)
+	if err != nil {
+		writeFindError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
 }
 
 func (c *UserController) GetById(ctx *gin.Context) {
@@ -46,7 +109,7 @@ func (c *UserController) GetById(ctx *gin.Context) {
 	ret, err := c.service.FindById(ctx, id)
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		ctx.JSON(findErrorStatus(err), gin.H{
 			"message": err.Error(),
 		})
 		return
@@ -57,8 +120,17 @@ func (c *UserController) GetById(ctx *gin.Context) {
 
 func (c *UserController) GetRecordsById(ctx *gin.Context) {
 	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
 
-	ret, err := c.service.FindRecordsById(ctx, id)
+	startDate, endDate, err := c.common.ParseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.FindRecordsByIdWithUID(ctx, id, uid, startDate, endDate)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -72,8 +144,17 @@ func (c *UserController) GetRecordsById(ctx *gin.Context) {
 
 func (c *UserController) GetGamesById(ctx *gin.Context) {
 	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	startDate, endDate, err := c.common.ParseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
 
-	ret, err := c.service.FindGamesById(ctx, id)
+	ret, err := c.service.FindGamesByIdWithUID(ctx, id, uid, startDate, endDate)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -89,7 +170,15 @@ func (c *UserController) GetDecksById(ctx *gin.Context) {
 	id := helpers.GetId(ctx)
 	uid, _ := helpers.GetUID(ctx)
 
-	ret, err := c.service.FindDecksByIdWithUID(ctx, id, uid)
+	startDate, endDate, err := c.common.ParseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": ErrInvalidParameter.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.FindDecksByIdWithUID(ctx, id, uid, startDate, endDate)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -100,3 +189,105 @@ func (c *UserController) GetDecksById(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, ret)
 }
+
+func (c *UserController) GetSetting(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.FindSettingByUID(ctx, uid)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *UserController) UpdateSetting(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+	dto := dtos.UserSetting{}
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.UpdateSetting(ctx, uid, &dto)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *UserController) Follow(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	if err := c.service.Follow(ctx, id, uid); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "accepted",
+	})
+}
+
+func (c *UserController) Unfollow(ctx *gin.Context) {
+	id := helpers.GetId(ctx)
+	uid, _ := helpers.GetUID(ctx)
+
+	if err := c.service.Unfollow(ctx, id, uid); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "accepted",
+	})
+}
+
+func (c *UserController) Sync(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+
+	ret, err := c.service.Sync(ctx, uid)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (c *UserController) UpdateProfile(ctx *gin.Context) {
+	uid, _ := helpers.GetUID(ctx)
+	dto := dtos.UserProfile{}
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ret, err := c.service.UpdateProfile(ctx, uid, &dto)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
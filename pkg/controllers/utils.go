@@ -1,18 +1,42 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/middlewares"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
 )
 
 const (
-	PAGE_LIMIT  = 20
 	DATE_LAYOUT = "2006-01-02"
 )
 
+// 日付で絞り込むときにユーザが設定したタイムゾーンを参照する
+type TimeZoneFinder interface {
+	FindTimeZoneByUID(
+		ctx context.Context,
+		uid string,
+	) (*time.Location, error)
+}
+
+// 全てのコントローラで共通して使うミドルウェアと設定
+// 起動時にサーバが作成し、各コントローラに渡す
+type Common struct {
+	Authorization *middlewares.Authorization
+	// nilの場合はリクエスト数を制限しない
+	RateLimiter *middlewares.RateLimiter
+	// nilの場合はユーザの設定を参照せずに既定のタイムゾーンを使う
+	TimeZoneFinder TimeZoneFinder
+	// 一覧取得で1ページあたりに返す件数
+	PageLimit int
+}
+
 func ParsePage(ctx *gin.Context) (int, error) {
 	pageNumber := helpers.GetPage(ctx)
 
@@ -33,7 +57,51 @@ func ParsePage(ctx *gin.Context) (int, error) {
 	}
 }
 
-func ParseDate(ctx *gin.Context) (time.Time, time.Time, error) {
+// クエリで指定された正の整数のidを返す
+// 指定されていない場合は0を返す
+func ParseId(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, err
+	} else if id == 0 {
+		return 0, ErrInvalidParameter
+	}
+
+	return uint(id), nil
+}
+
+// 公開範囲により閲覧できない場合は存在しない場合と同様に404を返す
+func findErrorStatus(err error) int {
+	if errors.Is(err, services.ErrNotVisible) || errors.Is(err, services.ErrUserNotFound) || errors.Is(err, services.ErrNotFound) {
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
+
+// findErrorStatusのステータスでエラーを返す
+// 想定していないエラーは内容をレスポンスに含めず、ログにのみ記録する
+func writeFindError(ctx *gin.Context, err error) {
+	status := findErrorStatus(err)
+	if status != http.StatusInternalServerError {
+		ctx.JSON(status, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.Error(err)
+	ctx.JSON(status, gin.H{
+		"message": http.StatusText(status),
+	})
+}
+
+// start_date・end_dateの日付をタイムゾーンで解釈し、[start_dateの0時, end_dateの翌日0時)をUTCで返す
+func (c *Common) ParseDate(ctx *gin.Context) (time.Time, time.Time, error) {
 	startDate, err := time.Parse(DATE_LAYOUT, helpers.GetStartDate(ctx))
 	if err != nil {
 		return time.Time{}, time.Time{}, err
@@ -44,18 +112,52 @@ func ParseDate(ctx *gin.Context) (time.Time, time.Time, error) {
 		return time.Time{}, time.Time{}, err
 	}
 
-	jst, err := time.LoadLocation("Asia/Tokyo")
+	// startDate > endDate
+	if startDate.After(endDate) {
+		return time.Time{}, time.Time{}, ErrInvalidParameter
+	}
+
+	loc, err := c.ParseTimeZone(ctx)
 	if err != nil {
 		return time.Time{}, time.Time{}, err
 	}
 
-	startDate = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, jst)
-	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 59, jst)
+	startDate = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	endDate = time.Date(endDate.Year(), endDate.Month(), endDate.Day()+1, 0, 0, 0, 0, loc)
 
-	// startDate > endDate
-	if !startDate.Before(endDate) && !startDate.Equal(endDate) {
-		return time.Time{}, time.Time{}, err
+	return startDate.UTC(), endDate.UTC(), nil
+}
+
+// start_date・end_dateのどちらも指定しない場合はゼロ値を返し、絞り込まない
+func (c *Common) ParseDateRange(ctx *gin.Context) (time.Time, time.Time, error) {
+	if helpers.GetStartDate(ctx) == "" && helpers.GetEndDate(ctx) == "" {
+		return time.Time{}, time.Time{}, nil
 	}
 
-	return startDate, endDate, nil
+	return c.ParseDate(ctx)
+}
+
+// tzで指定されたタイムゾーン、ログインしている場合はユーザの設定、どちらも無い場合は既定のタイムゾーンを返す
+func (c *Common) ParseTimeZone(ctx *gin.Context) (*time.Location, error) {
+	if tz := helpers.GetTimeZone(ctx); tz != "" {
+		return services.LoadTimeZone(tz)
+	}
+
+	if uid, exists := helpers.GetUID(ctx); exists && c.TimeZoneFinder != nil {
+		return c.TimeZoneFinder.FindTimeZoneByUID(ctx, uid)
+	}
+
+	return services.LoadTimeZone("")
+}
+
+// タイムゾーンでの今日の0時をUTCで返す
+func (c *Common) ParseToday(ctx *gin.Context) (time.Time, error) {
+	loc, err := c.ParseTimeZone(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now().In(loc)
+
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).UTC(), nil
 }
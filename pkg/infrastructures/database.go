@@ -0,0 +1,114 @@
+package infrastructures
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func NewDatabase(dbConfig *config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := newDatabase(dbConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	db.Logger = newQueryLogger(orDefault(dbConfig.SlowQueryThreshold, config.DEFAULT_SLOW_QUERY_THRESHOLD))
+
+	if err := registerTracing(db); err != nil {
+		CloseDatabase(db)
+		return nil, err
+	}
+
+	name := dbConfig.Name
+	if dbConfig.Driver == config.DB_DRIVER_SQLITE {
+		name = dbConfig.Path
+	}
+
+	if err := registerDBStats(db, name); err != nil {
+		CloseDatabase(db)
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func CloseDatabase(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
+
+func newDatabase(dbConfig *config.DatabaseConfig) (*gorm.DB, error) {
+	switch dbConfig.Driver {
+	case "", config.DB_DRIVER_MYSQL:
+		db, err := NewMySQL(dbConfig.UserName, dbConfig.Password, dbConfig.Hostname, dbConfig.Port, dbConfig.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+
+		sqlDB.SetMaxOpenConns(orDefault(dbConfig.MaxOpenConns, config.DEFAULT_MAX_OPEN_CONNS))
+		sqlDB.SetMaxIdleConns(orDefault(dbConfig.MaxIdleConns, config.DEFAULT_MAX_IDLE_CONNS))
+		sqlDB.SetConnMaxLifetime(orDefault(dbConfig.ConnMaxLifetime, config.DEFAULT_CONN_MAX_LIFETIME))
+
+		return db, nil
+	case config.DB_DRIVER_SQLITE:
+		return NewSQLite(dbConfig.Path)
+	default:
+		return nil, errors.New("unsupported database driver: " + dbConfig.Driver)
+	}
+}
+
+// CreatedAtなどの自動で設定される日時をUTCにする
+func newGormConfig() *gorm.Config {
+	return &gorm.Config{
+		NowFunc: func() time.Time {
+			return time.Now().UTC()
+		},
+	}
+}
+
+func orDefault[T int | time.Duration](value T, defaultValue T) T {
+	if value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
+
+// SQLiteは同時に1つの書き込みしか行えないため、接続を1つに制限する
+// インメモリの場合も接続を閉じない限りデータが保持される
+func NewSQLite(path string) (*gorm.DB, error) {
+	if path == "" {
+		return nil, errors.New("sqlite path is required")
+	}
+
+	dsn := path
+	if path == config.SQLITE_IN_MEMORY {
+		dsn = "file::memory:?cache=shared"
+	}
+
+	db, err := gorm.Open(sqlite.Open(dsn), newGormConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB.SetMaxOpenConns(1)
+
+	return db, nil
+}
@@ -0,0 +1,46 @@
+package infrastructures
+
+import (
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SQLファイルの各文を順に実行する
+func ExecSQLFile(db *gorm.DB, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return execSQL(db, string(b))
+}
+
+// 文は行末の ";" で区切られているものとする
+func execSQL(db *gorm.DB, sql string) error {
+	statement := ""
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		statement += line + "\n"
+
+		if strings.HasSuffix(trimmed, ";") {
+			if tx := db.Exec(statement); tx.Error != nil {
+				return tx.Error
+			}
+			statement = ""
+		}
+	}
+
+	if strings.TrimSpace(statement) != "" {
+		if tx := db.Exec(statement); tx.Error != nil {
+			return tx.Error
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package infrastructures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/logging"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// GORMのログをctxのロガー(リクエストID付き)に出力する
+// 全てのクエリはdebug、遅いクエリはwarn、失敗したクエリはerrorで出力する
+type queryLogger struct {
+	level         gormLogger.LogLevel
+	slowThreshold time.Duration
+}
+
+func newQueryLogger(slowThreshold time.Duration) gormLogger.Interface {
+	return &queryLogger{gormLogger.Info, slowThreshold}
+}
+
+func (l *queryLogger) LogMode(level gormLogger.LogLevel) gormLogger.Interface {
+	return &queryLogger{level, l.slowThreshold}
+}
+
+func (l *queryLogger) Info(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormLogger.Info {
+		logging.FromContext(ctx).InfoContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *queryLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormLogger.Warn {
+		logging.FromContext(ctx).WarnContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *queryLogger) Error(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormLogger.Error {
+		logging.FromContext(ctx).ErrorContext(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormLogger.Silent {
+		return
+	}
+
+	logger := logging.FromContext(ctx)
+	elapsed := time.Since(begin)
+
+	level := slog.LevelDebug
+	msg := "query"
+	switch {
+	// 見つからないことは呼び出し元で扱うためエラーにしない
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormLogger.Error:
+		level = slog.LevelError
+		msg = "query failed"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormLogger.Warn:
+		level = slog.LevelWarn
+		msg = "slow query"
+	}
+
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Duration("elapsed", elapsed),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,54 @@
+package infrastructures
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+const (
+	METRICS_NAMESPACE = "vsrecorder"
+)
+
+var (
+	firebaseRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "firebase_request_duration_seconds",
+		Help:      "Latency of Firebase Authentication API calls.",
+		Buckets:   []float64{0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0},
+	}, []string{"method", "result"})
+)
+
+// コネクションプールの状態(使用中・待機中の接続数など)を公開する
+// 同じ名前のデータベースは最初に登録したものだけを公開する
+func registerDBStats(db *gorm.DB, name string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	if err := prometheus.Register(collectors.NewDBStatsCollector(sqlDB, name)); err != nil {
+		if errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Firebase Authenticationの呼び出しにかかった時間を記録する
+// startには呼び出し前の時刻を渡す
+func ObserveFirebaseRequest(method string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	firebaseRequestDuration.WithLabelValues(method, result).Observe(time.Since(start).Seconds())
+}
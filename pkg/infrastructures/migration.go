@@ -0,0 +1,295 @@
+package infrastructures
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// マイグレーションはmigrations/<ドライバ名>/<バージョン>_<名前>.(up|down).sql に置く
+// 全てのドライバで同じバージョンを用意する
+//
+//go:embed migrations
+var migrationFS embed.FS
+
+var (
+	ErrSchemaOutOfDate  = errors.New("database schema is out of date")
+	ErrUnknownMigration = errors.New("unknown migration version")
+)
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   uint       `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// 適用済みのマイグレーションを記録する
+type schemaMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []*Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db, migrations}, nil
+}
+
+// 埋め込まれたマイグレーションをバージョン順に読み込む
+func LoadMigrations(driver string) ([]*Migration, error) {
+	dir := path.Join("migrations", driver)
+
+	entries, err := fs.ReadDir(migrationFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver: %s", driver)
+	}
+
+	migrationMap := map[uint]*Migration{}
+	for _, entry := range entries {
+		base, ok := strings.CutSuffix(entry.Name(), ".sql")
+		if !ok {
+			continue
+		}
+
+		base, direction, ok := strings.Cut(base, ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		v, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		b, err := migrationFS.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrationMap[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: name}
+			migrationMap[uint(version)] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("duplicate migration version: %d", version)
+		}
+
+		if direction == "up" {
+			migration.Up = string(b)
+		} else {
+			migration.Down = string(b)
+		}
+	}
+
+	migrations := []*Migration{}
+	for _, migration := range migrationMap {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d must have both up and down", migration.Version)
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) applied() (map[uint]*schemaMigration, error) {
+	if err := m.db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	var rows []*schemaMigration
+	if tx := m.db.Find(&rows); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	applied := map[uint]*schemaMigration{}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	return applied, nil
+}
+
+// 適用済みの最新のバージョン(未適用の場合は0)
+func (m *Migrator) Version() (uint, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	version := uint(0)
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+
+	return version, nil
+}
+
+func (m *Migrator) Status() ([]*MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []*MigrationStatus{}
+	for _, migration := range m.migrations {
+		status := &MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// 未適用のマイグレーションを全て適用する
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// 最後に適用したマイグレーションを1つ戻す
+func (m *Migrator) Down() error {
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	if version == 0 {
+		return nil
+	}
+
+	target := uint(0)
+	for _, migration := range m.migrations {
+		if migration.Version < version {
+			target = migration.Version
+		}
+	}
+
+	return m.To(target)
+}
+
+// 指定されたバージョンまで適用または巻き戻す(0の場合は全て巻き戻す)
+func (m *Migrator) To(version uint) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("%w: %d", ErrUnknownMigration, version)
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+
+		if err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := execSQL(tx, migration.Up); err != nil {
+				return err
+			}
+
+			return tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		}); err != nil {
+			return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+
+		if err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := execSQL(tx, migration.Down); err != nil {
+				return err
+			}
+
+			return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+		}); err != nil {
+			return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// 起動時にスキーマが最新のマイグレーションまで適用されているか確認する
+func (m *Migrator) Check() error {
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	if version != m.Latest() {
+		return fmt.Errorf("%w: current version %d, latest version %d", ErrSchemaOutOfDate, version, m.Latest())
+	}
+
+	return nil
+}
+
+func (m *Migrator) find(version uint) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package infrastructures
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm/schema"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	mysqlMigrations, err := LoadMigrations(config.DB_DRIVER_MYSQL)
+	require.NoError(t, err)
+
+	sqliteMigrations, err := LoadMigrations(config.DB_DRIVER_SQLITE)
+	require.NoError(t, err)
+
+	// 全てのドライバで同じバージョンが用意されている
+	require.Equal(t, len(mysqlMigrations), len(sqliteMigrations))
+	for i := range mysqlMigrations {
+		require.Equal(t, mysqlMigrations[i].Version, sqliteMigrations[i].Version)
+		require.Equal(t, mysqlMigrations[i].Name, sqliteMigrations[i].Name)
+	}
+
+	_, err = LoadMigrations("postgres")
+	require.Error(t, err)
+}
+
+func TestMigrator(t *testing.T) {
+	db, err := NewSQLite(filepath.Join(t.TempDir(), "migration.db"))
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(db)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, migrator.Check(), ErrSchemaOutOfDate)
+
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Check())
+
+	// daosの全てのカラムがマイグレーションで作成されている
+	for _, dao := range []any{
+		&daos.Record{},
+		&daos.Game{},
+		&daos.Battle{},
+		&daos.Deck{},
+		&daos.PersonalAccessToken{},
+		&daos.AuditLog{},
+		&daos.ErasureReceipt{},
+		&daos.UserSetting{},
+		&daos.Follow{},
+		&daos.User{},
+		&daos.OfficialEventLocation{},
+		&daos.EventAttendance{},
+		&daos.MetaAggregate{},
+		&daos.MaintenanceSetting{},
+	} {
+		s, err := schema.Parse(dao, &sync.Map{}, db.NamingStrategy)
+		require.NoError(t, err)
+
+		for _, field := range s.Fields {
+			if field.DBName == "" {
+				continue
+			}
+
+			require.True(t, db.Migrator().HasColumn(dao, field.DBName), "%s.%s", s.Table, field.DBName)
+		}
+
+		for _, index := range s.ParseIndexes() {
+			require.True(t, db.Migrator().HasIndex(dao, index.Name), "%s.%s", s.Table, index.Name)
+		}
+	}
+
+	require.NoError(t, migrator.Down())
+	version, err := migrator.Version()
+	require.NoError(t, err)
+	require.Equal(t, migrator.Latest()-1, version)
+
+	// 0003で作成したインデックスは0002まで戻すと削除される
+	require.NoError(t, migrator.To(2))
+	require.False(t, db.Migrator().HasIndex(&daos.Record{}, "idx_records_user_id"))
+
+	require.NoError(t, migrator.To(0))
+	require.False(t, db.Migrator().HasTable(&daos.Record{}))
+
+	require.ErrorIs(t, migrator.To(migrator.Latest()+1), ErrUnknownMigration)
+
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Check())
+}
+
+func TestBackfillUsersMigration(t *testing.T) {
+	db, err := NewSQLite(filepath.Join(t.TempDir(), "backfill.db"))
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(db)
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.To(12))
+
+	require.NoError(t, db.Create(&daos.Record{ID: "record", UserId: "owner"}).Error)
+	require.NoError(t, db.Create(&daos.Deck{ID: "deck", UserId: "deck-owner"}).Error)
+	require.NoError(t, db.Create(&daos.User{UID: "synced", DisplayName: "synced", SyncedAt: time.Now()}).Error)
+
+	require.NoError(t, migrator.Up())
+
+	// 同期していないユーザも登録され、同期済みのユーザは変更されない
+	var users []*daos.User
+	require.NoError(t, db.Order("uid").Find(&users).Error)
+	require.Len(t, users, 3)
+	require.Equal(t, "deck-owner", users[0].UID)
+	require.Equal(t, "owner", users[1].UID)
+	require.True(t, users[1].SyncedAt.IsZero())
+	require.Equal(t, "synced", users[2].DisplayName)
+
+	// 戻すと同期していないユーザのみ削除される
+	require.NoError(t, migrator.To(12))
+	require.NoError(t, db.Find(&users).Error)
+	require.Len(t, users, 1)
+}
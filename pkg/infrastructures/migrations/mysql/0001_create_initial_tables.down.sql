@@ -0,0 +1,5 @@
+-- official_eventsはimport-officialevent-batが管理するため削除しない
+DROP TABLE IF EXISTS `decks`;
+DROP TABLE IF EXISTS `battles`;
+DROP TABLE IF EXISTS `games`;
+DROP TABLE IF EXISTS `records`;
@@ -0,0 +1,79 @@
+-- official_eventsはimport-officialevent-batが管理するため、存在しない場合のみ作成する
+-- 既存のデータベースではテーブルは変更されないため、カラムの追加は後のマイグレーションで行う
+CREATE TABLE IF NOT EXISTS `official_events` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `title` longtext,
+  `address` longtext,
+  `venue` longtext,
+  `date` datetime(3) NULL,
+  `started_at` datetime(3) NULL,
+  `ended_at` datetime(3) NULL,
+  `deck_count` longtext,
+  `type_id` bigint unsigned,
+  `csp_flg` boolean,
+  `league_id` bigint unsigned,
+  `regulation_id` bigint unsigned,
+  `capacity` bigint unsigned,
+  `attr_id` bigint unsigned,
+  `shop_id` bigint unsigned,
+  PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `records` (
+  `id` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `official_event_id` bigint unsigned,
+  `user_id` varchar(191),
+  `deck_id` varchar(191),
+  PRIMARY KEY (`id`),
+  INDEX `idx_records_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `games` (
+  `id` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `record_id` varchar(191),
+  `user_id` varchar(191),
+  `opponents_user_id` varchar(191),
+  `bo3_flg` boolean,
+  `qualifying_round_flg` boolean,
+  `final_tournament_flg` boolean,
+  `victory_flg` boolean,
+  `opponents_deck_info` longtext,
+  `memo` longtext,
+  PRIMARY KEY (`id`),
+  INDEX `idx_games_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `battles` (
+  `id` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `game_id` varchar(191),
+  `user_id` varchar(191),
+  `go_first` boolean,
+  `victory_flg` boolean,
+  `your_prize_cards` bigint unsigned,
+  `opponents_prize_cards` bigint unsigned,
+  `memo` longtext,
+  PRIMARY KEY (`id`),
+  INDEX `idx_battles_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `decks` (
+  `id` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `user_id` varchar(191),
+  `name` longtext,
+  `code` longtext,
+  `private_code_flg` boolean,
+  PRIMARY KEY (`id`),
+  INDEX `idx_decks_deleted_at` (`deleted_at`)
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS `users`;
+DROP TABLE IF EXISTS `follows`;
+DROP TABLE IF EXISTS `user_settings`;
+DROP TABLE IF EXISTS `erasure_receipts`;
+DROP TABLE IF EXISTS `audit_logs`;
+DROP TABLE IF EXISTS `personal_access_tokens`;
@@ -0,0 +1,82 @@
+CREATE TABLE IF NOT EXISTS `personal_access_tokens` (
+  `id` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `user_id` varchar(191),
+  `name` longtext,
+  `token_hash` varchar(191),
+  `scopes` longtext,
+  `last_used_at` datetime(3) NULL,
+  `expires_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_personal_access_tokens_deleted_at` (`deleted_at`),
+  INDEX `idx_personal_access_tokens_user_id` (`user_id`),
+  UNIQUE INDEX `idx_personal_access_tokens_token_hash` (`token_hash`)
+);
+
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+  `id` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `actor_uid` varchar(191),
+  `entity` varchar(191),
+  `entity_id` varchar(191),
+  `action` longtext,
+  `before` text,
+  `after` text,
+  `diff` text,
+  `request_id` longtext,
+  PRIMARY KEY (`id`),
+  INDEX `idx_audit_logs_actor_uid` (`actor_uid`),
+  INDEX `idx_audit_logs_entity` (`entity`, `entity_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `erasure_receipts` (
+  `id` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `uid_hash` varchar(191),
+  `records` bigint,
+  `games` bigint,
+  `battles` bigint,
+  `decks` bigint,
+  `personal_access_tokens` bigint,
+  `users` bigint,
+  `user_settings` bigint,
+  `follows` bigint,
+  `scrubbed_games` bigint,
+  `anonymized_audit_logs` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_erasure_receipts_uid_hash` (`uid_hash`)
+);
+
+CREATE TABLE IF NOT EXISTS `user_settings` (
+  `user_id` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `default_visibility` longtext,
+  `default_memo_visibility` longtext,
+  PRIMARY KEY (`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `follows` (
+  `user_id` varchar(191) NOT NULL,
+  `followee_id` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`user_id`, `followee_id`),
+  INDEX `idx_follows_followee_id` (`followee_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `users` (
+  `uid` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `display_name` longtext,
+  `photo_url` longtext,
+  `ptcgl_name` longtext,
+  `player_id` longtext,
+  `prefecture` longtext,
+  `bio` longtext,
+  `favorite_deck_id` longtext,
+  `synced_at` datetime(3) NULL,
+  PRIMARY KEY (`uid`)
+);
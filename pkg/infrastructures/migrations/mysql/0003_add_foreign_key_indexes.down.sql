@@ -0,0 +1,8 @@
+DROP INDEX `idx_decks_user_id` ON `decks`;
+DROP INDEX `idx_battles_game_id` ON `battles`;
+DROP INDEX `idx_battles_user_id` ON `battles`;
+DROP INDEX `idx_games_record_id` ON `games`;
+DROP INDEX `idx_games_user_id` ON `games`;
+DROP INDEX `idx_records_deck_id` ON `records`;
+DROP INDEX `idx_records_official_event_id` ON `records`;
+DROP INDEX `idx_records_user_id` ON `records`;
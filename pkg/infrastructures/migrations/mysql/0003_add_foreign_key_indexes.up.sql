@@ -0,0 +1,14 @@
+-- 既存のデータベースではIDのカラムがlongtextで作成されており、そのままではインデックスを作成できないため型を揃える
+ALTER TABLE `records` MODIFY COLUMN `user_id` varchar(191), MODIFY COLUMN `deck_id` varchar(191);
+ALTER TABLE `games` MODIFY COLUMN `record_id` varchar(191), MODIFY COLUMN `user_id` varchar(191);
+ALTER TABLE `battles` MODIFY COLUMN `game_id` varchar(191), MODIFY COLUMN `user_id` varchar(191);
+ALTER TABLE `decks` MODIFY COLUMN `user_id` varchar(191);
+
+CREATE INDEX `idx_records_user_id` ON `records` (`user_id`);
+CREATE INDEX `idx_records_official_event_id` ON `records` (`official_event_id`);
+CREATE INDEX `idx_records_deck_id` ON `records` (`deck_id`);
+CREATE INDEX `idx_games_user_id` ON `games` (`user_id`);
+CREATE INDEX `idx_games_record_id` ON `games` (`record_id`);
+CREATE INDEX `idx_battles_user_id` ON `battles` (`user_id`);
+CREATE INDEX `idx_battles_game_id` ON `battles` (`game_id`);
+CREATE INDEX `idx_decks_user_id` ON `decks` (`user_id`);
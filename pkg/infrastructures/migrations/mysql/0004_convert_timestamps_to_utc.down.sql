@@ -0,0 +1,11 @@
+-- UTCの日時をAsia/Tokyoの日時に戻す
+UPDATE `records` SET `created_at` = CONVERT_TZ(`created_at`, '+00:00', '+09:00'), `updated_at` = CONVERT_TZ(`updated_at`, '+00:00', '+09:00'), `deleted_at` = CONVERT_TZ(`deleted_at`, '+00:00', '+09:00');
+UPDATE `games` SET `created_at` = CONVERT_TZ(`created_at`, '+00:00', '+09:00'), `updated_at` = CONVERT_TZ(`updated_at`, '+00:00', '+09:00'), `deleted_at` = CONVERT_TZ(`deleted_at`, '+00:00', '+09:00');
+UPDATE `battles` SET `created_at` = CONVERT_TZ(`created_at`, '+00:00', '+09:00'), `updated_at` = CONVERT_TZ(`updated_at`, '+00:00', '+09:00'), `deleted_at` = CONVERT_TZ(`deleted_at`, '+00:00', '+09:00');
+UPDATE `decks` SET `created_at` = CONVERT_TZ(`created_at`, '+00:00', '+09:00'), `updated_at` = CONVERT_TZ(`updated_at`, '+00:00', '+09:00'), `deleted_at` = CONVERT_TZ(`deleted_at`, '+00:00', '+09:00');
+UPDATE `personal_access_tokens` SET `created_at` = CONVERT_TZ(`created_at`, '+00:00', '+09:00'), `updated_at` = CONVERT_TZ(`updated_at`, '+00:00', '+09:00'), `deleted_at` = CONVERT_TZ(`deleted_at`, '+00:00', '+09:00'), `last_used_at` = CONVERT_TZ(`last_used_at`, '+00:00', '+09:00'), `expires_at` = CONVERT_TZ(`expires_at`, '+00:00', '+09:00');
+UPDATE `audit_logs` SET `created_at` = CONVERT_TZ(`created_at`, '+00:00', '+09:00');
+UPDATE `erasure_receipts` SET `created_at` = CONVERT_TZ(`created_at`, '+00:00', '+09:00');
+UPDATE `user_settings` SET `created_at` = CONVERT_TZ(`created_at`, '+00:00', '+09:00'), `updated_at` = CONVERT_TZ(`updated_at`, '+00:00', '+09:00');
+UPDATE `follows` SET `created_at` = CONVERT_TZ(`created_at`, '+00:00', '+09:00');
+UPDATE `users` SET `created_at` = CONVERT_TZ(`created_at`, '+00:00', '+09:00'), `updated_at` = CONVERT_TZ(`updated_at`, '+00:00', '+09:00'), `synced_at` = CONVERT_TZ(`synced_at`, '+00:00', '+09:00');
@@ -0,0 +1 @@
+ALTER TABLE `user_settings` ADD COLUMN `time_zone` varchar(64) NOT NULL DEFAULT '';
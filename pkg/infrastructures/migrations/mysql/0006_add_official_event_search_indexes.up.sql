@@ -0,0 +1,8 @@
+-- 大会の検索で使う条件は開催日と組み合わせて絞り込むため、開催日を含めた複合インデックスにする
+CREATE INDEX `idx_official_events_date` ON `official_events` (`date`);
+CREATE INDEX `idx_official_events_type_id_date` ON `official_events` (`type_id`, `date`);
+CREATE INDEX `idx_official_events_league_id_date` ON `official_events` (`league_id`, `date`);
+CREATE INDEX `idx_official_events_regulation_id_date` ON `official_events` (`regulation_id`, `date`);
+CREATE INDEX `idx_official_events_shop_id_date` ON `official_events` (`shop_id`, `date`);
+-- 都道府県は住所の前方一致で絞り込む
+CREATE INDEX `idx_official_events_address` ON `official_events` (`address`(32));
@@ -0,0 +1,12 @@
+-- 大会の会場の座標
+-- official_eventsはimport-officialevent-batが管理するため、アプリ側で別のテーブルに保持する
+CREATE TABLE IF NOT EXISTS `official_event_locations` (
+  `official_event_id` bigint unsigned NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `latitude` double NOT NULL,
+  `longitude` double NOT NULL,
+  `matched_name` longtext,
+  PRIMARY KEY (`official_event_id`),
+  INDEX `idx_official_event_locations_latitude_longitude` (`latitude`, `longitude`)
+);
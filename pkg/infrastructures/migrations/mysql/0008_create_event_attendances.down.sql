@@ -0,0 +1,3 @@
+ALTER TABLE `erasure_receipts` DROP COLUMN `event_attendances`;
+
+DROP TABLE IF EXISTS `event_attendances`;
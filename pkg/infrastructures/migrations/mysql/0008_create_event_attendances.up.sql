@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `event_attendances` (
+  `user_id` varchar(191) NOT NULL,
+  `official_event_id` bigint unsigned NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `status` varchar(191),
+  `record_id` varchar(191) NOT NULL DEFAULT '',
+  PRIMARY KEY (`user_id`, `official_event_id`),
+  INDEX `idx_event_attendances_official_event_id` (`official_event_id`),
+  INDEX `idx_event_attendances_record_id` (`record_id`)
+);
+
+ALTER TABLE `erasure_receipts` ADD COLUMN `event_attendances` bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP INDEX `idx_user_settings_calendar_token_hash` ON `user_settings`;
+ALTER TABLE `user_settings` DROP COLUMN `calendar_token_hash`;
@@ -0,0 +1,3 @@
+-- カレンダーの購読URLに含めるトークンのハッシュ値(空の場合は購読を無効にしている)
+ALTER TABLE `user_settings` ADD COLUMN `calendar_token_hash` varchar(64) NOT NULL DEFAULT '';
+CREATE INDEX `idx_user_settings_calendar_token_hash` ON `user_settings` (`calendar_token_hash`);
@@ -0,0 +1,2 @@
+DROP INDEX `idx_games_created_at` ON `games`;
+DROP TABLE IF EXISTS `meta_aggregates`;
@@ -0,0 +1,15 @@
+-- 公開されている対戦のアーキタイプ別の使用数・勝利数を1時間ごとに集計したもの
+-- 環境レポートのリクエストの度にgamesを走査しないようにする
+CREATE TABLE IF NOT EXISTS `meta_aggregates` (
+  `bucket` datetime(3) NOT NULL,
+  `regulation_id` bigint unsigned NOT NULL,
+  `side` varchar(16) NOT NULL,
+  `archetype` varchar(191) NOT NULL,
+  `updated_at` datetime(3) NULL,
+  `games` bigint NOT NULL DEFAULT 0,
+  `wins` bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (`bucket`, `regulation_id`, `side`, `archetype`)
+);
+
+-- 直近の対戦のみを集計し直すため
+CREATE INDEX `idx_games_created_at` ON `games` (`created_at`);
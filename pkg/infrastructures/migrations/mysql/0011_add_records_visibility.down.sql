@@ -0,0 +1 @@
+ALTER TABLE `records` DROP COLUMN `memo_visibility`, DROP COLUMN `visibility`;
@@ -0,0 +1 @@
+ALTER TABLE `records` ADD COLUMN `visibility` varchar(191) NOT NULL DEFAULT 'public', ADD COLUMN `memo_visibility` varchar(191) NOT NULL DEFAULT 'private';
@@ -0,0 +1,2 @@
+-- 受領証は削除時に返した秘密の値を知っている本人か管理者のみが参照できる
+ALTER TABLE `erasure_receipts` ADD COLUMN `secret_hash` varchar(64) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- 取り込みを行うとsynced_atが設定されるため、一度も取り込んでいない行のみ削除する
+DELETE FROM `users` WHERE `synced_at` IS NULL;
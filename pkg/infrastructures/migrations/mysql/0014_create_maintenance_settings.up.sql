@@ -0,0 +1,7 @@
+-- 読み取り専用モードなど全てのインスタンスで共有するメンテナンスの設定(id = 1の1行のみ)
+CREATE TABLE IF NOT EXISTS `maintenance_settings` (
+  `id` bigint unsigned NOT NULL,
+  `updated_at` datetime(3) NULL,
+  `read_only` boolean NOT NULL DEFAULT false,
+  PRIMARY KEY (`id`)
+);
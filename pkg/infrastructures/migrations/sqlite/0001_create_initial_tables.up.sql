@@ -0,0 +1,83 @@
+-- official_eventsはimport-officialevent-batが管理するため、存在しない場合のみ作成する
+-- 既存のデータベースではテーブルは変更されないため、カラムの追加は後のマイグレーションで行う
+CREATE TABLE IF NOT EXISTS `official_events` (
+  `id` integer NOT NULL,
+  `title` text,
+  `address` text,
+  `venue` text,
+  `date` datetime,
+  `started_at` datetime,
+  `ended_at` datetime,
+  `deck_count` text,
+  `type_id` integer,
+  `csp_flg` numeric,
+  `league_id` integer,
+  `regulation_id` integer,
+  `capacity` integer,
+  `attr_id` integer,
+  `shop_id` integer,
+  PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `records` (
+  `id` text NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `official_event_id` integer,
+  `user_id` text,
+  `deck_id` text,
+  PRIMARY KEY (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_records_deleted_at` ON `records` (`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `games` (
+  `id` text NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `record_id` text,
+  `user_id` text,
+  `opponents_user_id` text,
+  `bo3_flg` numeric,
+  `qualifying_round_flg` numeric,
+  `final_tournament_flg` numeric,
+  `victory_flg` numeric,
+  `opponents_deck_info` text,
+  `memo` text,
+  PRIMARY KEY (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_games_deleted_at` ON `games` (`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `battles` (
+  `id` text NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `game_id` text,
+  `user_id` text,
+  `go_first` numeric,
+  `victory_flg` numeric,
+  `your_prize_cards` integer,
+  `opponents_prize_cards` integer,
+  `memo` text,
+  PRIMARY KEY (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_battles_deleted_at` ON `battles` (`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `decks` (
+  `id` text NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `user_id` text,
+  `name` text,
+  `code` text,
+  `private_code_flg` numeric,
+  PRIMARY KEY (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_decks_deleted_at` ON `decks` (`deleted_at`);
@@ -0,0 +1,86 @@
+CREATE TABLE IF NOT EXISTS `personal_access_tokens` (
+  `id` text NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `user_id` text,
+  `name` text,
+  `token_hash` text,
+  `scopes` text,
+  `last_used_at` datetime,
+  `expires_at` datetime,
+  PRIMARY KEY (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_personal_access_tokens_deleted_at` ON `personal_access_tokens` (`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_personal_access_tokens_user_id` ON `personal_access_tokens` (`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_personal_access_tokens_token_hash` ON `personal_access_tokens` (`token_hash`);
+
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+  `id` text NOT NULL,
+  `created_at` datetime,
+  `actor_uid` text,
+  `entity` text,
+  `entity_id` text,
+  `action` text,
+  `before` text,
+  `after` text,
+  `diff` text,
+  `request_id` text,
+  PRIMARY KEY (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_audit_logs_actor_uid` ON `audit_logs` (`actor_uid`);
+CREATE INDEX IF NOT EXISTS `idx_audit_logs_entity` ON `audit_logs` (`entity`, `entity_id`);
+
+CREATE TABLE IF NOT EXISTS `erasure_receipts` (
+  `id` text NOT NULL,
+  `created_at` datetime,
+  `uid_hash` text,
+  `records` integer,
+  `games` integer,
+  `battles` integer,
+  `decks` integer,
+  `personal_access_tokens` integer,
+  `users` integer,
+  `user_settings` integer,
+  `follows` integer,
+  `scrubbed_games` integer,
+  `anonymized_audit_logs` integer,
+  PRIMARY KEY (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_erasure_receipts_uid_hash` ON `erasure_receipts` (`uid_hash`);
+
+CREATE TABLE IF NOT EXISTS `user_settings` (
+  `user_id` text NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `default_visibility` text,
+  `default_memo_visibility` text,
+  PRIMARY KEY (`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `follows` (
+  `user_id` text NOT NULL,
+  `followee_id` text NOT NULL,
+  `created_at` datetime,
+  PRIMARY KEY (`user_id`, `followee_id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_follows_followee_id` ON `follows` (`followee_id`);
+
+CREATE TABLE IF NOT EXISTS `users` (
+  `uid` text NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `display_name` text,
+  `photo_url` text,
+  `ptcgl_name` text,
+  `player_id` text,
+  `prefecture` text,
+  `bio` text,
+  `favorite_deck_id` text,
+  `synced_at` datetime,
+  PRIMARY KEY (`uid`)
+);
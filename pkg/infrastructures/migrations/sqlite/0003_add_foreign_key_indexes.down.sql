@@ -0,0 +1,8 @@
+DROP INDEX `idx_decks_user_id`;
+DROP INDEX `idx_battles_game_id`;
+DROP INDEX `idx_battles_user_id`;
+DROP INDEX `idx_games_record_id`;
+DROP INDEX `idx_games_user_id`;
+DROP INDEX `idx_records_deck_id`;
+DROP INDEX `idx_records_official_event_id`;
+DROP INDEX `idx_records_user_id`;
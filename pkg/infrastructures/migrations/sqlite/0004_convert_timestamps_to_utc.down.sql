@@ -0,0 +1 @@
+-- SQLiteは開発環境でのみ使い、以前の日時はオフセット付きで保存しているため変換しない
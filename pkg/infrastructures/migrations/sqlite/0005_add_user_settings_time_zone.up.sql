@@ -0,0 +1 @@
+ALTER TABLE `user_settings` ADD COLUMN `time_zone` text NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+DROP INDEX `idx_official_events_address`;
+DROP INDEX `idx_official_events_shop_id_date`;
+DROP INDEX `idx_official_events_regulation_id_date`;
+DROP INDEX `idx_official_events_league_id_date`;
+DROP INDEX `idx_official_events_type_id_date`;
+DROP INDEX `idx_official_events_date`;
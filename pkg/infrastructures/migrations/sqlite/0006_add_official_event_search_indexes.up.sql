@@ -0,0 +1,6 @@
+CREATE INDEX `idx_official_events_date` ON `official_events` (`date`);
+CREATE INDEX `idx_official_events_type_id_date` ON `official_events` (`type_id`, `date`);
+CREATE INDEX `idx_official_events_league_id_date` ON `official_events` (`league_id`, `date`);
+CREATE INDEX `idx_official_events_regulation_id_date` ON `official_events` (`regulation_id`, `date`);
+CREATE INDEX `idx_official_events_shop_id_date` ON `official_events` (`shop_id`, `date`);
+CREATE INDEX `idx_official_events_address` ON `official_events` (`address`);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `official_event_locations`;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `official_event_locations` (
+  `official_event_id` integer NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `latitude` real NOT NULL,
+  `longitude` real NOT NULL,
+  `matched_name` text,
+  PRIMARY KEY (`official_event_id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_official_event_locations_latitude_longitude` ON `official_event_locations` (`latitude`, `longitude`);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `event_attendances` (
+  `user_id` text NOT NULL,
+  `official_event_id` integer NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `status` text,
+  `record_id` text NOT NULL DEFAULT '',
+  PRIMARY KEY (`user_id`, `official_event_id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_event_attendances_official_event_id` ON `event_attendances` (`official_event_id`);
+CREATE INDEX IF NOT EXISTS `idx_event_attendances_record_id` ON `event_attendances` (`record_id`);
+
+ALTER TABLE `erasure_receipts` ADD COLUMN `event_attendances` integer NOT NULL DEFAULT 0;
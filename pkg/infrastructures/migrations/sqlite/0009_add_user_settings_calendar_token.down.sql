@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_user_settings_calendar_token_hash`;
+ALTER TABLE `user_settings` DROP COLUMN `calendar_token_hash`;
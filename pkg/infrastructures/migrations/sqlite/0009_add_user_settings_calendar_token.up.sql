@@ -0,0 +1,2 @@
+ALTER TABLE `user_settings` ADD COLUMN `calendar_token_hash` text NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS `idx_user_settings_calendar_token_hash` ON `user_settings` (`calendar_token_hash`);
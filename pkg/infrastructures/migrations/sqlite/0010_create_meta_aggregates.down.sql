@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_games_created_at`;
+DROP TABLE IF EXISTS `meta_aggregates`;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `meta_aggregates` (
+  `bucket` datetime NOT NULL,
+  `regulation_id` integer NOT NULL,
+  `side` text NOT NULL,
+  `archetype` text NOT NULL,
+  `updated_at` datetime,
+  `games` integer NOT NULL DEFAULT 0,
+  `wins` integer NOT NULL DEFAULT 0,
+  PRIMARY KEY (`bucket`, `regulation_id`, `side`, `archetype`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_games_created_at` ON `games` (`created_at`);
@@ -0,0 +1,2 @@
+ALTER TABLE `records` DROP COLUMN `memo_visibility`;
+ALTER TABLE `records` DROP COLUMN `visibility`;
@@ -0,0 +1,2 @@
+ALTER TABLE `records` ADD COLUMN `visibility` text NOT NULL DEFAULT 'public';
+ALTER TABLE `records` ADD COLUMN `memo_visibility` text NOT NULL DEFAULT 'private';
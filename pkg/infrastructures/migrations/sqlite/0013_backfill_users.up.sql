@@ -0,0 +1,11 @@
+-- 以前はPOST /users/me/syncを呼ぶまでusersに登録されず、データを持つユーザのプロフィールを参照できなかったため登録する
+-- 表示名・画像は取り込まず、synced_atがNULLのまま次に認証したリクエストで取り込む
+INSERT INTO `users` (`uid`, `created_at`, `updated_at`, `display_name`, `photo_url`, `ptcgl_name`, `player_id`, `prefecture`, `bio`, `favorite_deck_id`)
+SELECT `user_id`, MIN(`created_at`), MIN(`created_at`), '', '', '', '', '', '', ''
+FROM (
+  SELECT `user_id`, `created_at` FROM `records` WHERE `deleted_at` IS NULL
+  UNION ALL
+  SELECT `user_id`, `created_at` FROM `decks` WHERE `deleted_at` IS NULL
+) AS `owners`
+WHERE `user_id` <> '' AND `user_id` NOT IN (SELECT `uid` FROM `users`)
+GROUP BY `user_id`;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS `maintenance_settings` (
+  `id` integer NOT NULL,
+  `updated_at` datetime,
+  `read_only` numeric NOT NULL DEFAULT false,
+  PRIMARY KEY (`id`)
+);
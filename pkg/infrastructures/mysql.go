@@ -14,8 +14,9 @@ func NewMySQL(
 	dbPort string,
 	dbName string,
 ) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", userName, password, dbHostname, dbPort, dbName)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// 日時はUTCで保存し、セッションのタイムゾーンもUTCにしてNOW()などと揃える
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%%27%%2B00%%3A00%%27", userName, password, dbHostname, dbPort, dbName)
+	db, err := gorm.Open(mysql.Open(dsn), newGormConfig())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,86 @@
+package infrastructures
+
+import (
+	"errors"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	TRACING_CALLBACK_PREFIX = "tracing:"
+
+	tracingSpanKey = "tracing:span"
+)
+
+var (
+	tracer = tracing.Tracer("infrastructures")
+)
+
+// 全てのクエリをスパンとして記録する
+// 同じ*gorm.DBに対して複数回呼び出しても一度だけ登録する
+func registerTracing(db *gorm.DB) error {
+	if db.Callback().Query().Get(TRACING_CALLBACK_PREFIX+"before_query") != nil {
+		return nil
+	}
+
+	callback := db.Callback()
+	for _, processor := range []struct {
+		name   string
+		before func(string, func(*gorm.DB)) error
+		after  func(string, func(*gorm.DB)) error
+	}{
+		{"create", callback.Create().Before("gorm:create").Register, callback.Create().After("gorm:create").Register},
+		{"query", callback.Query().Before("gorm:query").Register, callback.Query().After("gorm:query").Register},
+		{"update", callback.Update().Before("gorm:update").Register, callback.Update().After("gorm:update").Register},
+		{"delete", callback.Delete().Before("gorm:delete").Register, callback.Delete().After("gorm:delete").Register},
+		{"row", callback.Row().Before("gorm:row").Register, callback.Row().After("gorm:row").Register},
+		{"raw", callback.Raw().Before("gorm:raw").Register, callback.Raw().After("gorm:raw").Register},
+	} {
+		if err := processor.before(TRACING_CALLBACK_PREFIX+"before_"+processor.name, startQuerySpan("gorm."+processor.name)); err != nil {
+			return err
+		}
+
+		if err := processor.after(TRACING_CALLBACK_PREFIX+"after_"+processor.name, endQuerySpan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func startQuerySpan(name string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx, span := tracer.Start(tx.Statement.Context, name, trace.WithSpanKind(trace.SpanKindClient))
+		tx.Statement.Context = ctx
+		tx.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+func endQuerySpan(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBSystemKey.String(tx.Dialector.Name()),
+		semconv.DBStatement(tx.Statement.SQL.String()),
+		semconv.DBSQLTable(tx.Statement.Table),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+
+	// 見つからないことは呼び出し元で扱うためエラーにしない
+	if !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		tracing.RecordError(span, tx.Error)
+	}
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FORMAT_JSON = "json"
+	FORMAT_TEXT = "text"
+
+	DEFAULT_FORMAT = FORMAT_JSON
+	DEFAULT_LEVEL  = "info"
+)
+
+type Config struct {
+	// json または text
+	Format string `yaml:"format"`
+	// debug, info, warn, error
+	Level string `yaml:"level"`
+}
+
+type loggerKey struct{}
+
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return level, errors.New("invalid log level: " + value)
+	}
+
+	return level, nil
+}
+
+func New(w io.Writer, config *Config) (*slog.Logger, error) {
+	level, err := ParseLevel(config.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(config.Format) {
+	case "", FORMAT_JSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FORMAT_TEXT:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, errors.New("invalid log format: " + config.Format)
+	}
+}
+
+// リクエストIDなどを付与したロガーをサービス・リポジトリに引き継ぐ
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// ctxにロガーが設定されていなければデフォルトのロガーを返す
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+
+	return slog.Default()
+}
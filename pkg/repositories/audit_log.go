@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm"
+)
+
+type AuditLogRepositoryInterface interface {
+	Find(
+		ctx context.Context,
+		condition *daos.AuditLog,
+		startDate time.Time,
+		endDate time.Time,
+		limit int,
+		offset int,
+	) ([]*daos.AuditLog, error)
+
+	FindByUID(
+		ctx context.Context,
+		uid string,
+		startDate time.Time,
+		endDate time.Time,
+		limit int,
+		offset int,
+	) ([]*daos.AuditLog, error)
+
+	Create(
+		ctx context.Context,
+		dao *daos.AuditLog,
+	) error
+
+	// ownedEntitiesはエンティティ名と、user_idカラムを持つdaoの対応
+	AnonymizeByUID(
+		ctx context.Context,
+		uid string,
+		pseudonym string,
+		ownedEntities map[string]any,
+	) (int64, error)
+}
+
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(
+	db *gorm.DB,
+) AuditLogRepositoryInterface {
+	return &AuditLogRepository{db}
+}
+
+func (r *AuditLogRepository) Find(
+	ctx context.Context,
+	condition *daos.AuditLog,
+	startDate time.Time,
+	endDate time.Time,
+	limit int,
+	offset int,
+) ([]*daos.AuditLog, error) {
+	var auditLogs []*daos.AuditLog
+	if tx := conn(ctx, r.db).Where(condition).Scopes(dateRange("created_at", startDate, endDate)).Order("created_at DESC").Limit(limit).Offset(offset).Find(&auditLogs); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return auditLogs, nil
+}
+
+func (r *AuditLogRepository) FindByUID(
+	ctx context.Context,
+	uid string,
+	startDate time.Time,
+	endDate time.Time,
+	limit int,
+	offset int,
+) ([]*daos.AuditLog, error) {
+	return r.Find(ctx, &daos.AuditLog{ActorUID: uid}, startDate, endDate, limit, offset)
+}
+
+// 既存の行を上書きしないようにSaveではなくCreateを使う
+func (r *AuditLogRepository) Create(
+	ctx context.Context,
+	dao *daos.AuditLog,
+) error {
+	if tx := conn(ctx, r.db).Create(dao); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+// アカウント削除時のみ使用し、所有するエンティティを削除する前に呼び出す
+// 次の行の操作者・対象のuidをpseudonymに置き換え、個人データを含み得る変更内容を消去する
+//   - uidが操作した行
+//   - uidのユーザや、uidが所有するエンティティ(削除済みのものを含む)を対象にした行(運営者による参照・削除など)
+//
+// それ以外の行も、変更内容に含まれるuid(対戦相手として記録された場合など)はpseudonymに置き換える
+func (r *AuditLogRepository) AnonymizeByUID(
+	ctx context.Context,
+	uid string,
+	pseudonym string,
+	ownedEntities map[string]any,
+) (int64, error) {
+	affected := int64(0)
+
+	tx := conn(ctx, r.db).Model(&daos.AuditLog{}).Where(&daos.AuditLog{ActorUID: uid}).Updates(map[string]any{
+		"actor_uid": pseudonym,
+		"entity_id": gorm.Expr("CASE WHEN entity_id = ? THEN ? ELSE entity_id END", uid, pseudonym),
+		"before":    "",
+		"after":     "",
+		"diff":      "",
+	})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	affected += tx.RowsAffected
+
+	condition := conn(ctx, r.db).Where("entity_id = ?", uid)
+	for entity, model := range ownedEntities {
+		ids := conn(ctx, r.db).Unscoped().Model(model).Select("id").Where("user_id = ?", uid)
+		condition = condition.Or("entity = ? AND entity_id IN (?)", entity, ids)
+	}
+
+	// 操作者を置き換えた行は既に消去している
+	tx = conn(ctx, r.db).Model(&daos.AuditLog{}).Where(condition).Where("actor_uid <> ?", pseudonym).Updates(map[string]any{
+		"entity_id": gorm.Expr("CASE WHEN entity_id = ? THEN ? ELSE entity_id END", uid, pseudonym),
+		"before":    "",
+		"after":     "",
+		"diff":      "",
+	})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	affected += tx.RowsAffected
+
+	pattern := "%" + likeEscaper.Replace(uid) + "%"
+	tx = conn(ctx, r.db).Model(&daos.AuditLog{}).
+		Where(
+			"`before` LIKE ? ESCAPE '"+LIKE_ESCAPE_CHARACTER+"' OR `after` LIKE ? ESCAPE '"+LIKE_ESCAPE_CHARACTER+"' OR `diff` LIKE ? ESCAPE '"+LIKE_ESCAPE_CHARACTER+"'",
+			pattern, pattern, pattern,
+		).
+		Updates(map[string]any{
+			"before": gorm.Expr("REPLACE(`before`, ?, ?)", uid, pseudonym),
+			"after":  gorm.Expr("REPLACE(`after`, ?, ?)", uid, pseudonym),
+			"diff":   gorm.Expr("REPLACE(`diff`, ?, ?)", uid, pseudonym),
+		})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return affected + tx.RowsAffected, nil
+}
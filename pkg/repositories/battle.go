@@ -13,6 +13,10 @@ type BattleRepositoryInterface interface {
 		id string,
 	) (*daos.Battle, error)
 
+	Count(
+		ctx context.Context,
+	) (int64, error)
+
 	FindByGameId(
 		ctx context.Context,
 		gameId string,
@@ -28,6 +32,19 @@ type BattleRepositoryInterface interface {
 		id string,
 		uid string,
 	) error
+
+	DeleteAllByUID(
+		ctx context.Context,
+		uid string,
+	) (int64, error)
+
+	// idの昇順にafterIdより後のBattleをlimit件返す
+	FindPageByUID(
+		ctx context.Context,
+		uid string,
+		afterId string,
+		limit int,
+	) ([]*daos.Battle, error)
 }
 
 type BattleRepository struct {
@@ -45,7 +62,7 @@ func (r *BattleRepository) FindById(
 	id string,
 ) (*daos.Battle, error) {
 	dao := daos.Battle{}
-	if tx := r.db.Where(&daos.Battle{ID: id}).First(&dao); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Battle{ID: id}).First(&dao); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -58,18 +75,30 @@ func (r *BattleRepository) FindByGameId(
 ) ([]*daos.Battle, error) {
 	var battles []*daos.Battle
 
-	if tx := r.db.Where(&daos.Battle{GameId: gameId}).Find(&battles); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Battle{GameId: gameId}).Find(&battles); tx.Error != nil {
 		return nil, tx.Error
 	}
 
 	return battles, nil
 }
 
+func (r *BattleRepository) Count(
+	ctx context.Context,
+) (int64, error) {
+	var count int64
+
+	if tx := conn(ctx, r.db).Model(&daos.Battle{}).Count(&count); tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return count, nil
+}
+
 func (r *BattleRepository) Save(
 	ctx context.Context,
 	dao *daos.Battle,
 ) error {
-	if tx := r.db.Save(dao); tx.Error != nil {
+	if tx := conn(ctx, r.db).Save(dao); tx.Error != nil {
 		return tx.Error
 	}
 
@@ -81,9 +110,37 @@ func (r *BattleRepository) Delete(
 	id string,
 	uid string,
 ) error {
-	if tx := r.db.Where(&daos.Record{ID: id, UserId: uid}).Delete(&daos.Battle{}); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Record{ID: id, UserId: uid}).Delete(&daos.Battle{}); tx.Error != nil {
 		return tx.Error
 	}
 
 	return nil
 }
+
+// 論理削除ではなく物理削除する
+func (r *BattleRepository) DeleteAllByUID(
+	ctx context.Context,
+	uid string,
+) (int64, error) {
+	tx := conn(ctx, r.db).Unscoped().Where(&daos.Battle{UserId: uid}).Delete(&daos.Battle{})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return tx.RowsAffected, nil
+}
+
+func (r *BattleRepository) FindPageByUID(
+	ctx context.Context,
+	uid string,
+	afterId string,
+	limit int,
+) ([]*daos.Battle, error) {
+	var battles []*daos.Battle
+
+	if tx := conn(ctx, r.db).Where(&daos.Battle{UserId: uid}).Scopes(afterIdPage(afterId, limit)).Find(&battles); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return battles, nil
+}
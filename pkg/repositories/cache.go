@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"sync"
+	"time"
+)
+
+type ttlCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// 有効期限付きのインメモリキャッシュ
+// 期限切れのエントリは参照時と、ttl毎に書き込み時にまとめて削除する
+type ttlCache[V any] struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]ttlCacheEntry[V]
+	sweptAt   time.Time
+	timeNowFn func() time.Time
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{
+		ttl:       ttl,
+		entries:   map[string]ttlCacheEntry[V]{},
+		timeNowFn: time.Now,
+	}
+}
+
+func (c *ttlCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	if !c.timeNowFn().Before(entry.expiresAt) {
+		delete(c.entries, key)
+
+		var zero V
+		return zero, false
+	}
+
+	return entry.value, true
+}
+
+func (c *ttlCache[V]) set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.timeNowFn()
+
+	if now.Sub(c.sweptAt) >= c.ttl {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.sweptAt = now
+	}
+
+	c.entries[key] = ttlCacheEntry[V]{
+		value:     value,
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+func (c *ttlCache[V]) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLCache(t *testing.T) {
+	now := time.Now()
+
+	c := newTTLCache[string](time.Minute)
+	c.timeNowFn = func() time.Time { return now }
+
+	c.set("key", "value")
+
+	value, ok := c.get("key")
+	require.True(t, ok)
+	require.Equal(t, "value", value)
+
+	// 有効期限を過ぎたエントリは取得できない
+	now = now.Add(time.Minute)
+	_, ok = c.get("key")
+	require.False(t, ok)
+
+	c.set("key", "value")
+	c.delete("key")
+	_, ok = c.get("key")
+	require.False(t, ok)
+
+	// 書き込み時に期限切れのエントリがまとめて削除される
+	c.set("expired", "value")
+	now = now.Add(2 * time.Minute)
+	c.set("key", "value")
+	require.Len(t, c.entries, 1)
+}
@@ -0,0 +1,19 @@
+package daos
+
+import (
+	"time"
+)
+
+// 監査ログは追記のみで更新・削除はしない
+type AuditLog struct {
+	ID        string `gorm:"primaryKey"`
+	CreatedAt time.Time
+	ActorUID  string `gorm:"index"`
+	Entity    string `gorm:"index:idx_audit_logs_entity"`
+	EntityId  string `gorm:"index:idx_audit_logs_entity"`
+	Action    string
+	Before    string `gorm:"type:text"`
+	After     string `gorm:"type:text"`
+	Diff      string `gorm:"type:text"`
+	RequestId string
+}
@@ -11,8 +11,8 @@ type Battle struct {
 	CreatedAt           time.Time
 	UpdatedAt           time.Time
 	DeletedAt           gorm.DeletedAt `gorm:"index"`
-	GameId              string
-	UserId              string
+	GameId              string         `gorm:"index"`
+	UserId              string         `gorm:"index"`
 	GoFirst             bool
 	VictoryFlg          bool
 	YourPrizeCards      uint
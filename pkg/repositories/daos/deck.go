@@ -11,7 +11,7 @@ type Deck struct {
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	DeletedAt      gorm.DeletedAt `gorm:"index"`
-	UserId         string
+	UserId         string         `gorm:"index"`
 	Name           string
 	Code           string
 	PrivateCodeFlg bool
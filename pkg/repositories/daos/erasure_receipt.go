@@ -0,0 +1,25 @@
+package daos
+
+import (
+	"time"
+)
+
+// アカウント削除の完了証明
+// 削除後もUIDそのものは保持せず、UIDのハッシュ値のみを保持する
+type ErasureReceipt struct {
+	ID                   string `gorm:"primaryKey"`
+	CreatedAt            time.Time
+	UIDHash              string `gorm:"index"`
+	SecretHash           string
+	Records              int64
+	Games                int64
+	Battles              int64
+	Decks                int64
+	PersonalAccessTokens int64
+	Users                int64
+	UserSettings         int64
+	Follows              int64
+	EventAttendances     int64
+	ScrubbedGames        int64
+	AnonymizedAuditLogs  int64
+}
@@ -0,0 +1,16 @@
+package daos
+
+import (
+	"time"
+)
+
+// UserIdのユーザがOfficialEventIdの大会に参加する予定であることなどを表す
+type EventAttendance struct {
+	UserId          string `gorm:"primaryKey"`
+	OfficialEventId uint   `gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Status          string
+	// 開催日に自動で作成したRecord(作成前は空)
+	RecordId string `gorm:"index"`
+}
@@ -0,0 +1,12 @@
+package daos
+
+import (
+	"time"
+)
+
+// UserIdのユーザがFolloweeIdのユーザをフォローしていることを表す
+type Follow struct {
+	UserId     string `gorm:"primaryKey"`
+	FolloweeId string `gorm:"primaryKey;index"`
+	CreatedAt  time.Time
+}
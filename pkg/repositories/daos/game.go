@@ -7,12 +7,12 @@ import (
 )
 
 type Game struct {
-	ID                 string `gorm:"primaryKey"`
-	CreatedAt          time.Time
+	ID                 string    `gorm:"primaryKey"`
+	CreatedAt          time.Time `gorm:"index"`
 	UpdatedAt          time.Time
 	DeletedAt          gorm.DeletedAt `gorm:"index"`
-	RecordId           string
-	UserId             string
+	RecordId           string         `gorm:"index"`
+	UserId             string         `gorm:"index"`
 	OpponentsUserId    string
 	BO3Flg             bool
 	QualifyingRoundFlg bool
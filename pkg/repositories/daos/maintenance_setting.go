@@ -0,0 +1,13 @@
+package daos
+
+import (
+	"time"
+)
+
+// 全てのインスタンスで共有するメンテナンスの設定
+// Idが1の行のみを使う
+type MaintenanceSetting struct {
+	Id        uint `gorm:"primaryKey;autoIncrement:false"`
+	UpdatedAt time.Time
+	ReadOnly  bool
+}
@@ -0,0 +1,17 @@
+package daos
+
+import (
+	"time"
+)
+
+// 公開されている対戦のアーキタイプ別の使用数・勝利数を1時間ごとに集計したもの
+// Sideが対戦相手の場合、Winsは対戦相手が勝った(記録したユーザが負けた)数になる
+type MetaAggregate struct {
+	Bucket       time.Time `gorm:"primaryKey"`
+	RegulationId uint      `gorm:"primaryKey;autoIncrement:false"`
+	Side         string    `gorm:"primaryKey;size:16"`
+	Archetype    string    `gorm:"primaryKey;size:191"`
+	UpdatedAt    time.Time
+	Games        int64
+	Wins         int64
+}
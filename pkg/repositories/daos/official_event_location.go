@@ -0,0 +1,17 @@
+package daos
+
+import (
+	"time"
+)
+
+// 大会の会場の座標
+// 住所をジオコーディングした結果で、取り込み時に一度だけ求める
+type OfficialEventLocation struct {
+	OfficialEventId uint `gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Latitude        float64 `gorm:"index:idx_official_event_locations_latitude_longitude,priority:1"`
+	Longitude       float64 `gorm:"index:idx_official_event_locations_latitude_longitude,priority:2"`
+	// 住所と一致したジオコーディング用テーブルの地名
+	MatchedName string
+}
@@ -0,0 +1,20 @@
+package daos
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PersonalAccessToken struct {
+	ID         string `gorm:"primaryKey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	UserId     string         `gorm:"index"`
+	Name       string
+	TokenHash  string `gorm:"uniqueIndex"`
+	Scopes     string
+	LastUsedAt *time.Time
+	ExpiresAt  *time.Time
+}
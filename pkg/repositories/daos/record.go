@@ -11,7 +11,9 @@ type Record struct {
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 	DeletedAt       gorm.DeletedAt `gorm:"index"`
-	OfficialEventId uint
-	UserId          string
-	DeckId          string
+	OfficialEventId uint           `gorm:"index"`
+	UserId          string         `gorm:"index"`
+	DeckId          string         `gorm:"index"`
+	Visibility      string         `gorm:"default:public"`
+	MemoVisibility  string         `gorm:"default:private"`
 }
@@ -1,7 +1,21 @@
 package daos
 
+import (
+	"time"
+)
+
+// Firebase Authenticationのユーザ情報(表示名・画像)とアプリ独自のプロフィールを保持する
 type User struct {
-	UID         string `json:"uid"`
-	DisplayName string `json:"display_name"`
-	PhotoURL    string `json:"photo_url"`
+	UID            string `gorm:"primaryKey"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DisplayName    string
+	PhotoURL       string
+	PTCGLName      string
+	PlayerId       string
+	Prefecture     string
+	Bio            string
+	FavoriteDeckId string
+	// 最後にFirebase Authenticationから表示名・画像を取り込んだ日時
+	SyncedAt time.Time
 }
@@ -0,0 +1,17 @@
+package daos
+
+import (
+	"time"
+)
+
+type UserSetting struct {
+	UserId                string `gorm:"primaryKey"`
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	DefaultVisibility     string
+	DefaultMemoVisibility string
+	// IANAのタイムゾーン名(空の場合はデフォルトのタイムゾーン)
+	TimeZone string
+	// カレンダーの購読URLに含めるトークンのハッシュ値
+	CalendarTokenHash string `gorm:"index"`
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"time"
+
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// import-officialevent-batは日本時間の日時をタイムゾーンなしで保存する
+	OFFICIAL_EVENT_TIME_ZONE = "Asia/Tokyo"
+)
+
+var (
+	officialEventLocation = mustLoadLocation(OFFICIAL_EVENT_TIME_ZONE)
+)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return loc
+}
+
+// columnがstartDate以上endDate未満の行に絞り込む
+// ゼロ値の場合はその側を絞り込まない
+func dateRange(column string, startDate time.Time, endDate time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if !startDate.IsZero() {
+			db = db.Where(column+" >= ?", startDate.UTC())
+		}
+
+		if !endDate.IsZero() {
+			db = db.Where(column+" < ?", endDate.UTC())
+		}
+
+		return db
+	}
+}
+
+// official_eventsの日時と比較できるように日本時間の時刻をタイムゾーンなしの値にする
+func toOfficialEventWallClock(t time.Time) time.Time {
+	t = t.In(officialEventLocation)
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// official_eventsから読み込んだタイムゾーンなしの日時を日本時間として扱う
+func fromOfficialEventWallClock(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), officialEventLocation)
+}
+
+func normalizeOfficialEvent(officialEvent *oem.OfficialEvent) *oem.OfficialEvent {
+	officialEvent.Date = fromOfficialEventWallClock(officialEvent.Date)
+	officialEvent.StartedAt = fromOfficialEventWallClock(officialEvent.StartedAt)
+	officialEvent.EndedAt = fromOfficialEventWallClock(officialEvent.EndedAt)
+
+	return officialEvent
+}
+
+func normalizeOfficialEvents(officialEvents []*oem.OfficialEvent) []*oem.OfficialEvent {
+	for _, officialEvent := range officialEvents {
+		normalizeOfficialEvent(officialEvent)
+	}
+
+	return officialEvents
+}
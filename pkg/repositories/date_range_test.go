@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfficialEventWallClock(t *testing.T) {
+	for scenario, tc := range map[string]struct {
+		t        time.Time
+		expected time.Time
+	}{
+		"UTC":   {time.Date(2024, 1, 13, 15, 0, 0, 0, time.UTC), time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)},
+		"JST":   {time.Date(2024, 1, 14, 0, 0, 0, 0, officialEventLocation), time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)},
+		"UTC-8": {time.Date(2024, 1, 13, 7, 0, 0, 0, time.FixedZone("UTC-8", -8*60*60)), time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)},
+	} {
+		tc := tc
+		t.Run(scenario, func(t *testing.T) {
+			wallClock := toOfficialEventWallClock(tc.t)
+			require.Equal(t, tc.expected, wallClock)
+
+			// 読み込んだ値は元の時刻と同じ瞬間を指す
+			require.True(t, tc.t.Equal(fromOfficialEventWallClock(wallClock)))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
 	"gorm.io/gorm"
@@ -13,9 +14,20 @@ type DeckRepositoryInterface interface {
 		id string,
 	) (*daos.Deck, error)
 
+	FindByIds(
+		ctx context.Context,
+		ids []string,
+	) ([]*daos.Deck, error)
+
+	Count(
+		ctx context.Context,
+	) (int64, error)
+
 	FindByUID(
 		ctx context.Context,
 		uid string,
+		startDate time.Time,
+		endDate time.Time,
 		limit int,
 		offset int,
 	) ([]*daos.Deck, error)
@@ -25,6 +37,22 @@ type DeckRepositoryInterface interface {
 		uid string,
 	) ([]*daos.Deck, error)
 
+	// 作成日時で絞り込んだDeckを全件返す
+	FindAllByUIDAndDateRange(
+		ctx context.Context,
+		uid string,
+		startDate time.Time,
+		endDate time.Time,
+	) ([]*daos.Deck, error)
+
+	// idの昇順にafterIdより後のDeckをlimit件返す
+	FindPageByUID(
+		ctx context.Context,
+		uid string,
+		afterId string,
+		limit int,
+	) ([]*daos.Deck, error)
+
 	Save(
 		ctx context.Context,
 		dao *daos.Deck,
@@ -35,6 +63,11 @@ type DeckRepositoryInterface interface {
 		id string,
 		uid string,
 	) error
+
+	DeleteAllByUID(
+		ctx context.Context,
+		uid string,
+	) (int64, error)
 }
 
 type DeckRepository struct {
@@ -53,22 +86,40 @@ func (r *DeckRepository) FindById(
 ) (*daos.Deck, error) {
 	dao := &daos.Deck{}
 
-	if tx := r.db.Where(&daos.Deck{ID: id}).First(dao); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Deck{ID: id}).First(dao); tx.Error != nil {
 		return nil, tx.Error
 	}
 
 	return dao, nil
 }
 
+func (r *DeckRepository) FindByIds(
+	ctx context.Context,
+	ids []string,
+) ([]*daos.Deck, error) {
+	var decks []*daos.Deck
+	if len(ids) == 0 {
+		return decks, nil
+	}
+
+	if tx := conn(ctx, r.db).Where("id IN ?", ids).Find(&decks); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return decks, nil
+}
+
 func (r *DeckRepository) FindByUID(
 	ctx context.Context,
 	uid string,
+	startDate time.Time,
+	endDate time.Time,
 	limit int,
 	offset int,
 ) ([]*daos.Deck, error) {
 	var decks []*daos.Deck
 
-	if tx := r.db.Where(&daos.Deck{UserId: uid}).Limit(limit).Offset(offset).Find(&decks); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Deck{UserId: uid}).Scopes(dateRange("created_at", startDate, endDate)).Limit(limit).Offset(offset).Find(&decks); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -81,18 +132,60 @@ func (r *DeckRepository) FindAllByUID(
 ) ([]*daos.Deck, error) {
 	var decks []*daos.Deck
 
-	if tx := r.db.Where(&daos.Deck{UserId: uid}).Find(&decks); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Deck{UserId: uid}).Find(&decks); tx.Error != nil {
 		return nil, tx.Error
 	}
 
 	return decks, nil
 }
 
+func (r *DeckRepository) FindAllByUIDAndDateRange(
+	ctx context.Context,
+	uid string,
+	startDate time.Time,
+	endDate time.Time,
+) ([]*daos.Deck, error) {
+	var decks []*daos.Deck
+
+	if tx := conn(ctx, r.db).Where(&daos.Deck{UserId: uid}).Scopes(dateRange("created_at", startDate, endDate)).Find(&decks); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return decks, nil
+}
+
+func (r *DeckRepository) FindPageByUID(
+	ctx context.Context,
+	uid string,
+	afterId string,
+	limit int,
+) ([]*daos.Deck, error) {
+	var decks []*daos.Deck
+
+	if tx := conn(ctx, r.db).Where(&daos.Deck{UserId: uid}).Scopes(afterIdPage(afterId, limit)).Find(&decks); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return decks, nil
+}
+
+func (r *DeckRepository) Count(
+	ctx context.Context,
+) (int64, error) {
+	var count int64
+
+	if tx := conn(ctx, r.db).Model(&daos.Deck{}).Count(&count); tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return count, nil
+}
+
 func (r *DeckRepository) Save(
 	ctx context.Context,
 	dao *daos.Deck,
 ) error {
-	if tx := r.db.Save(dao); tx.Error != nil {
+	if tx := conn(ctx, r.db).Save(dao); tx.Error != nil {
 		return tx.Error
 	}
 
@@ -104,9 +197,22 @@ func (r *DeckRepository) Delete(
 	id string,
 	uid string,
 ) error {
-	if tx := r.db.Where(&daos.Deck{ID: id, UserId: uid}).Delete(&daos.Deck{}); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Deck{ID: id, UserId: uid}).Delete(&daos.Deck{}); tx.Error != nil {
 		return tx.Error
 	}
 
 	return nil
 }
+
+// 論理削除ではなく物理削除する
+func (r *DeckRepository) DeleteAllByUID(
+	ctx context.Context,
+	uid string,
+) (int64, error) {
+	tx := conn(ctx, r.db).Unscoped().Where(&daos.Deck{UserId: uid}).Delete(&daos.Deck{})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return tx.RowsAffected, nil
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm"
+)
+
+type ErasureReceiptRepositoryInterface interface {
+	FindById(
+		ctx context.Context,
+		id string,
+	) (*daos.ErasureReceipt, error)
+
+	Create(
+		ctx context.Context,
+		dao *daos.ErasureReceipt,
+	) error
+}
+
+type ErasureReceiptRepository struct {
+	db *gorm.DB
+}
+
+func NewErasureReceiptRepository(
+	db *gorm.DB,
+) ErasureReceiptRepositoryInterface {
+	return &ErasureReceiptRepository{db}
+}
+
+func (r *ErasureReceiptRepository) FindById(
+	ctx context.Context,
+	id string,
+) (*daos.ErasureReceipt, error) {
+	dao := &daos.ErasureReceipt{}
+	if tx := conn(ctx, r.db).Where(&daos.ErasureReceipt{ID: id}).First(dao); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return dao, nil
+}
+
+func (r *ErasureReceiptRepository) Create(
+	ctx context.Context,
+	dao *daos.ErasureReceipt,
+) error {
+	if tx := conn(ctx, r.db).Create(dao); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
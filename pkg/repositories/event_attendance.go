@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm"
+)
+
+type EventAttendanceRepositoryInterface interface {
+	FindById(
+		ctx context.Context,
+		uid string,
+		officialEventId uint,
+	) (*daos.EventAttendance, error)
+
+	// statusが空の場合は全ての状態を返す
+	// 大会の開催日の昇順に返す
+	FindByUID(
+		ctx context.Context,
+		uid string,
+		status string,
+		startDate time.Time,
+		endDate time.Time,
+	) ([]*daos.EventAttendance, error)
+
+	// 開催日が日本時間でdateの日以前でRecordを作成していない、statusesのいずれかの状態のものを返す
+	FindDue(
+		ctx context.Context,
+		statuses []string,
+		date time.Time,
+		limit int,
+	) ([]*daos.EventAttendance, error)
+
+	Save(
+		ctx context.Context,
+		dao *daos.EventAttendance,
+	) error
+
+	// Recordを作成していない場合のみrecordIdを設定し、設定したかどうかを返す
+	SetRecordId(
+		ctx context.Context,
+		uid string,
+		officialEventId uint,
+		recordId string,
+	) (bool, error)
+
+	Delete(
+		ctx context.Context,
+		uid string,
+		officialEventId uint,
+	) error
+
+	DeleteAllByUID(
+		ctx context.Context,
+		uid string,
+	) (int64, error)
+}
+
+type EventAttendanceRepository struct {
+	db *gorm.DB
+}
+
+func NewEventAttendanceRepository(
+	db *gorm.DB,
+) EventAttendanceRepositoryInterface {
+	return &EventAttendanceRepository{db}
+}
+
+// 未作成の場合はUserId・OfficialEventIdのみを埋めたdaoを返す
+func (r *EventAttendanceRepository) FindById(
+	ctx context.Context,
+	uid string,
+	officialEventId uint,
+) (*daos.EventAttendance, error) {
+	dao := &daos.EventAttendance{}
+	if tx := conn(ctx, r.db).Where(&daos.EventAttendance{UserId: uid, OfficialEventId: officialEventId}).FirstOrInit(dao); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return dao, nil
+}
+
+func (r *EventAttendanceRepository) FindByUID(
+	ctx context.Context,
+	uid string,
+	status string,
+	startDate time.Time,
+	endDate time.Time,
+) ([]*daos.EventAttendance, error) {
+	var attendances []*daos.EventAttendance
+
+	tx := conn(ctx, r.db).
+		Joins("JOIN official_events ON official_events.id = event_attendances.official_event_id").
+		Where(&daos.EventAttendance{UserId: uid, Status: status})
+
+	if !startDate.IsZero() {
+		tx = tx.Where("official_events.date >= ?", toOfficialEventWallClock(startDate))
+	}
+
+	if !endDate.IsZero() {
+		tx = tx.Where("official_events.date < ?", toOfficialEventWallClock(endDate))
+	}
+
+	if tx := tx.Order("official_events.date ASC, event_attendances.official_event_id ASC").Find(&attendances); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return attendances, nil
+}
+
+func (r *EventAttendanceRepository) FindDue(
+	ctx context.Context,
+	statuses []string,
+	date time.Time,
+	limit int,
+) ([]*daos.EventAttendance, error) {
+	var attendances []*daos.EventAttendance
+
+	date = toOfficialEventWallClock(date)
+	nextDay := time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, time.UTC)
+
+	tx := conn(ctx, r.db).
+		Joins("JOIN official_events ON official_events.id = event_attendances.official_event_id").
+		Where("event_attendances.status IN ? AND event_attendances.record_id = ''", statuses).
+		Where("official_events.date < ?", nextDay).
+		Order("official_events.date ASC").
+		Limit(limit).
+		Find(&attendances)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return attendances, nil
+}
+
+func (r *EventAttendanceRepository) Save(
+	ctx context.Context,
+	dao *daos.EventAttendance,
+) error {
+	if tx := conn(ctx, r.db).Save(dao); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (r *EventAttendanceRepository) SetRecordId(
+	ctx context.Context,
+	uid string,
+	officialEventId uint,
+	recordId string,
+) (bool, error) {
+	tx := conn(ctx, r.db).
+		Model(&daos.EventAttendance{}).
+		Where("user_id = ? AND official_event_id = ? AND record_id = ''", uid, officialEventId).
+		Update("record_id", recordId)
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+
+	return tx.RowsAffected > 0, nil
+}
+
+func (r *EventAttendanceRepository) Delete(
+	ctx context.Context,
+	uid string,
+	officialEventId uint,
+) error {
+	if tx := conn(ctx, r.db).Where(&daos.EventAttendance{UserId: uid, OfficialEventId: officialEventId}).Delete(&daos.EventAttendance{}); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (r *EventAttendanceRepository) DeleteAllByUID(
+	ctx context.Context,
+	uid string,
+) (int64, error) {
+	tx := conn(ctx, r.db).Where(&daos.EventAttendance{UserId: uid}).Delete(&daos.EventAttendance{})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return tx.RowsAffected, nil
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm"
+)
+
+type FollowRepositoryInterface interface {
+	Exists(
+		ctx context.Context,
+		uid string,
+		followeeId string,
+	) (bool, error)
+
+	// uidのユーザがフォローしているユーザをフォローした日時の昇順に返す
+	FindByUID(
+		ctx context.Context,
+		uid string,
+	) ([]*daos.Follow, error)
+
+	Save(
+		ctx context.Context,
+		dao *daos.Follow,
+	) error
+
+	Delete(
+		ctx context.Context,
+		uid string,
+		followeeId string,
+	) error
+
+	DeleteAllByUID(
+		ctx context.Context,
+		uid string,
+	) (int64, error)
+}
+
+type FollowRepository struct {
+	db *gorm.DB
+}
+
+func NewFollowRepository(
+	db *gorm.DB,
+) FollowRepositoryInterface {
+	return &FollowRepository{db}
+}
+
+func (r *FollowRepository) Exists(
+	ctx context.Context,
+	uid string,
+	followeeId string,
+) (bool, error) {
+	var count int64
+
+	if tx := conn(ctx, r.db).Model(&daos.Follow{}).Where(&daos.Follow{UserId: uid, FolloweeId: followeeId}).Count(&count); tx.Error != nil {
+		return false, tx.Error
+	}
+
+	return count > 0, nil
+}
+
+func (r *FollowRepository) FindByUID(
+	ctx context.Context,
+	uid string,
+) ([]*daos.Follow, error) {
+	var follows []*daos.Follow
+
+	if tx := conn(ctx, r.db).Where(&daos.Follow{UserId: uid}).Order("created_at ASC").Find(&follows); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return follows, nil
+}
+
+func (r *FollowRepository) Save(
+	ctx context.Context,
+	dao *daos.Follow,
+) error {
+	if tx := conn(ctx, r.db).Save(dao); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (r *FollowRepository) Delete(
+	ctx context.Context,
+	uid string,
+	followeeId string,
+) error {
+	if tx := conn(ctx, r.db).Where(&daos.Follow{UserId: uid, FolloweeId: followeeId}).Delete(&daos.Follow{}); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+// フォローしている関係とフォローされている関係の両方を削除する
+func (r *FollowRepository) DeleteAllByUID(
+	ctx context.Context,
+	uid string,
+) (int64, error) {
+	tx := conn(ctx, r.db).Where("user_id = ? OR followee_id = ?", uid, uid).Delete(&daos.Follow{})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return tx.RowsAffected, nil
+}
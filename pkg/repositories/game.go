@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
 	"gorm.io/gorm"
@@ -13,16 +14,42 @@ type GameRepositoryInterface interface {
 		id string,
 	) (*daos.Game, error)
 
+	Count(
+		ctx context.Context,
+	) (int64, error)
+
 	FindAllByUID(
 		ctx context.Context,
 		uid string,
 	) ([]*daos.Game, error)
 
+	// 所属するRecordの公開範囲がvisibilitiesのいずれかのGameを作成日時で絞り込んで返す
+	FindByUIDAndVisibilities(
+		ctx context.Context,
+		uid string,
+		visibilities []string,
+		startDate time.Time,
+		endDate time.Time,
+	) ([]*daos.Game, error)
+
+	// idの昇順にafterIdより後のGameをlimit件返す
+	FindPageByUID(
+		ctx context.Context,
+		uid string,
+		afterId string,
+		limit int,
+	) ([]*daos.Game, error)
+
 	FindByRecordId(
 		ctx context.Context,
 		recordId string,
 	) ([]*daos.Game, error)
 
+	FindByRecordIds(
+		ctx context.Context,
+		recordIds []string,
+	) ([]*daos.Game, error)
+
 	Save(
 		ctx context.Context,
 		game *daos.Game,
@@ -33,6 +60,16 @@ type GameRepositoryInterface interface {
 		id string,
 		uid string,
 	) error
+
+	DeleteAllByUID(
+		ctx context.Context,
+		uid string,
+	) (int64, error)
+
+	ScrubOpponentsUserId(
+		ctx context.Context,
+		uid string,
+	) (int64, error)
 }
 
 type GameRepository struct {
@@ -51,7 +88,7 @@ func (r *GameRepository) FindById(
 ) (*daos.Game, error) {
 	game := &daos.Game{}
 
-	if tx := r.db.Where(&daos.Game{ID: id}).First(game); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Game{ID: id}).First(game); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -64,7 +101,44 @@ func (r *GameRepository) FindAllByUID(
 ) ([]*daos.Game, error) {
 	var games []*daos.Game
 
-	if tx := r.db.Where(&daos.Game{UserId: uid}).Find(&games); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Game{UserId: uid}).Find(&games); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return games, nil
+}
+
+func (r *GameRepository) FindByUIDAndVisibilities(
+	ctx context.Context,
+	uid string,
+	visibilities []string,
+	startDate time.Time,
+	endDate time.Time,
+) ([]*daos.Game, error) {
+	var games []*daos.Game
+	if len(visibilities) == 0 {
+		return games, nil
+	}
+
+	db := conn(ctx, r.db)
+	records := db.Model(&daos.Record{}).Select("id").Where(&daos.Record{UserId: uid}).Where("visibility IN ?", visibilities)
+
+	if tx := db.Where(&daos.Game{UserId: uid}).Where("record_id IN (?)", records).Scopes(dateRange("created_at", startDate, endDate)).Find(&games); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return games, nil
+}
+
+func (r *GameRepository) FindPageByUID(
+	ctx context.Context,
+	uid string,
+	afterId string,
+	limit int,
+) ([]*daos.Game, error) {
+	var games []*daos.Game
+
+	if tx := conn(ctx, r.db).Where(&daos.Game{UserId: uid}).Scopes(afterIdPage(afterId, limit)).Find(&games); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -78,18 +152,46 @@ func (r *GameRepository) FindByRecordId(
 	// 指定された
 	var games []*daos.Game
 
-	if tx := r.db.Where(&daos.Game{RecordId: recordId}).Find(&games); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Game{RecordId: recordId}).Find(&games); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return games, nil
+}
+
+func (r *GameRepository) FindByRecordIds(
+	ctx context.Context,
+	recordIds []string,
+) ([]*daos.Game, error) {
+	var games []*daos.Game
+	if len(recordIds) == 0 {
+		return games, nil
+	}
+
+	if tx := conn(ctx, r.db).Where("record_id IN ?", recordIds).Find(&games); tx.Error != nil {
 		return nil, tx.Error
 	}
 
 	return games, nil
 }
 
+func (r *GameRepository) Count(
+	ctx context.Context,
+) (int64, error) {
+	var count int64
+
+	if tx := conn(ctx, r.db).Model(&daos.Game{}).Count(&count); tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return count, nil
+}
+
 func (r *GameRepository) Save(
 	ctx context.Context,
 	game *daos.Game,
 ) error {
-	if tx := r.db.Save(game); tx.Error != nil {
+	if tx := conn(ctx, r.db).Save(game); tx.Error != nil {
 		return tx.Error
 	}
 
@@ -101,9 +203,35 @@ func (r *GameRepository) Delete(
 	id string,
 	uid string,
 ) error {
-	if tx := r.db.Where(&daos.Game{ID: id, UserId: uid}).Delete(&daos.Game{}); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Game{ID: id, UserId: uid}).Delete(&daos.Game{}); tx.Error != nil {
 		return tx.Error
 	}
 
 	return nil
 }
+
+// 論理削除ではなく物理削除する
+func (r *GameRepository) DeleteAllByUID(
+	ctx context.Context,
+	uid string,
+) (int64, error) {
+	tx := conn(ctx, r.db).Unscoped().Where(&daos.Game{UserId: uid}).Delete(&daos.Game{})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return tx.RowsAffected, nil
+}
+
+// 他のユーザのGameに記録されている対戦相手としてのUIDを消去する
+func (r *GameRepository) ScrubOpponentsUserId(
+	ctx context.Context,
+	uid string,
+) (int64, error) {
+	tx := conn(ctx, r.db).Unscoped().Model(&daos.Game{}).Where(&daos.Game{OpponentsUserId: uid}).Update("opponents_user_id", "")
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return tx.RowsAffected, nil
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGameRepository(t *testing.T) {
+	db, mock, err := setupMySQLMock()
+	require.NoError(t, err)
+
+	r := NewGameRepository(db)
+
+	for scenario, fn := range map[string]func(
+		t *testing.T, r GameRepositoryInterface, mock sqlmock.Sqlmock,
+	){
+		"DeleteAllByUID":           test_DeleteAllByUID,
+		"ScrubOpponentsUserId":     test_ScrubOpponentsUserId,
+		"FindByUIDAndVisibilities": test_GameFindByUIDAndVisibilities,
+	} {
+		fn := fn // ↑引数の順番通りに実行するための設定(https://github.com/golang/go/wiki/CommonMistakes)
+		t.Run(scenario, func(t *testing.T) {
+			fn(t, r, mock)
+		})
+	}
+}
+
+func test_DeleteAllByUID(t *testing.T, r GameRepositoryInterface, mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(
+		regexp.QuoteMeta("DELETE FROM `games` WHERE `games`.`user_id` = ?"),
+	).WithArgs("CeQ0Oa9g9uRThL11lj4l45VAg8p1").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	count, err := r.DeleteAllByUID(context.Background(), "CeQ0Oa9g9uRThL11lj4l45VAg8p1")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func test_ScrubOpponentsUserId(t *testing.T, r GameRepositoryInterface, mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(
+		regexp.QuoteMeta("UPDATE `games` SET `opponents_user_id`=?,`updated_at`=? WHERE `games`.`opponents_user_id` = ?"),
+	).WithArgs("", sqlmock.AnyArg(), "CeQ0Oa9g9uRThL11lj4l45VAg8p1").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	count, err := r.ScrubOpponentsUserId(context.Background(), "CeQ0Oa9g9uRThL11lj4l45VAg8p1")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func test_GameFindByUIDAndVisibilities(t *testing.T, r GameRepositoryInterface, mock sqlmock.Sqlmock) {
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(
+		regexp.QuoteMeta("SELECT * FROM `games` WHERE `games`.`user_id` = ? AND record_id IN (SELECT `id` FROM `records` WHERE `records`.`user_id` = ? AND visibility IN (?,?) AND `records`.`deleted_at` IS NULL) AND created_at >= ? AND created_at < ? AND `games`.`deleted_at` IS NULL"),
+	).WithArgs("CeQ0Oa9g9uRThL11lj4l45VAg8p1", "CeQ0Oa9g9uRThL11lj4l45VAg8p1", "public", "followers", startDate, endDate).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("01J0000000000000000000000G"))
+
+	games, err := r.FindByUIDAndVisibilities(context.Background(), "CeQ0Oa9g9uRThL11lj4l45VAg8p1", []string{"public", "followers"}, startDate, endDate)
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
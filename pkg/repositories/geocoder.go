@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 住所から座標を求める
+// 外部のサービスに依存しないように、既定では地名と代表点の対応表で求める
+type GeocoderInterface interface {
+	// 座標が求められない場合はokにfalseを返す
+	Geocode(
+		ctx context.Context,
+		address string,
+	) (location *GeocoderEntry, ok bool, err error)
+}
+
+// ジオコーディング用テーブルの1件分
+// [{"name": "東京都千代田区", "latitude": 35.694, "longitude": 139.7536}] の形式で記述する
+type GeocoderEntry struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+func LoadGeocoderEntries(path string) ([]*GeocoderEntry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*GeocoderEntry{}
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.Name == "" {
+			return nil, errors.New("name is required in geocoder table: " + path)
+		}
+
+		if entry.Latitude < -90 || entry.Latitude > 90 || entry.Longitude < -180 || entry.Longitude > 180 {
+			return nil, errors.New("invalid coordinates in geocoder table: " + entry.Name)
+		}
+	}
+
+	return entries, nil
+}
+
+var (
+	// 住所の先頭の郵便番号
+	postalCodePattern = regexp.MustCompile(`^〒?\s*[0-9０-９]{3}[-－ー]?[0-9０-９]{4}\s*`)
+)
+
+// 住所の前方に最も長く一致する地名の代表点を返す
+// 市区町村の代表点を登録すると、都道府県の代表点より優先して使う
+type LocalGeocoder struct {
+	entries []*GeocoderEntry
+}
+
+// 後に指定したテーブルの地名が同じ場合は上書きする
+func NewLocalGeocoder(tables ...[]*GeocoderEntry) GeocoderInterface {
+	entryMap := map[string]*GeocoderEntry{}
+	for _, table := range tables {
+		for _, entry := range table {
+			entryMap[normalizeAddress(entry.Name)] = entry
+		}
+	}
+
+	entries := []*GeocoderEntry{}
+	for _, entry := range entryMap {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return len(normalizeAddress(entries[i].Name)) > len(normalizeAddress(entries[j].Name))
+	})
+
+	return &LocalGeocoder{entries}
+}
+
+func normalizeAddress(address string) string {
+	address = postalCodePattern.ReplaceAllString(strings.TrimSpace(address), "")
+
+	return strings.Join(strings.Fields(address), "")
+}
+
+func (g *LocalGeocoder) Geocode(
+	ctx context.Context,
+	address string,
+) (*GeocoderEntry, bool, error) {
+	address = normalizeAddress(address)
+	if address == "" {
+		return nil, false, nil
+	}
+
+	for _, entry := range g.entries {
+		if strings.HasPrefix(address, normalizeAddress(entry.Name)) {
+			return entry, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// 都道府県庁所在地の座標
+var PrefectureGeocoderEntries = []*GeocoderEntry{
+	{"北海道", 43.0642, 141.3469},
+	{"青森県", 40.8244, 140.7400},
+	{"岩手県", 39.7036, 141.1527},
+	{"宮城県", 38.2688, 140.8721},
+	{"秋田県", 39.7186, 140.1024},
+	{"山形県", 38.2404, 140.3633},
+	{"福島県", 37.7500, 140.4678},
+	{"茨城県", 36.3418, 140.4468},
+	{"栃木県", 36.5657, 139.8836},
+	{"群馬県", 36.3911, 139.0608},
+	{"埼玉県", 35.8569, 139.6489},
+	{"千葉県", 35.6047, 140.1233},
+	{"東京都", 35.6895, 139.6917},
+	{"神奈川県", 35.4478, 139.6425},
+	{"新潟県", 37.9026, 139.0236},
+	{"富山県", 36.6953, 137.2113},
+	{"石川県", 36.5947, 136.6256},
+	{"福井県", 36.0652, 136.2216},
+	{"山梨県", 35.6642, 138.5684},
+	{"長野県", 36.6513, 138.1810},
+	{"岐阜県", 35.3912, 136.7223},
+	{"静岡県", 34.9769, 138.3831},
+	{"愛知県", 35.1802, 136.9066},
+	{"三重県", 34.7303, 136.5086},
+	{"滋賀県", 35.0045, 135.8686},
+	{"京都府", 35.0214, 135.7556},
+	{"大阪府", 34.6863, 135.5200},
+	{"兵庫県", 34.6913, 135.1830},
+	{"奈良県", 34.6851, 135.8329},
+	{"和歌山県", 34.2260, 135.1675},
+	{"鳥取県", 35.5036, 134.2383},
+	{"島根県", 35.4723, 133.0505},
+	{"岡山県", 34.6618, 133.9344},
+	{"広島県", 34.3966, 132.4596},
+	{"山口県", 34.1859, 131.4714},
+	{"徳島県", 34.0657, 134.5593},
+	{"香川県", 34.3401, 134.0434},
+	{"愛媛県", 33.8417, 132.7657},
+	{"高知県", 33.5597, 133.5311},
+	{"福岡県", 33.6064, 130.4183},
+	{"佐賀県", 33.2494, 130.2988},
+	{"長崎県", 32.7448, 129.8737},
+	{"熊本県", 32.7898, 130.7417},
+	{"大分県", 33.2382, 131.6126},
+	{"宮崎県", 31.9111, 131.4239},
+	{"鹿児島県", 31.5602, 130.5581},
+	{"沖縄県", 26.2124, 127.6809},
+}
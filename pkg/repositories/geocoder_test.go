@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalGeocoder(t *testing.T) {
+	geocoder := NewLocalGeocoder(PrefectureGeocoderEntries, []*GeocoderEntry{
+		{"東京都千代田区", 35.6940, 139.7536},
+	})
+
+	for scenario, tc := range map[string]struct {
+		address  string
+		expected string
+	}{
+		"Municipality": {"東京都千代田区丸の内1-1", "東京都千代田区"},
+		"Prefecture":   {"東京都新宿区西新宿2-8-1", "東京都"},
+		"PostalCode":   {"〒100-0005 東京都 千代田区丸の内1-1", "東京都千代田区"},
+		"Unknown":      {"千代田区丸の内1-1", ""},
+		"Empty":        {"", ""},
+	} {
+		tc := tc
+		t.Run(scenario, func(t *testing.T) {
+			entry, ok, err := geocoder.Geocode(context.Background(), tc.address)
+			require.NoError(t, err)
+
+			if tc.expected == "" {
+				require.False(t, ok)
+				return
+			}
+
+			require.True(t, ok)
+			require.Equal(t, tc.expected, entry.Name)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type HealthRepositoryInterface interface {
+	Ping(
+		ctx context.Context,
+	) error
+}
+
+type HealthRepository struct {
+	db *gorm.DB
+}
+
+func NewHealthRepository(
+	db *gorm.DB,
+) HealthRepositoryInterface {
+	return &HealthRepository{db}
+}
+
+func (r *HealthRepository) Ping(
+	ctx context.Context,
+) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm"
+)
+
+// JSONフィクスチャの1件分
+// [{"uid": "...", "display_name": "...", "photo_url": "..."}] の形式で記述する
+type UserFixture struct {
+	UID         string `json:"uid"`
+	DisplayName string `json:"display_name"`
+	PhotoURL    string `json:"photo_url"`
+}
+
+func LoadUserFixtures(path string) ([]*UserFixture, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := []*UserFixture{}
+	if err := json.Unmarshal(b, &fixtures); err != nil {
+		return nil, err
+	}
+
+	for _, fixture := range fixtures {
+		if fixture.UID == "" {
+			return nil, errors.New("uid is required in user fixture: " + path)
+		}
+	}
+
+	return fixtures, nil
+}
+
+type fixtureUserProvider struct {
+	mu    sync.RWMutex
+	users map[string]*UserFixture
+}
+
+func (p *fixtureUserProvider) ping(
+	ctx context.Context,
+) error {
+	return nil
+}
+
+func (p *fixtureUserProvider) getUser(
+	ctx context.Context,
+	id string,
+) (*daos.User, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	fixture, ok := p.users[id]
+	if !ok {
+		return nil, errors.New("user not found: " + id)
+	}
+
+	return &daos.User{
+		UID:         fixture.UID,
+		DisplayName: fixture.DisplayName,
+		PhotoURL:    fixture.PhotoURL,
+	}, nil
+}
+
+// フィクスチャのファイルは変更せず、プロセスが終了するまで削除したユーザとして扱う
+func (p *fixtureUserProvider) deleteUser(
+	ctx context.Context,
+	id string,
+) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.users, id)
+
+	return nil
+}
+
+type LocalUserRepositoryInterface interface {
+	UserRepositoryInterface
+
+	// usersテーブルに取り込まれているかに関わらず、フィクスチャのユーザを返す
+	// フィクスチャに存在しない(削除された)場合はgorm.ErrRecordNotFoundを返す
+	FindFixtureById(
+		ctx context.Context,
+		id string,
+	) (*daos.User, error)
+}
+
+type LocalUserRepository struct {
+	*UserRepository
+	fixtures *fixtureUserProvider
+}
+
+// Firebase Authenticationの代わりにフィクスチャからユーザを取り込むUserRepository
+// Firebaseの認証情報が無い開発環境やテストで使う
+func NewLocalUserRepository(
+	fixtures []*UserFixture,
+	db *gorm.DB,
+) LocalUserRepositoryInterface {
+	users := map[string]*UserFixture{}
+	for _, fixture := range fixtures {
+		users[fixture.UID] = fixture
+	}
+
+	provider := &fixtureUserProvider{users: users}
+
+	return &LocalUserRepository{&UserRepository{provider, db, newTTLCache[daos.User](USER_CACHE_TTL)}, provider}
+}
+
+func (r *LocalUserRepository) FindFixtureById(
+	ctx context.Context,
+	id string,
+) (*daos.User, error) {
+	dao, err := r.fixtures.getUser(ctx, id)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return dao, nil
+}
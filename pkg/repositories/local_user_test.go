@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadUserFixtures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"uid": "uid1", "display_name": "User1"}, {"uid": "uid2"}]`), 0600))
+
+	fixtures, err := LoadUserFixtures(path)
+	require.NoError(t, err)
+	require.Len(t, fixtures, 2)
+
+	provider := &fixtureUserProvider{users: map[string]*UserFixture{}}
+	for _, fixture := range fixtures {
+		provider.users[fixture.UID] = fixture
+	}
+
+	user, err := provider.getUser(context.Background(), "uid1")
+	require.NoError(t, err)
+	require.Equal(t, "User1", user.DisplayName)
+
+	_, err = provider.getUser(context.Background(), "unknown")
+	require.Error(t, err)
+
+	// 削除したユーザは取り込めない
+	require.NoError(t, provider.deleteUser(context.Background(), "uid1"))
+	require.NoError(t, provider.deleteUser(context.Background(), "unknown"))
+	_, err = provider.getUser(context.Background(), "uid1")
+	require.Error(t, err)
+
+	// uidが無いフィクスチャはエラーになる
+	require.NoError(t, os.WriteFile(path, []byte(`[{"display_name": "User"}]`), 0600))
+	_, err = LoadUserFixtures(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm"
+)
+
+const (
+	MAINTENANCE_SETTING_ID = 1
+
+	// 全てのリクエストで参照するため短い間キャッシュする
+	// 他のインスタンスでの更新はTTLが経過するまで反映されない
+	MAINTENANCE_SETTING_CACHE_TTL = time.Duration(5) * time.Second
+)
+
+type MaintenanceSettingRepositoryInterface interface {
+	Find(
+		ctx context.Context,
+	) (*daos.MaintenanceSetting, error)
+
+	Save(
+		ctx context.Context,
+		dao *daos.MaintenanceSetting,
+	) error
+}
+
+type MaintenanceSettingRepository struct {
+	db    *gorm.DB
+	cache *ttlCache[daos.MaintenanceSetting]
+}
+
+func NewMaintenanceSettingRepository(
+	db *gorm.DB,
+) MaintenanceSettingRepositoryInterface {
+	return &MaintenanceSettingRepository{db, newTTLCache[daos.MaintenanceSetting](MAINTENANCE_SETTING_CACHE_TTL)}
+}
+
+// 設定が未作成の場合は初期値(読み取り専用モードではない)のdaoを返す
+func (r *MaintenanceSettingRepository) Find(
+	ctx context.Context,
+) (*daos.MaintenanceSetting, error) {
+	if setting, ok := r.cache.get(""); ok {
+		return &setting, nil
+	}
+
+	dao := &daos.MaintenanceSetting{}
+	if tx := conn(ctx, r.db).Where(&daos.MaintenanceSetting{Id: MAINTENANCE_SETTING_ID}).FirstOrInit(dao); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	r.cache.set("", *dao)
+
+	return dao, nil
+}
+
+func (r *MaintenanceSettingRepository) Save(
+	ctx context.Context,
+	dao *daos.MaintenanceSetting,
+) error {
+	dao.Id = MAINTENANCE_SETTING_ID
+
+	if tx := conn(ctx, r.db).Save(dao); tx.Error != nil {
+		return tx.Error
+	}
+
+	r.cache.delete("")
+
+	return nil
+}
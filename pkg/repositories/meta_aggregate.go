@@ -0,0 +1,298 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm"
+)
+
+const (
+	META_AGGREGATE_BATCH_SIZE = 500
+)
+
+// 集計の元になる対戦
+// デッキを登録していないRecordのDeckName、公式大会ではないRecordのRegulationIdはゼロ値になる
+type MetaGame struct {
+	// 集計する日時(大会の開催日、公式大会ではないRecordは対戦の作成日時)
+	PlayedAt          time.Time
+	VictoryFlg        bool
+	OpponentsDeckInfo string
+	DeckName          string
+	RegulationId      uint
+}
+
+type metaGameRow struct {
+	CreatedAt         time.Time
+	EventDate         *time.Time
+	VictoryFlg        bool
+	OpponentsDeckInfo string
+	DeckName          string
+	RegulationId      uint
+}
+
+func (row *metaGameRow) playedAt() time.Time {
+	if row.EventDate != nil && !row.EventDate.IsZero() {
+		return fromOfficialEventWallClock(*row.EventDate)
+	}
+
+	return row.CreatedAt
+}
+
+type MetaAggregateSum struct {
+	Side      string
+	Archetype string
+	Games     int64
+	Wins      int64
+}
+
+type MetaAggregateRepositoryInterface interface {
+	// 集計する日時がstartDate以上endDate未満の、公開されているRecordの対戦を返す
+	FindGames(
+		ctx context.Context,
+		startDate time.Time,
+		endDate time.Time,
+	) ([]*MetaGame, error)
+
+	// 最も古い対戦の集計する日時を返す(対戦が無い場合はゼロ値)
+	FindFirstPlayedAt(
+		ctx context.Context,
+	) (time.Time, error)
+
+	// recordIdsのRecordの対戦の集計する日時を公開範囲に関わらず返す
+	FindPlayedAtByRecordIds(
+		ctx context.Context,
+		recordIds []string,
+	) ([]time.Time, error)
+
+	// uidのユーザのRecordの対戦の集計する日時を公開範囲に関わらず返す
+	FindPlayedAtByUID(
+		ctx context.Context,
+		uid string,
+	) ([]time.Time, error)
+
+	// 作成・変更・削除された日時がsince以降の対戦の集計する日時を返す
+	FindPlayedAtUpdatedSince(
+		ctx context.Context,
+		since time.Time,
+	) ([]time.Time, error)
+
+	// BucketがstartDate以上endDate未満の集計をdaosで置き換える
+	Replace(
+		ctx context.Context,
+		startDate time.Time,
+		endDate time.Time,
+		daos []*daos.MetaAggregate,
+	) error
+
+	// Bucketがdate未満の集計を削除する
+	DeleteBefore(
+		ctx context.Context,
+		date time.Time,
+	) error
+
+	// BucketがstartDate以上endDate未満の集計をSide・Archetypeごとに合計する
+	// regulationIdが0の場合は全てのレギュレーションを合計する
+	Sum(
+		ctx context.Context,
+		regulationId uint,
+		startDate time.Time,
+		endDate time.Time,
+	) ([]*MetaAggregateSum, error)
+}
+
+type MetaAggregateRepository struct {
+	db *gorm.DB
+}
+
+func NewMetaAggregateRepository(
+	db *gorm.DB,
+) MetaAggregateRepositoryInterface {
+	return &MetaAggregateRepository{db}
+}
+
+// 削除されていないRecordの対戦に、Recordのデッキと大会を結合する
+func (r *MetaAggregateRepository) games(ctx context.Context) *gorm.DB {
+	return conn(ctx, r.db).
+		Model(&daos.Game{}).
+		Joins("JOIN records ON records.id = games.record_id AND records.deleted_at IS NULL").
+		Joins("LEFT JOIN decks ON decks.id = records.deck_id AND decks.deleted_at IS NULL").
+		Joins("LEFT JOIN official_events ON official_events.id = records.official_event_id")
+}
+
+// 大会の開催日はofficial_eventsの日時と同じくタイムゾーンなしの値で比較する
+func playedAtRange(startDate time.Time, endDate time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"(official_events.id IS NOT NULL AND official_events.date >= ? AND official_events.date < ?) OR "+
+				"(official_events.id IS NULL AND games.created_at >= ? AND games.created_at < ?)",
+			toOfficialEventWallClock(startDate), toOfficialEventWallClock(endDate), startDate.UTC(), endDate.UTC(),
+		)
+	}
+}
+
+func findPlayedAt(tx *gorm.DB) ([]time.Time, error) {
+	var rows []*metaGameRow
+
+	if tx := tx.Select("games.created_at, official_events.date AS event_date").Scan(&rows); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	playedAts := []time.Time{}
+	for _, row := range rows {
+		playedAts = append(playedAts, row.playedAt())
+	}
+
+	return playedAts, nil
+}
+
+func (r *MetaAggregateRepository) FindGames(
+	ctx context.Context,
+	startDate time.Time,
+	endDate time.Time,
+) ([]*MetaGame, error) {
+	var rows []*metaGameRow
+
+	tx := r.games(ctx).
+		Select(
+			"games.created_at, official_events.date AS event_date, games.victory_flg, games.opponents_deck_info, "+
+				"COALESCE(decks.name, '') AS deck_name, COALESCE(official_events.regulation_id, 0) AS regulation_id",
+		).
+		Where("records.visibility = ?", "public").
+		Scopes(playedAtRange(startDate, endDate)).
+		Scan(&rows)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	games := []*MetaGame{}
+	for _, row := range rows {
+		games = append(games, &MetaGame{
+			PlayedAt:          row.playedAt(),
+			VictoryFlg:        row.VictoryFlg,
+			OpponentsDeckInfo: row.OpponentsDeckInfo,
+			DeckName:          row.DeckName,
+			RegulationId:      row.RegulationId,
+		})
+	}
+
+	return games, nil
+}
+
+func (r *MetaAggregateRepository) FindFirstPlayedAt(
+	ctx context.Context,
+) (time.Time, error) {
+	first := time.Time{}
+
+	// 公式大会のRecordは開催日、公式大会ではないRecordは作成日時の最も古い対戦を比べる
+	for _, tx := range []*gorm.DB{
+		r.games(ctx).Where("official_events.id IS NOT NULL").Order("official_events.date ASC").Limit(1),
+		r.games(ctx).Where("official_events.id IS NULL").Order("games.created_at ASC").Limit(1),
+	} {
+		playedAts, err := findPlayedAt(tx)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		for _, playedAt := range playedAts {
+			if first.IsZero() || playedAt.Before(first) {
+				first = playedAt
+			}
+		}
+	}
+
+	return first, nil
+}
+
+func (r *MetaAggregateRepository) FindPlayedAtByRecordIds(
+	ctx context.Context,
+	recordIds []string,
+) ([]time.Time, error) {
+	if len(recordIds) == 0 {
+		return []time.Time{}, nil
+	}
+
+	return findPlayedAt(r.games(ctx).Where("records.id IN ?", recordIds))
+}
+
+func (r *MetaAggregateRepository) FindPlayedAtByUID(
+	ctx context.Context,
+	uid string,
+) ([]time.Time, error) {
+	return findPlayedAt(r.games(ctx).Where("records.user_id = ?", uid))
+}
+
+// 削除された対戦も含めるため、論理削除された行も参照する
+func (r *MetaAggregateRepository) FindPlayedAtUpdatedSince(
+	ctx context.Context,
+	since time.Time,
+) ([]time.Time, error) {
+	tx := conn(ctx, r.db).
+		Unscoped().
+		Model(&daos.Game{}).
+		Joins("JOIN records ON records.id = games.record_id").
+		Joins("LEFT JOIN official_events ON official_events.id = records.official_event_id").
+		Where("games.updated_at >= ? OR games.deleted_at >= ?", since.UTC(), since.UTC())
+
+	return findPlayedAt(tx)
+}
+
+func (r *MetaAggregateRepository) Replace(
+	ctx context.Context,
+	startDate time.Time,
+	endDate time.Time,
+	aggregates []*daos.MetaAggregate,
+) error {
+	tx := conn(ctx, r.db).
+		Where("bucket >= ? AND bucket < ?", startDate.UTC(), endDate.UTC()).
+		Delete(&daos.MetaAggregate{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if len(aggregates) == 0 {
+		return nil
+	}
+
+	if tx := conn(ctx, r.db).CreateInBatches(aggregates, META_AGGREGATE_BATCH_SIZE); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (r *MetaAggregateRepository) DeleteBefore(
+	ctx context.Context,
+	date time.Time,
+) error {
+	if tx := conn(ctx, r.db).Where("bucket < ?", date.UTC()).Delete(&daos.MetaAggregate{}); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (r *MetaAggregateRepository) Sum(
+	ctx context.Context,
+	regulationId uint,
+	startDate time.Time,
+	endDate time.Time,
+) ([]*MetaAggregateSum, error) {
+	var sums []*MetaAggregateSum
+
+	tx := conn(ctx, r.db).
+		Model(&daos.MetaAggregate{}).
+		Select("side, archetype, SUM(games) AS games, SUM(wins) AS wins").
+		Where("bucket >= ? AND bucket < ?", startDate.UTC(), endDate.UTC())
+
+	if regulationId != 0 {
+		tx = tx.Where(&daos.MetaAggregate{RegulationId: regulationId})
+	}
+
+	if tx := tx.Group("side, archetype").Scan(&sums); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return sums, nil
+}
@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -26,6 +28,72 @@ type OfficialEventRepositoryInterface interface {
 		startDate time.Time,
 		endDate time.Time,
 	) ([]*oem.OfficialEvent, error)
+
+	FindByIds(
+		ctx context.Context,
+		ids []uint,
+	) ([]*oem.OfficialEvent, error)
+
+	FindByTitleAndDate(
+		ctx context.Context,
+		title string,
+		startDate time.Time,
+		endDate time.Time,
+	) ([]*oem.OfficialEvent, error)
+
+	Search(
+		ctx context.Context,
+		condition *OfficialEventCondition,
+		limit int,
+		offset int,
+	) ([]*oem.OfficialEvent, error)
+}
+
+const (
+	OFFICIAL_EVENT_SORT_DATE      = "date"
+	OFFICIAL_EVENT_SORT_DATE_DESC = "-date"
+	OFFICIAL_EVENT_SORT_ID        = "id"
+	OFFICIAL_EVENT_SORT_ID_DESC   = "-id"
+
+	// 検索キーワード中の%と_をそのまま検索するためのエスケープ文字
+	LIKE_ESCAPE_CHARACTER = "!"
+)
+
+var (
+	officialEventOrders = map[string]string{
+		OFFICIAL_EVENT_SORT_DATE:      "date ASC, id ASC",
+		OFFICIAL_EVENT_SORT_DATE_DESC: "date DESC, id DESC",
+		OFFICIAL_EVENT_SORT_ID:        "id ASC",
+		OFFICIAL_EVENT_SORT_ID_DESC:   "id DESC",
+	}
+
+	likeEscaper = strings.NewReplacer(
+		LIKE_ESCAPE_CHARACTER, LIKE_ESCAPE_CHARACTER+LIKE_ESCAPE_CHARACTER,
+		"%", LIKE_ESCAPE_CHARACTER+"%",
+		"_", LIKE_ESCAPE_CHARACTER+"_",
+	)
+)
+
+func IsValidOfficialEventSort(sort string) bool {
+	_, ok := officialEventOrders[sort]
+	return ok
+}
+
+// 大会の検索条件
+// ゼロ値の項目では絞り込まない
+type OfficialEventCondition struct {
+	// 空白で区切った全ての語を大会名または会場名に含む
+	Keywords []string
+	// 住所の前方一致で絞り込む
+	Prefecture   string
+	TypeId       uint
+	LeagueId     uint
+	RegulationId uint
+	ShopId       uint
+	StartDate    time.Time
+	EndDate      time.Time
+	// 空の場合は開催日の昇順
+	Sort string
 }
 
 type OfficialEventRepository struct {
@@ -45,11 +113,11 @@ func (r *OfficialEventRepository) Find(
 ) ([]*oem.OfficialEvent, error) {
 	var officialEvents []*oem.OfficialEvent
 
-	if tx := r.db.Limit(limit).Offset(offset).Find(&officialEvents); tx.Error != nil {
+	if tx := conn(ctx, r.db).Limit(limit).Offset(offset).Find(&officialEvents); tx.Error != nil {
 		return nil, tx.Error
 	}
 
-	return officialEvents, nil
+	return normalizeOfficialEvents(officialEvents), nil
 }
 
 func (r *OfficialEventRepository) FindById(
@@ -58,11 +126,11 @@ func (r *OfficialEventRepository) FindById(
 ) (*oem.OfficialEvent, error) {
 	var officialEvent oem.OfficialEvent
 
-	if tx := r.db.Where(&oem.OfficialEvent{Id: id}).First(&officialEvent); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&oem.OfficialEvent{Id: id}).First(&officialEvent); tx.Error != nil {
 		return nil, tx.Error
 	}
 
-	return &officialEvent, nil
+	return normalizeOfficialEvent(&officialEvent), nil
 }
 
 func (r *OfficialEventRepository) FindByDate(
@@ -72,9 +140,91 @@ func (r *OfficialEventRepository) FindByDate(
 ) ([]*oem.OfficialEvent, error) {
 	var officialEvents []*oem.OfficialEvent
 
-	if tx := r.db.Where("date BETWEEN ? AND ?", startDate, endDate).Find(&officialEvents); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where("date >= ? AND date < ?", toOfficialEventWallClock(startDate), toOfficialEventWallClock(endDate)).Find(&officialEvents); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return normalizeOfficialEvents(officialEvents), nil
+}
+
+func (r *OfficialEventRepository) FindByIds(
+	ctx context.Context,
+	ids []uint,
+) ([]*oem.OfficialEvent, error) {
+	var officialEvents []*oem.OfficialEvent
+	if len(ids) == 0 {
+		return officialEvents, nil
+	}
+
+	if tx := conn(ctx, r.db).Where("id IN ?", ids).Find(&officialEvents); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return normalizeOfficialEvents(officialEvents), nil
+}
+
+func (r *OfficialEventRepository) FindByTitleAndDate(
+	ctx context.Context,
+	title string,
+	startDate time.Time,
+	endDate time.Time,
+) ([]*oem.OfficialEvent, error) {
+	var officialEvents []*oem.OfficialEvent
+	if tx := conn(ctx, r.db).Where("title = ? AND date >= ? AND date < ?", title, toOfficialEventWallClock(startDate), toOfficialEventWallClock(endDate)).Find(&officialEvents); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return normalizeOfficialEvents(officialEvents), nil
+}
+
+func (r *OfficialEventRepository) Search(
+	ctx context.Context,
+	condition *OfficialEventCondition,
+	limit int,
+	offset int,
+) ([]*oem.OfficialEvent, error) {
+	sort := condition.Sort
+	if sort == "" {
+		sort = OFFICIAL_EVENT_SORT_DATE
+	}
+
+	order, ok := officialEventOrders[sort]
+	if !ok {
+		return nil, fmt.Errorf("invalid sort: %s", sort)
+	}
+
+	tx := conn(ctx, r.db).Where(&oem.OfficialEvent{
+		TypeId:       condition.TypeId,
+		LeagueId:     condition.LeagueId,
+		RegulationId: condition.RegulationId,
+		ShopId:       condition.ShopId,
+	})
+
+	for _, keyword := range condition.Keywords {
+		pattern := "%" + likeEscaper.Replace(keyword) + "%"
+		tx = tx.Where(
+			"(title LIKE ? ESCAPE '"+LIKE_ESCAPE_CHARACTER+"' OR venue LIKE ? ESCAPE '"+LIKE_ESCAPE_CHARACTER+"')",
+			pattern,
+			pattern,
+		)
+	}
+
+	if condition.Prefecture != "" {
+		tx = tx.Where("address LIKE ? ESCAPE '"+LIKE_ESCAPE_CHARACTER+"'", likeEscaper.Replace(condition.Prefecture)+"%")
+	}
+
+	if !condition.StartDate.IsZero() {
+		tx = tx.Where("date >= ?", toOfficialEventWallClock(condition.StartDate))
+	}
+
+	if !condition.EndDate.IsZero() {
+		tx = tx.Where("date < ?", toOfficialEventWallClock(condition.EndDate))
+	}
+
+	var officialEvents []*oem.OfficialEvent
+	if tx := tx.Order(order).Limit(limit).Offset(offset).Find(&officialEvents); tx.Error != nil {
 		return nil, tx.Error
 	}
 
-	return officialEvents, nil
+	return normalizeOfficialEvents(officialEvents), nil
 }
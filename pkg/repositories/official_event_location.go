@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm"
+)
+
+type OfficialEventLocationRepositoryInterface interface {
+	// 座標を求めていない大会をidの昇順に返す
+	FindUnlocated(
+		ctx context.Context,
+		afterId uint,
+		limit int,
+	) ([]*oem.OfficialEvent, error)
+
+	// 緯度・経度の範囲内にある、開催日がstartDate以上endDate未満の大会の座標を返す
+	FindByBoundingBox(
+		ctx context.Context,
+		minLatitude float64,
+		maxLatitude float64,
+		minLongitude float64,
+		maxLongitude float64,
+		startDate time.Time,
+		endDate time.Time,
+	) ([]*daos.OfficialEventLocation, error)
+
+	Save(
+		ctx context.Context,
+		dao *daos.OfficialEventLocation,
+	) error
+}
+
+type OfficialEventLocationRepository struct {
+	db *gorm.DB
+}
+
+func NewOfficialEventLocationRepository(
+	db *gorm.DB,
+) OfficialEventLocationRepositoryInterface {
+	return &OfficialEventLocationRepository{db}
+}
+
+func (r *OfficialEventLocationRepository) FindUnlocated(
+	ctx context.Context,
+	afterId uint,
+	limit int,
+) ([]*oem.OfficialEvent, error) {
+	var officialEvents []*oem.OfficialEvent
+
+	tx := conn(ctx, r.db).
+		Model(&oem.OfficialEvent{}).
+		Joins("LEFT JOIN official_event_locations ON official_event_locations.official_event_id = official_events.id").
+		Where("official_event_locations.official_event_id IS NULL AND official_events.id > ?", afterId).
+		Order("official_events.id ASC").
+		Limit(limit).
+		Find(&officialEvents)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return normalizeOfficialEvents(officialEvents), nil
+}
+
+func (r *OfficialEventLocationRepository) FindByBoundingBox(
+	ctx context.Context,
+	minLatitude float64,
+	maxLatitude float64,
+	minLongitude float64,
+	maxLongitude float64,
+	startDate time.Time,
+	endDate time.Time,
+) ([]*daos.OfficialEventLocation, error) {
+	var locations []*daos.OfficialEventLocation
+
+	tx := conn(ctx, r.db).
+		Joins("JOIN official_events ON official_events.id = official_event_locations.official_event_id").
+		Where("official_event_locations.latitude BETWEEN ? AND ?", minLatitude, maxLatitude).
+		Where("official_event_locations.longitude BETWEEN ? AND ?", minLongitude, maxLongitude)
+
+	if !startDate.IsZero() {
+		tx = tx.Where("official_events.date >= ?", toOfficialEventWallClock(startDate))
+	}
+
+	if !endDate.IsZero() {
+		tx = tx.Where("official_events.date < ?", toOfficialEventWallClock(endDate))
+	}
+
+	if tx := tx.Find(&locations); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return locations, nil
+}
+
+func (r *OfficialEventLocationRepository) Save(
+	ctx context.Context,
+	dao *daos.OfficialEventLocation,
+) error {
+	if tx := conn(ctx, r.db).Save(dao); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
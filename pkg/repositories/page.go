@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+)
+
+// idの昇順にafterIdより後の行をlimit件に絞り込む(afterIdが空の場合は先頭から)
+// 読み込み中に作成・削除されても取りこぼさないように、オフセットではなく直前のページの最後のidを基準にする
+func afterIdPage(afterId string, limit int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if afterId != "" {
+			db = db.Where("id > ?", afterId)
+		}
+
+		return db.Order("id ASC").Limit(limit)
+	}
+}
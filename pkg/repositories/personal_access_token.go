@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm"
+)
+
+type PersonalAccessTokenRepositoryInterface interface {
+	FindById(
+		ctx context.Context,
+		id string,
+	) (*daos.PersonalAccessToken, error)
+
+	FindAllByUID(
+		ctx context.Context,
+		uid string,
+	) ([]*daos.PersonalAccessToken, error)
+
+	FindByTokenHash(
+		ctx context.Context,
+		tokenHash string,
+	) (*daos.PersonalAccessToken, error)
+
+	Save(
+		ctx context.Context,
+		dao *daos.PersonalAccessToken,
+	) error
+
+	Delete(
+		ctx context.Context,
+		id string,
+		uid string,
+	) error
+
+	DeleteAllByUID(
+		ctx context.Context,
+		uid string,
+	) (int64, error)
+}
+
+type PersonalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(
+	db *gorm.DB,
+) PersonalAccessTokenRepositoryInterface {
+	return &PersonalAccessTokenRepository{db}
+}
+
+func (r *PersonalAccessTokenRepository) FindById(
+	ctx context.Context,
+	id string,
+) (*daos.PersonalAccessToken, error) {
+	dao := &daos.PersonalAccessToken{}
+	if tx := conn(ctx, r.db).Where(&daos.PersonalAccessToken{ID: id}).First(dao); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return dao, nil
+}
+
+func (r *PersonalAccessTokenRepository) FindAllByUID(
+	ctx context.Context,
+	uid string,
+) ([]*daos.PersonalAccessToken, error) {
+	var tokens []*daos.PersonalAccessToken
+	if tx := conn(ctx, r.db).Where(&daos.PersonalAccessToken{UserId: uid}).Find(&tokens); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return tokens, nil
+}
+
+func (r *PersonalAccessTokenRepository) FindByTokenHash(
+	ctx context.Context,
+	tokenHash string,
+) (*daos.PersonalAccessToken, error) {
+	dao := &daos.PersonalAccessToken{}
+	if tx := conn(ctx, r.db).Where(&daos.PersonalAccessToken{TokenHash: tokenHash}).First(dao); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return dao, nil
+}
+
+func (r *PersonalAccessTokenRepository) Save(
+	ctx context.Context,
+	dao *daos.PersonalAccessToken,
+) error {
+	if tx := conn(ctx, r.db).Save(dao); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (r *PersonalAccessTokenRepository) Delete(
+	ctx context.Context,
+	id string,
+	uid string,
+) error {
+	if tx := conn(ctx, r.db).Where(&daos.PersonalAccessToken{ID: id, UserId: uid}).Delete(&daos.PersonalAccessToken{}); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+// 論理削除ではなく物理削除する
+func (r *PersonalAccessTokenRepository) DeleteAllByUID(
+	ctx context.Context,
+	uid string,
+) (int64, error) {
+	tx := conn(ctx, r.db).Unscoped().Where(&daos.PersonalAccessToken{UserId: uid}).Delete(&daos.PersonalAccessToken{})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return tx.RowsAffected, nil
+}
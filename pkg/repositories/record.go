@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
 	"gorm.io/gorm"
@@ -16,14 +17,33 @@ type RecordRepositoryInterface interface {
 		offset int,
 	) ([]*daos.Record, error)
 
+	FindByVisibility(
+		ctx context.Context,
+		visibility string,
+		startDate time.Time,
+		endDate time.Time,
+		limit int,
+		offset int,
+	) ([]*daos.Record, error)
+
 	FindById(
 		ctx context.Context,
 		id string,
 	) (*daos.Record, error)
 
+	Count(
+		ctx context.Context,
+	) (int64, error)
+
+	CountUsers(
+		ctx context.Context,
+	) (int64, error)
+
 	FindByUID(
 		ctx context.Context,
 		uid string,
+		startDate time.Time,
+		endDate time.Time,
 		limit int,
 		offset int,
 	) ([]*daos.Record, error)
@@ -33,6 +53,28 @@ type RecordRepositoryInterface interface {
 		uid string,
 	) ([]*daos.Record, error)
 
+	// 公開範囲がvisibilitiesのいずれかのRecordを作成日時で絞り込んで返す
+	FindByUIDAndVisibilities(
+		ctx context.Context,
+		uid string,
+		visibilities []string,
+		startDate time.Time,
+		endDate time.Time,
+	) ([]*daos.Record, error)
+
+	FindByIds(
+		ctx context.Context,
+		ids []string,
+	) ([]*daos.Record, error)
+
+	// idの昇順にafterIdより後のRecordをlimit件返す
+	FindPageByUID(
+		ctx context.Context,
+		uid string,
+		afterId string,
+		limit int,
+	) ([]*daos.Record, error)
+
 	FindByOfficialEventId(
 		ctx context.Context,
 		officialEventId uint,
@@ -53,6 +95,11 @@ type RecordRepositoryInterface interface {
 		id string,
 		uid string,
 	) error
+
+	DeleteAllByUID(
+		ctx context.Context,
+		uid string,
+	) (int64, error)
 }
 
 type RecordRepository struct {
@@ -81,7 +128,25 @@ func (r *RecordRepository) Find(
 ) ([]*daos.Record, error) {
 	var records []*daos.Record
 
-	if tx := r.db.Limit(limit).Offset(offset).Find(&records); tx.Error != nil {
+	if tx := conn(ctx, r.db).Limit(limit).Offset(offset).Find(&records); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return records, nil
+}
+
+// startDate・endDateを指定した場合は作成日時で絞り込む
+func (r *RecordRepository) FindByVisibility(
+	ctx context.Context,
+	visibility string,
+	startDate time.Time,
+	endDate time.Time,
+	limit int,
+	offset int,
+) ([]*daos.Record, error) {
+	var records []*daos.Record
+
+	if tx := conn(ctx, r.db).Where(&daos.Record{Visibility: visibility}).Scopes(dateRange("created_at", startDate, endDate)).Limit(limit).Offset(offset).Find(&records); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -94,7 +159,7 @@ func (r *RecordRepository) FindById(
 ) (*daos.Record, error) {
 	var record daos.Record
 
-	if tx := r.db.Where(&daos.Record{ID: id}).First(&record); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Record{ID: id}).First(&record); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -104,12 +169,14 @@ func (r *RecordRepository) FindById(
 func (r *RecordRepository) FindByUID(
 	ctx context.Context,
 	uid string,
+	startDate time.Time,
+	endDate time.Time,
 	limit int,
 	offset int,
 ) ([]*daos.Record, error) {
 	var records []*daos.Record
 
-	if tx := r.db.Where(&daos.Record{UserId: uid}).Limit(limit).Offset(offset).Find(&records); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Record{UserId: uid}).Scopes(dateRange("created_at", startDate, endDate)).Limit(limit).Offset(offset).Find(&records); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -122,7 +189,57 @@ func (r *RecordRepository) FindAllByUID(
 ) ([]*daos.Record, error) {
 	var records []*daos.Record
 
-	if tx := r.db.Where(&daos.Record{UserId: uid}).Find(&records); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Record{UserId: uid}).Find(&records); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return records, nil
+}
+
+func (r *RecordRepository) FindByUIDAndVisibilities(
+	ctx context.Context,
+	uid string,
+	visibilities []string,
+	startDate time.Time,
+	endDate time.Time,
+) ([]*daos.Record, error) {
+	var records []*daos.Record
+	if len(visibilities) == 0 {
+		return records, nil
+	}
+
+	if tx := conn(ctx, r.db).Where(&daos.Record{UserId: uid}).Where("visibility IN ?", visibilities).Scopes(dateRange("created_at", startDate, endDate)).Find(&records); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return records, nil
+}
+
+func (r *RecordRepository) FindByIds(
+	ctx context.Context,
+	ids []string,
+) ([]*daos.Record, error) {
+	var records []*daos.Record
+	if len(ids) == 0 {
+		return records, nil
+	}
+
+	if tx := conn(ctx, r.db).Where("id IN ?", ids).Find(&records); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return records, nil
+}
+
+func (r *RecordRepository) FindPageByUID(
+	ctx context.Context,
+	uid string,
+	afterId string,
+	limit int,
+) ([]*daos.Record, error) {
+	var records []*daos.Record
+
+	if tx := conn(ctx, r.db).Where(&daos.Record{UserId: uid}).Scopes(afterIdPage(afterId, limit)).Find(&records); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -135,7 +252,7 @@ func (r *RecordRepository) FindByOfficialEventId(
 ) ([]*daos.Record, error) {
 	var records []*daos.Record
 
-	if tx := r.db.Where(&daos.Record{OfficialEventId: officialEventId}).Find(&records); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Record{OfficialEventId: officialEventId}).Find(&records); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -148,18 +265,43 @@ func (r *RecordRepository) FindByDeckId(
 ) ([]*daos.Record, error) {
 	var records []*daos.Record
 
-	if tx := r.db.Where(&daos.Record{DeckId: deckId}).Find(&records); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Record{DeckId: deckId}).Find(&records); tx.Error != nil {
 		return nil, tx.Error
 	}
 
 	return records, nil
 }
 
+func (r *RecordRepository) Count(
+	ctx context.Context,
+) (int64, error) {
+	var count int64
+
+	if tx := conn(ctx, r.db).Model(&daos.Record{}).Count(&count); tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return count, nil
+}
+
+// Recordを1件以上作成しているユーザの数を返す
+func (r *RecordRepository) CountUsers(
+	ctx context.Context,
+) (int64, error) {
+	var count int64
+
+	if tx := conn(ctx, r.db).Model(&daos.Record{}).Distinct("user_id").Count(&count); tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return count, nil
+}
+
 func (r *RecordRepository) Save(
 	ctx context.Context,
 	record *daos.Record,
 ) error {
-	if tx := r.db.Save(record); tx.Error != nil {
+	if tx := conn(ctx, r.db).Save(record); tx.Error != nil {
 		return tx.Error
 	}
 
@@ -171,9 +313,22 @@ func (r *RecordRepository) Delete(
 	id string,
 	uid string,
 ) error {
-	if tx := r.db.Where(&daos.Record{ID: id, UserId: uid}).Delete(&daos.Record{}); tx.Error != nil {
+	if tx := conn(ctx, r.db).Where(&daos.Record{ID: id, UserId: uid}).Delete(&daos.Record{}); tx.Error != nil {
 		return tx.Error
 	}
 
 	return nil
 }
+
+// 論理削除ではなく物理削除する
+func (r *RecordRepository) DeleteAllByUID(
+	ctx context.Context,
+	uid string,
+) (int64, error) {
+	tx := conn(ctx, r.db).Unscoped().Where(&daos.Record{UserId: uid}).Delete(&daos.Record{})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return tx.RowsAffected, nil
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type transactionKey struct{}
+
+type TransactionRepositoryInterface interface {
+	Transaction(
+		ctx context.Context,
+		fn func(ctx context.Context) error,
+	) error
+}
+
+type TransactionRepository struct {
+	db *gorm.DB
+}
+
+func NewTransactionRepository(
+	db *gorm.DB,
+) TransactionRepositoryInterface {
+	return &TransactionRepository{db}
+}
+
+// fnに渡されるctxを各リポジトリに渡すと同一トランザクション内で実行される
+// fnがエラーを返した場合はロールバックする
+func (r *TransactionRepository) Transaction(
+	ctx context.Context,
+	fn func(ctx context.Context) error,
+) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, transactionKey{}, tx))
+	})
+}
+
+// ctxにトランザクションが設定されていればそれを、なければdbを返す
+// リクエストがキャンセルされた場合や制限時間を過ぎた場合にクエリを中断するためctxを引き継ぐ
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+
+	return db.WithContext(ctx)
+}
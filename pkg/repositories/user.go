@@ -2,41 +2,311 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	firebaseAuth "firebase.google.com/go/v4/auth"
+	"github.com/vsrecorder/vsr-apiserver/pkg/infrastructures"
 	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	// キャッシュはプロセス毎に持ち、他のインスタンスでの更新・削除はTTLが経過するまで反映されないため短くする
+	USER_CACHE_TTL = time.Duration(30) * time.Second
+
+	// 接続確認に使う、存在しないUID
+	FIREBASE_PING_UID = "vsrecorder-readyz"
+)
+
+var (
+	// Firebase Authenticationの呼び出しをスパンとして記録する
+	firebaseTracer = tracing.Tracer("repositories/firebase")
 )
 
 type UserRepositoryInterface interface {
+	// usersテーブルに存在しない場合はgorm.ErrRecordNotFoundを返す
 	FindById(
 		ctx context.Context,
 		id string,
 	) (*daos.User, error)
+
+	FindByIds(
+		ctx context.Context,
+		ids []string,
+	) ([]*daos.User, error)
+
+	// 本人のログイン時のみ呼び出す
+	Sync(
+		ctx context.Context,
+		id string,
+	) (*daos.User, error)
+
+	Save(
+		ctx context.Context,
+		dao *daos.User,
+	) error
+
+	DeleteByUID(
+		ctx context.Context,
+		uid string,
+	) (int64, error)
+
+	// 取り込み元のユーザを削除し、再びログイン・取り込みできないようにする
+	// 存在しないユーザの場合はエラーにしない
+	DeleteProvidedUser(
+		ctx context.Context,
+		uid string,
+	) error
+
+	// 表示名・画像の取り込み元に接続できるか確認する
+	Ping(
+		ctx context.Context,
+	) error
 }
 
-type UserRepository struct {
+// 表示名・画像の取り込み元
+// 本番環境ではFirebase Authentication、開発環境ではフィクスチャを使う
+type userProvider interface {
+	getUser(
+		ctx context.Context,
+		id string,
+	) (*daos.User, error)
+
+	// 存在しないユーザの場合はエラーにしない
+	deleteUser(
+		ctx context.Context,
+		id string,
+	) error
+
+	ping(
+		ctx context.Context,
+	) error
+}
+
+type firebaseUserProvider struct {
 	fbAuth *firebaseAuth.Client
 }
 
+func (p *firebaseUserProvider) getUser(
+	ctx context.Context,
+	id string,
+) (*daos.User, error) {
+	ctx, span := firebaseTracer.Start(ctx, "firebase.GetUser", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	start := time.Now()
+	userRecord, err := p.fbAuth.GetUser(ctx, id)
+	infrastructures.ObserveFirebaseRequest("GetUser", start, err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	return &daos.User{
+		UID:         userRecord.UID,
+		DisplayName: userRecord.DisplayName,
+		PhotoURL:    userRecord.PhotoURL,
+	}, nil
+}
+
+func (p *firebaseUserProvider) deleteUser(
+	ctx context.Context,
+	id string,
+) error {
+	ctx, span := firebaseTracer.Start(ctx, "firebase.DeleteUser", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	start := time.Now()
+	err := p.fbAuth.DeleteUser(ctx, id)
+	if firebaseAuth.IsUserNotFound(err) {
+		err = nil
+	}
+
+	infrastructures.ObserveFirebaseRequest("DeleteUser", start, err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// 存在しないユーザを取得し、ユーザが見つからないこと以外のエラーにならなければ接続できているとみなす
+func (p *firebaseUserProvider) ping(
+	ctx context.Context,
+) error {
+	ctx, span := firebaseTracer.Start(ctx, "firebase.Ping", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	start := time.Now()
+	_, err := p.fbAuth.GetUser(ctx, FIREBASE_PING_UID)
+	if firebaseAuth.IsUserNotFound(err) {
+		err = nil
+	}
+
+	infrastructures.ObserveFirebaseRequest("Ping", start, err)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+type UserRepository struct {
+	provider userProvider
+	db       *gorm.DB
+	cache    *ttlCache[daos.User]
+}
+
 func NewUserRepository(
 	fbAuth *firebaseAuth.Client,
+	db *gorm.DB,
 ) UserRepositoryInterface {
-	return &UserRepository{fbAuth}
+	return &UserRepository{&firebaseUserProvider{fbAuth}, db, newTTLCache[daos.User](USER_CACHE_TTL)}
+}
+
+func (r *UserRepository) cacheUser(dao *daos.User) *daos.User {
+	r.cache.set(dao.UID, *dao)
+
+	user := *dao
+	return &user
 }
 
+// 公開のプロフィールの参照で削除済みのユーザを取り込み直さないように、Firebase Authenticationは参照しない
 func (r *UserRepository) FindById(
 	ctx context.Context,
 	id string,
 ) (*daos.User, error) {
-	userRecord, err := r.fbAuth.GetUser(context.Background(), id)
+	if user, ok := r.cache.get(id); ok {
+		return &user, nil
+	}
+
+	dao := &daos.User{}
+	if tx := conn(ctx, r.db).Where(&daos.User{UID: id}).First(dao); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return r.cacheUser(dao), nil
+}
+
+// 一覧表示用に複数のユーザをまとめて取得する
+// 存在しないユーザは結果に含めず、順序はidsの順序に従う
+func (r *UserRepository) FindByIds(
+	ctx context.Context,
+	ids []string,
+) ([]*daos.User, error) {
+	userMap := map[string]*daos.User{}
+
+	missingIds := []string{}
+	for _, id := range ids {
+		if _, ok := userMap[id]; ok {
+			continue
+		}
+
+		if user, ok := r.cache.get(id); ok {
+			user := user
+			userMap[id] = &user
+			continue
+		}
+
+		missingIds = append(missingIds, id)
+	}
+
+	if len(missingIds) != 0 {
+		var users []*daos.User
+		if tx := conn(ctx, r.db).Where("uid IN ?", missingIds).Find(&users); tx.Error != nil {
+			return nil, tx.Error
+		}
+
+		for _, user := range users {
+			userMap[user.UID] = r.cacheUser(user)
+		}
+	}
+
+	users := []*daos.User{}
+	for _, id := range ids {
+		if user, ok := userMap[id]; ok {
+			users = append(users, user)
+			delete(userMap, id)
+		}
+	}
+
+	return users, nil
+}
+
+// Firebase Authenticationから表示名・画像を取り込む
+// アプリ独自のプロフィールは変更しない
+func (r *UserRepository) Sync(
+	ctx context.Context,
+	id string,
+) (*daos.User, error) {
+	providedUser, err := r.provider.getUser(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	user := &daos.User{}
-	user.UID = userRecord.UID
-	user.DisplayName = userRecord.DisplayName
-	user.PhotoURL = userRecord.PhotoURL
+	return r.save(ctx, providedUser)
+}
+
+func (r *UserRepository) save(
+	ctx context.Context,
+	providedUser *daos.User,
+) (*daos.User, error) {
+	dao := &daos.User{}
+	if tx := conn(ctx, r.db).Where(&daos.User{UID: providedUser.UID}).FirstOrInit(dao); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	dao.DisplayName = providedUser.DisplayName
+	dao.PhotoURL = providedUser.PhotoURL
+	dao.SyncedAt = time.Now()
+
+	if err := r.Save(ctx, dao); err != nil {
+		return nil, err
+	}
+
+	return r.cacheUser(dao), nil
+}
+
+func (r *UserRepository) Save(
+	ctx context.Context,
+	dao *daos.User,
+) error {
+	if tx := conn(ctx, r.db).Save(dao); tx.Error != nil {
+		return tx.Error
+	}
+
+	r.cache.delete(dao.UID)
+
+	return nil
+}
+
+func (r *UserRepository) DeleteByUID(
+	ctx context.Context,
+	uid string,
+) (int64, error) {
+	tx := conn(ctx, r.db).Where(&daos.User{UID: uid}).Delete(&daos.User{})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	r.cache.delete(uid)
+
+	return tx.RowsAffected, nil
+}
 
-	return user, nil
+func (r *UserRepository) DeleteProvidedUser(
+	ctx context.Context,
+	uid string,
+) error {
+	return r.provider.deleteUser(ctx, uid)
+}
+
+func (r *UserRepository) Ping(
+	ctx context.Context,
+) error {
+	return r.provider.ping(ctx)
 }
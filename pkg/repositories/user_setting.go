@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"gorm.io/gorm"
+)
+
+type UserSettingRepositoryInterface interface {
+	FindByUID(
+		ctx context.Context,
+		uid string,
+	) (*daos.UserSetting, error)
+
+	FindByCalendarTokenHash(
+		ctx context.Context,
+		calendarTokenHash string,
+	) (*daos.UserSetting, error)
+
+	Save(
+		ctx context.Context,
+		dao *daos.UserSetting,
+	) error
+
+	DeleteByUID(
+		ctx context.Context,
+		uid string,
+	) (int64, error)
+}
+
+type UserSettingRepository struct {
+	db *gorm.DB
+}
+
+func NewUserSettingRepository(
+	db *gorm.DB,
+) UserSettingRepositoryInterface {
+	return &UserSettingRepository{db}
+}
+
+// 設定が未作成の場合はUserIdのみを埋めたdaoを返す
+func (r *UserSettingRepository) FindByUID(
+	ctx context.Context,
+	uid string,
+) (*daos.UserSetting, error) {
+	dao := &daos.UserSetting{}
+	if tx := conn(ctx, r.db).Where(&daos.UserSetting{UserId: uid}).FirstOrInit(dao); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return dao, nil
+}
+
+func (r *UserSettingRepository) FindByCalendarTokenHash(
+	ctx context.Context,
+	calendarTokenHash string,
+) (*daos.UserSetting, error) {
+	// 購読を無効にしている全てのユーザに一致してしまうため拒否する
+	if calendarTokenHash == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	dao := &daos.UserSetting{}
+	if tx := conn(ctx, r.db).Where(&daos.UserSetting{CalendarTokenHash: calendarTokenHash}).First(dao); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return dao, nil
+}
+
+func (r *UserSettingRepository) Save(
+	ctx context.Context,
+	dao *daos.UserSetting,
+) error {
+	if tx := conn(ctx, r.db).Save(dao); tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (r *UserSettingRepository) DeleteByUID(
+	ctx context.Context,
+	uid string,
+) (int64, error) {
+	tx := conn(ctx, r.db).Where(&daos.UserSetting{UserId: uid}).Delete(&daos.UserSetting{})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	return tx.RowsAffected, nil
+}
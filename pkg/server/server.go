@@ -0,0 +1,598 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	firebase "firebase.google.com/go/v4"
+	"google.golang.org/api/option"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/penglongli/gin-metrics/ginmetrics"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/middlewares"
+	"github.com/vsrecorder/vsr-apiserver/pkg/infrastructures"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+	"gorm.io/gorm"
+)
+
+const (
+	API_PATH = "/api/v1alpha"
+
+	SCHEDULER_INTERVAL = time.Duration(10) * time.Minute
+)
+
+// 全てのリポジトリ・サービス・コントローラで1つのコネクションプールを共有する
+type Server struct {
+	// 起動時の設定(リクエストの処理中に参照されるため、再読み込みでは置き換えない)
+	config      *config.Config
+	db          *gorm.DB
+	router      *gin.Engine
+	rateLimiter *middlewares.RateLimiter
+
+	healthService        services.HealthServiceInterface
+	maintenanceService   services.MaintenanceServiceInterface
+	officialEventService services.OfficialEventServiceInterface
+
+	eventAttendanceService services.EventAttendanceServiceInterface
+	metaService            services.MetaServiceInterface
+
+	// 再読み込みで変更されるためリクエスト毎に参照する
+	allowOrigins atomic.Pointer[[]string]
+
+	// 最後に読み込んだ設定ファイルの読み取り専用モード
+	configReadOnly atomic.Bool
+}
+
+func NewServer(cfg *config.Config) (_ *Server, err error) {
+	db, err := infrastructures.NewDatabase(&cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	// 構築に失敗した場合は接続を閉じる
+	defer func() {
+		if err != nil {
+			infrastructures.CloseDatabase(db)
+		}
+	}()
+
+	schemaVersion, err := checkSchema(db, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 開発モードではFirebaseの代わりにフィクスチャのユーザを使う
+	var userRepository repositories.UserRepositoryInterface
+	var localUserRepository repositories.LocalUserRepositoryInterface
+	if cfg.Mode == config.MODE_DEVELOPMENT {
+		localUserRepository, err = newLocalUserRepository(db, cfg)
+		userRepository = localUserRepository
+	} else {
+		userRepository, err = newUserRepository(db, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	geocoder, err := newGeocoder(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := parseRateLimits(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		config:      cfg,
+		db:          db,
+		router:      gin.New(),
+		rateLimiter: middlewares.NewRateLimiter(middlewares.NewMemoryRateLimitStore(), limits),
+	}
+
+	s.allowOrigins.Store(&cfg.CORS.AllowOrigins)
+	s.configReadOnly.Store(cfg.Maintenance.ReadOnly)
+
+	r := s.router
+
+	// 信頼するプロキシを指定しない場合、X-Forwarded-Forなどのヘッダは詐称できるため使わない
+	if err := r.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		return nil, err
+	}
+
+	// *gin.Contextをサービス・リポジトリに渡した際にリクエストのキャンセル・制限時間・ロガーを引き継ぐ
+	r.ContextWithFallback = true
+	r.Use(gin.Recovery())
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(s.traceRequest)))
+
+	m := ginmetrics.GetMonitor()
+
+	m.SetMetricPath(cfg.MetricsPath)
+	m.SetSlowTime(10)
+	m.SetDuration([]float64{0.1, 0.25, 0.5, 0.75, 1.0, 2.5, 5.0, 7.5, 10.0})
+	m.Use(r)
+
+	r.Use(cors.New(cors.Config{
+		AllowMethods: []string{
+			"GET",
+			"POST",
+			"PUT",
+			"DELETE",
+		},
+		AllowHeaders: []string{
+			"Authorization",
+			"Content-Type",
+			"Content-Length",
+			"Access-Control-Allow-Origin",
+			middlewares.REQUEST_ID_HEADER,
+		},
+		ExposeHeaders: []string{
+			middlewares.REQUEST_ID_HEADER,
+			"RateLimit-Limit",
+			"RateLimit-Remaining",
+			"RateLimit-Reset",
+			"Retry-After",
+		},
+		AllowOriginFunc: s.allowOrigin,
+		MaxAge:          24 * time.Hour,
+	}))
+
+	r.Use(middlewares.RequestId)
+	r.Use(middlewares.Logging(slog.Default()))
+	// エクスポートは全件を読み込むため、クエリの制限時間を設けない
+	r.Use(middlewares.QueryTimeout(cfg.Database.QueryTimeout, API_PATH+controllers.USERS_PATH+"/me"+controllers.EXPORT_PATH))
+
+	transactionRepository := repositories.NewTransactionRepository(db)
+	officialEventRepository := repositories.NewOfficialEventRepository(db)
+	officialEventLocationRepository := repositories.NewOfficialEventLocationRepository(db)
+	recordRepository := repositories.NewRecordRepository(db)
+	gameRepository := repositories.NewGameRepository(db)
+	battleRepository := repositories.NewBattleRepository(db)
+	deckRepository := repositories.NewDeckRepository(db)
+	personalAccessTokenRepository := repositories.NewPersonalAccessTokenRepository(db)
+	userSettingRepository := repositories.NewUserSettingRepository(db)
+	followRepository := repositories.NewFollowRepository(db)
+	eventAttendanceRepository := repositories.NewEventAttendanceRepository(db)
+	metaAggregateRepository := repositories.NewMetaAggregateRepository(db)
+	auditLogRepository := repositories.NewAuditLogRepository(db)
+	erasureReceiptRepository := repositories.NewErasureReceiptRepository(db)
+	healthRepository := repositories.NewHealthRepository(db)
+	maintenanceSettingRepository := repositories.NewMaintenanceSettingRepository(db)
+
+	s.healthService = services.NewHealthService(
+		healthRepository,
+		userRepository,
+		schemaVersion,
+	)
+	s.maintenanceService = services.NewMaintenanceService(
+		maintenanceSettingRepository,
+		auditLogRepository,
+		transactionRepository,
+	)
+
+	// 設定ファイルで読み取り専用モードにしていない場合は、他のインスタンスや管理者が切り替えた状態を残す
+	if cfg.Maintenance.ReadOnly {
+		if err := s.maintenanceService.SetReadOnly(context.Background(), true); err != nil {
+			return nil, err
+		}
+	}
+
+	// 読み取り専用モードでもメンテナンスモードの解除は受け付ける
+	r.Use(middlewares.ReadOnly(s.maintenanceService, API_PATH+controllers.ADMIN_PATH+controllers.MAINTENANCE_PATH))
+
+	s.metaService = services.NewMetaService(
+		transactionRepository,
+		metaAggregateRepository,
+	)
+
+	userService := services.NewUserService(
+		userRepository,
+		recordRepository,
+		gameRepository,
+		deckRepository,
+		userSettingRepository,
+		followRepository,
+	)
+	recordService := services.NewRecordService(
+		transactionRepository,
+		recordRepository,
+		gameRepository,
+		officialEventRepository,
+		userSettingRepository,
+		followRepository,
+		auditLogRepository,
+		s.metaService,
+	)
+	gameService := services.NewGameService(
+		transactionRepository,
+		gameRepository,
+		recordRepository,
+		battleRepository,
+		followRepository,
+		auditLogRepository,
+	)
+	battleService := services.NewBattleService(
+		transactionRepository,
+		battleRepository,
+		gameRepository,
+		recordRepository,
+		followRepository,
+		auditLogRepository,
+	)
+	deckService := services.NewDeckService(
+		transactionRepository,
+		deckRepository,
+		recordRepository,
+		followRepository,
+		auditLogRepository,
+	)
+	personalAccessTokenService := services.NewPersonalAccessTokenService(
+		personalAccessTokenRepository,
+	)
+	calendarService := services.NewCalendarService(
+		userSettingRepository,
+		eventAttendanceRepository,
+		recordRepository,
+		officialEventRepository,
+	)
+
+	common := &controllers.Common{
+		Authorization: middlewares.NewAuthorization(
+			cfg.Auth.JWTSecret,
+			cfg.Auth.AdminUIDs,
+			personalAccessTokenService,
+			userService,
+			services.NewAuthorizationMetrics(),
+		),
+		RateLimiter:    s.rateLimiter,
+		TimeZoneFinder: userService,
+		PageLimit:      cfg.PageLimit,
+	}
+
+	controllers.NewHealthController(r, s.healthService).RegisterRoutes("")
+	controllers.NewMaintenanceController(r, common, s.maintenanceService).RegisterRoutes(API_PATH)
+	controllers.NewUserController(r, common, userService).RegisterRoutes(API_PATH)
+
+	s.officialEventService = services.NewOfficialEventService(
+		officialEventRepository,
+		recordRepository,
+		gameRepository,
+		deckRepository,
+		followRepository,
+		officialEventLocationRepository,
+		geocoder,
+	)
+
+	controllers.NewOfficialEventController(r, common, s.officialEventService).RegisterRoutes(API_PATH)
+
+	s.eventAttendanceService = services.NewEventAttendanceService(
+		transactionRepository,
+		eventAttendanceRepository,
+		officialEventRepository,
+		recordRepository,
+		recordService,
+		auditLogRepository,
+	)
+
+	controllers.NewEventAttendanceController(r, common, s.eventAttendanceService).RegisterRoutes(API_PATH)
+	controllers.NewCalendarController(r, common, calendarService).RegisterRoutes(API_PATH)
+
+	controllers.NewMetaController(r, common, s.metaService).RegisterRoutes(API_PATH)
+	controllers.NewRecordController(r, common, recordService).RegisterRoutes(API_PATH)
+	controllers.NewGameController(r, common, gameService).RegisterRoutes(API_PATH)
+	controllers.NewDeckController(r, common, deckService).RegisterRoutes(API_PATH)
+	controllers.NewBattleController(r, common, battleService).RegisterRoutes(API_PATH)
+	controllers.NewPersonalAccessTokenController(r, common, personalAccessTokenService).RegisterRoutes(API_PATH)
+
+	controllers.NewAuditLogController(
+		r,
+		common,
+		services.NewAuditLogService(
+			auditLogRepository,
+		),
+	).RegisterRoutes(API_PATH)
+
+	controllers.NewErasureController(
+		r,
+		common,
+		services.NewErasureService(
+			userRepository,
+			recordRepository,
+			gameRepository,
+			battleRepository,
+			deckRepository,
+			personalAccessTokenRepository,
+			userSettingRepository,
+			followRepository,
+			eventAttendanceRepository,
+			auditLogRepository,
+			erasureReceiptRepository,
+			s.metaService,
+		),
+	).RegisterRoutes(API_PATH)
+
+	controllers.NewExportController(
+		r,
+		common,
+		services.NewExportService(
+			userRepository,
+			userSettingRepository,
+			followRepository,
+			eventAttendanceRepository,
+			deckRepository,
+			recordRepository,
+			gameRepository,
+			battleRepository,
+			officialEventRepository,
+		),
+	).RegisterRoutes(API_PATH)
+
+	controllers.NewImportController(
+		r,
+		common,
+		services.NewImportService(
+			transactionRepository,
+			officialEventRepository,
+			deckRepository,
+			deckService,
+			recordService,
+			gameService,
+			battleService,
+		),
+	).RegisterRoutes(API_PATH)
+
+	controllers.NewAdminController(
+		r,
+		common,
+		services.NewAdminService(
+			transactionRepository,
+			userRepository,
+			recordRepository,
+			gameRepository,
+			battleRepository,
+			deckRepository,
+			auditLogRepository,
+			s.metaService,
+		),
+	).RegisterRoutes(API_PATH)
+
+	if cfg.Mode == config.MODE_DEVELOPMENT {
+		controllers.NewDevelopmentController(
+			r,
+			common,
+			services.NewDevelopmentService(localUserRepository),
+		).RegisterRoutes(API_PATH)
+	}
+
+	return s, nil
+}
+
+func (s *Server) DB() *gorm.DB {
+	return s.db
+}
+
+func (s *Server) Close() error {
+	return infrastructures.CloseDatabase(s.db)
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// 参加予定の大会の開催日を迎えたRecordの作成、取り込まれた大会の会場の座標の算出、環境レポートの直近の集計を定期的に行う
+// 読み取り専用モードの間は行わない
+func (s *Server) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if readOnly, err := s.maintenanceService.IsReadOnly(ctx); err != nil {
+			slog.Error("failed to check read-only mode", slog.Any("error", err))
+		} else if !readOnly {
+			if _, err := s.eventAttendanceService.CreateDueRecords(ctx, time.Now()); err != nil {
+				slog.Error("failed to create records for event attendances", slog.Any("error", err))
+			}
+
+			if _, err := s.officialEventService.Geocode(ctx); err != nil {
+				slog.Error("failed to geocode official events", slog.Any("error", err))
+			}
+
+			if _, err := s.metaService.Aggregate(ctx, time.Now()); err != nil {
+				slog.Error("failed to aggregate meta", slog.Any("error", err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// import-officialevent-batで取り込んだ大会の会場の座標を求める
+func (s *Server) GeocodeOfficialEvents(ctx context.Context) (int, error) {
+	return s.officialEventService.Geocode(ctx)
+}
+
+// 環境レポートの集計を全ての対戦から作り直す
+func (s *Server) RebuildMeta(ctx context.Context) (int, error) {
+	return s.metaService.Rebuild(ctx, time.Now())
+}
+
+// ctxがキャンセルされると新しい接続の受け付けを止め、処理中のリクエストを待ってから終了する
+func (s *Server) Run(ctx context.Context) error {
+	httpConfig := s.config.HTTP
+	srv := &http.Server{
+		Addr:              s.config.Addr,
+		Handler:           s.router,
+		ReadTimeout:       httpConfig.ReadTimeout,
+		ReadHeaderTimeout: httpConfig.ReadHeaderTimeout,
+		WriteTimeout:      httpConfig.WriteTimeout,
+		IdleTimeout:       httpConfig.IdleTimeout,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("listening", slog.String("addr", srv.Addr))
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down", slog.Duration("timeout", httpConfig.ShutdownTimeout))
+	s.healthService.SetShuttingDown()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), httpConfig.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// 再起動せずに反映できる設定(CORSの許可オリジン、レート制限、読み取り専用モード)のみ反映する
+// 読み取り専用モードは管理者がAPIで切り替えた状態を残すため、設定ファイルで変更された場合のみ反映する
+func (s *Server) Reload(cfg *config.Config) error {
+	limits, err := parseRateLimits(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.rateLimiter.SetLimits(limits)
+	s.allowOrigins.Store(&cfg.CORS.AllowOrigins)
+
+	if s.configReadOnly.Swap(cfg.Maintenance.ReadOnly) != cfg.Maintenance.ReadOnly {
+		if err := s.maintenanceService.SetReadOnly(context.Background(), cfg.Maintenance.ReadOnly); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ヘルスチェックとメトリクスの取得はトレースしない
+func (s *Server) traceRequest(req *http.Request) bool {
+	switch req.URL.Path {
+	case controllers.HEALTHZ_PATH, controllers.READYZ_PATH, s.config.MetricsPath:
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) allowOrigin(origin string) bool {
+	for _, allowOrigin := range *s.allowOrigins.Load() {
+		if allowOrigin == "*" || allowOrigin == origin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SQLiteの場合はスキーマを自動で作成し、それ以外はスキーマが古いまま起動しない
+// 適用済みのマイグレーションのバージョンを返す
+func checkSchema(db *gorm.DB, cfg *config.Config) (uint, error) {
+	migrator, err := infrastructures.NewMigrator(db)
+	if err != nil {
+		return 0, err
+	}
+
+	if cfg.Database.Driver == config.DB_DRIVER_SQLITE {
+		if err := migrator.Up(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := migrator.Check(); err != nil {
+		return 0, errors.New(err.Error() + ": run `migrate up` first")
+	}
+
+	return migrator.Version()
+}
+
+// 都道府県庁所在地の座標に、設定されたテーブル(市区町村の代表点など)を加える
+func newGeocoder(cfg *config.Config) (repositories.GeocoderInterface, error) {
+	if cfg.Geocoder.TablePath == "" {
+		return repositories.NewLocalGeocoder(repositories.PrefectureGeocoderEntries), nil
+	}
+
+	entries, err := repositories.LoadGeocoderEntries(cfg.Geocoder.TablePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return repositories.NewLocalGeocoder(repositories.PrefectureGeocoderEntries, entries), nil
+}
+
+// 開発モードではフィクスチャのユーザを使い、テスト用のJWTを発行する
+func newLocalUserRepository(db *gorm.DB, cfg *config.Config) (repositories.LocalUserRepositoryInterface, error) {
+	slog.Warn("running in development mode")
+
+	fixtures, err := repositories.LoadUserFixtures(cfg.Development.UserFixturePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Development.SQLFixturePath != "" {
+		if err := infrastructures.ExecSQLFile(db, cfg.Development.SQLFixturePath); err != nil {
+			return nil, err
+		}
+	}
+
+	return repositories.NewLocalUserRepository(fixtures, db), nil
+}
+
+func newUserRepository(db *gorm.DB, cfg *config.Config) (repositories.UserRepositoryInterface, error) {
+	opt := option.WithCredentialsFile(cfg.Firebase.CredentialsFilePath)
+	firebaseConfig := &firebase.Config{ProjectID: cfg.Firebase.ProjectId}
+
+	app, err := firebase.NewApp(context.Background(), firebaseConfig, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := app.Auth(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return repositories.NewUserRepository(auth, db), nil
+}
+
+func parseRateLimits(cfg *config.Config) (map[string]config.RateLimit, error) {
+	limits := map[string]config.RateLimit{}
+
+	for group, value := range cfg.RateLimits {
+		limit, err := config.ParseRateLimit(value)
+		if err != nil {
+			return nil, err
+		}
+
+		limits[group] = limit
+	}
+
+	return limits, nil
+}
@@ -0,0 +1,1283 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+	"github.com/vsrecorder/vsr-apiserver/pkg/config"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/middlewares"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services"
+)
+
+const (
+	INTEGRATION_OFFICIAL_EVENT_ID = 1
+	INTEGRATION_JWT_SECRET        = "integration"
+)
+
+// 開発モードのサーバをSQLiteのデータベースで構築する
+func setupIntegration(t *testing.T) http.Handler {
+	return newIntegrationServer(t).Handler()
+}
+
+func newIntegrationServer(t *testing.T) *Server {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Default()
+	cfg.Mode = config.MODE_DEVELOPMENT
+	cfg.Addr = "localhost:0"
+	cfg.Database.Driver = config.DB_DRIVER_SQLITE
+	cfg.Database.Path = filepath.Join(t.TempDir(), "vsrecorder.db")
+	cfg.Auth.JWTSecret = INTEGRATION_JWT_SECRET
+	cfg.Development.UserFixturePath = "../../configs/users.example.json"
+	cfg.RateLimits = nil
+	require.NoError(t, cfg.Validate())
+
+	s, err := NewServer(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, s.Close())
+	})
+
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	require.NoError(t, s.DB().Create(&oem.OfficialEvent{
+		Id:           INTEGRATION_OFFICIAL_EVENT_ID,
+		Title:        "シティリーグ",
+		Address:      "東京都千代田区",
+		Venue:        "ポケモンカードジム 東京",
+		Date:         time.Date(2024, 1, 14, 0, 0, 0, 0, jst),
+		TypeId:       2,
+		LeagueId:     1,
+		RegulationId: 1,
+		ShopId:       100,
+	}).Error)
+
+	located, err := s.GeocodeOfficialEvents(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, located)
+
+	return s
+}
+
+func integrationToken(t *testing.T, uid string, role string) string {
+	token, _, err := middlewares.NewAuthorization(INTEGRATION_JWT_SECRET, nil, nil, nil, nil).GenerateDevelopmentToken(uid, role)
+	require.NoError(t, err)
+
+	return token
+}
+
+// ユーザはログイン時に本人が同期するまでusersテーブルに存在しない
+func syncIntegrationUser(t *testing.T, r http.Handler, uid string) {
+	w := doIntegrationRequest(t, r, http.MethodPost, "/users/me/sync", integrationToken(t, uid, ""), nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func readIntegrationZip(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+
+		files[file.Name] = string(b)
+	}
+
+	return files
+}
+
+func doIntegrationRequest(
+	t *testing.T,
+	r http.Handler,
+	method string,
+	path string,
+	token string,
+	body any,
+) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	switch b := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(b))
+	default:
+		data, err := json.Marshal(b)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, API_PATH+path, reader)
+	require.NoError(t, err)
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func decodeIntegrationResponse(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	ret := map[string]any{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &ret), w.Body.String())
+
+	return ret
+}
+
+func TestIntegration(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T, r http.Handler){
+		"RecordGameBattle":     testIntegrationRecordGameBattle,
+		"DeckVisibility":       testIntegrationDeckVisibility,
+		"User":                 testIntegrationUser,
+		"OfficialEvent":        testIntegrationOfficialEvent,
+		"PersonalAccessToken":  testIntegrationPersonalAccessToken,
+		"ImportExport":         testIntegrationImportExport,
+		"Admin":                testIntegrationAdmin,
+		"Erasure":              testIntegrationErasure,
+		"Development":          testIntegrationDevelopment,
+		"Health":               testIntegrationHealth,
+		"Maintenance":          testIntegrationMaintenance,
+		"Metrics":              testIntegrationMetrics,
+		"Calendar":             testIntegrationCalendar,
+		"OfficialEventSummary": testIntegrationOfficialEventSummary,
+	} {
+		fn := fn
+		t.Run(scenario, func(t *testing.T) {
+			fn(t, setupIntegration(t))
+		})
+	}
+}
+
+func testIntegrationRecordGameBattle(t *testing.T, r http.Handler) {
+	token := integrationToken(t, "developer", "")
+
+	w := doIntegrationRequest(t, r, http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	recordId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/games", token, map[string]any{
+		"record_id":           recordId,
+		"victory_flg":         true,
+		"opponents_deck_info": "リザードンex",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	gameId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/battles", token, map[string]any{
+		"game_id":     gameId,
+		"go_first":    true,
+		"victory_flg": true,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	battleId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records/"+recordId, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records/"+recordId+"/games", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/games/"+gameId+"/battles", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/battles/"+battleId, token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, decodeIntegrationResponse(t, w)["records"], 1)
+
+	// 非公開にすると他のユーザからは参照できない
+	w = doIntegrationRequest(t, r, http.MethodPut, "/records/"+recordId, token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+		"visibility":        services.VISIBILITY_PRIVATE,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records/"+recordId, integrationToken(t, "opponent", ""), nil)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/battles/"+battleId, token, map[string]any{
+		"game_id":     gameId,
+		"go_first":    false,
+		"victory_flg": true,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/battles/"+battleId, token, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/games/"+gameId, token, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/records/"+recordId, token, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records/"+recordId, token, nil)
+	require.NotEqual(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 作成・更新・削除は監査ログに記録される
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/audit", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotEmpty(t, decodeIntegrationResponse(t, w)["audit_logs"])
+}
+
+func testIntegrationDeckVisibility(t *testing.T, r http.Handler) {
+	token := integrationToken(t, "developer", "")
+
+	w := doIntegrationRequest(t, r, http.MethodPost, "/decks", token, map[string]any{
+		"name":             "サーナイトex",
+		"code":             "abcdef-123456-ghijkl",
+		"private_code_flg": true,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	deckId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/decks/"+deckId, token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "abcdef-123456-ghijkl", decodeIntegrationResponse(t, w)["code"])
+
+	// デッキコードを非公開にしている場合は他のユーザには隠す
+	w = doIntegrationRequest(t, r, http.MethodGet, "/decks/"+deckId, integrationToken(t, "opponent", ""), nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotEqual(t, "abcdef-123456-ghijkl", decodeIntegrationResponse(t, w)["code"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/decks", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/decks/"+deckId, token, map[string]any{
+		"name": "サーナイトex 改",
+		"code": "abcdef-123456-ghijkl",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/decks/"+deckId, token, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+}
+
+func testIntegrationUser(t *testing.T, r http.Handler) {
+	token := integrationToken(t, "developer", "")
+
+	// フィクスチャに存在しても、認証したことの無いユーザは参照できない
+	w := doIntegrationRequest(t, r, http.MethodGet, "/users/administrator", "", nil)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	syncIntegrationUser(t, r, "developer")
+
+	// 同期を呼ばなくても、認証したリクエストで登録される
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/settings", integrationToken(t, "opponent", ""), nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/opponent", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotEmpty(t, decodeIntegrationResponse(t, w)["display_name"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users?ids=developer,opponent", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 一度に取得できる数を超える場合は拒否する
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users?ids="+strings.Repeat("a,", services.USER_FIND_BY_IDS_LIMIT+1), "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/users/me/profile", token, map[string]any{
+		"display_name": "Developer",
+		"prefecture":   "東京都",
+		"bio":          "よろしくお願いします",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/users/me/sync", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/users/me/settings", token, map[string]any{
+		"default_visibility":      services.VISIBILITY_FOLLOWERS,
+		"default_memo_visibility": services.VISIBILITY_PRIVATE,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/settings", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	ret := decodeIntegrationResponse(t, w)
+	require.Equal(t, services.VISIBILITY_FOLLOWERS, ret["default_visibility"])
+	require.Equal(t, services.DEFAULT_TIME_ZONE, ret["time_zone"])
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/users/me/settings", token, map[string]any{
+		"default_visibility":      services.VISIBILITY_FOLLOWERS,
+		"default_memo_visibility": services.VISIBILITY_PRIVATE,
+		"time_zone":               "Invalid/Zone",
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/users/me/settings", token, map[string]any{
+		"default_visibility":      services.VISIBILITY_FOLLOWERS,
+		"default_memo_visibility": services.VISIBILITY_PRIVATE,
+		"time_zone":               "America/Los_Angeles",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "America/Los_Angeles", decodeIntegrationResponse(t, w)["time_zone"])
+
+	// フォロワー限定のRecordはフォローしているユーザのみ参照できる
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	recordId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	opponentToken := integrationToken(t, "opponent", "")
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records/"+recordId, opponentToken, nil)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	// Gameが無いRecordでも存在を知られないようにする
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records/"+recordId+"/games", opponentToken, nil)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/users/developer/follow", opponentToken, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records/"+recordId, opponentToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/developer/records", opponentToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/developer/games", opponentToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/developer/decks", opponentToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/users/developer/follow", opponentToken, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+}
+
+func testIntegrationOfficialEvent(t *testing.T, r http.Handler) {
+	w := doIntegrationRequest(t, r, http.MethodGet, "/official_events", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, decodeIntegrationResponse(t, w)["official_events"], 1)
+
+	// 日付は既定では日本時間、tzを指定するとそのタイムゾーンで解釈する
+	for query, expected := range map[string]int{
+		"start_date=2024-01-14&end_date=2024-01-14":              1,
+		"start_date=2024-01-13&end_date=2024-01-13":              0,
+		"start_date=2024-01-13&end_date=2024-01-13&tz=UTC":       1,
+		"start_date=2024-01-14&end_date=2024-01-14&tz=UTC":       0,
+		"start_date=2024-01-15&end_date=2024-01-15":              0,
+		"start_date=2024-01-14&end_date=2024-01-14&tz=Etc/GMT-9": 1,
+	} {
+		w = doIntegrationRequest(t, r, http.MethodGet, "/official_events?"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var officialEvents []map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &officialEvents), w.Body.String())
+		require.Len(t, officialEvents, expected, query)
+	}
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events?start_date=2024-01-14&end_date=2024-01-14&tz=Invalid/Zone", "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events?start_date=2024-01-15&end_date=2024-01-14", "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	// 検索条件を組み合わせて絞り込む
+	for query, expected := range map[string]int{
+		"keyword=シティ":                           1,
+		"keyword=ジム 東京":                         1,
+		"keyword=ジム 大阪":                         0,
+		"keyword=%25":                           0,
+		"prefecture=東京都":                        1,
+		"prefecture=大阪府":                        0,
+		"type_id=2&league_id=1&regulation_id=1": 1,
+		"shop_id=101":                           0,
+		"keyword=シティ&start_date=2024-01-14&end_date=2024-01-14&sort=-date": 1,
+		"keyword=シティ&start_date=2024-01-15&end_date=2024-01-15":            0,
+		"start_date=2024-01-14&end_date=2024-01-14&page=2":                 0,
+	} {
+		w = doIntegrationRequest(t, r, http.MethodGet, "/official_events?"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, decodeIntegrationResponse(t, w)["official_events"], expected, query)
+	}
+
+	for _, query := range []string{"prefecture=東京", "sort=name", "type_id=abc", "shop_id=0"} {
+		w = doIntegrationRequest(t, r, http.MethodGet, "/official_events?"+query, "", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	// 会場の住所は都道府県庁所在地の座標で近似する
+	for query, expected := range map[string]int{
+		"lat=35.681&lng=139.767&radius_km=10&start_date=2024-01-14&end_date=2024-01-14":  1,
+		"lat=35.681&lng=139.767&radius_km=1&start_date=2024-01-14&end_date=2024-01-14":   0,
+		"lat=34.702&lng=135.495&radius_km=100&start_date=2024-01-14&end_date=2024-01-14": 0,
+		"lat=35.681&lng=139.767&radius_km=10":                                            0,
+	} {
+		w = doIntegrationRequest(t, r, http.MethodGet, "/official_events/nearby?"+query, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.Len(t, decodeIntegrationResponse(t, w)["official_events"], expected, query)
+	}
+
+	for _, query := range []string{"lat=abc&lng=139.767", "lng=139.767", "lat=91&lng=139.767", "lat=35.681&lng=139.767&radius_km=1000"} {
+		w = doIntegrationRequest(t, r, http.MethodGet, "/official_events/nearby?"+query, "", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events/1", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events/2", "", nil)
+	require.NotEqual(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func testIntegrationPersonalAccessToken(t *testing.T, r http.Handler) {
+	token := integrationToken(t, "developer", "")
+
+	w := doIntegrationRequest(t, r, http.MethodPost, "/users/me/tokens", token, map[string]any{
+		"name":   "読み取り専用",
+		"scopes": []string{helpers.SCOPE_RECORDS_READ},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	ret := decodeIntegrationResponse(t, w)
+	tokenId := ret["id"].(string)
+	personalAccessToken := ret["token"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records", personalAccessToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 許可されていないスコープの操作は拒否される
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", personalAccessToken, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+	})
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/tokens", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/users/me/tokens/"+tokenId, token, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records", personalAccessToken, nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+}
+
+func testIntegrationImportExport(t *testing.T, r http.Handler) {
+	token := integrationToken(t, "developer", "")
+
+	csv := strings.Join([]string{
+		"official_event_id,deck_name,round,opponent_deck,result,go_first,prizes",
+		"1,ロストバレット,1,リザードンex,win,先攻,6-3",
+		"1,ロストバレット,2,=1+1,lose,後攻,2-6",
+	}, "\n")
+
+	// dry_runでは検証のみ行い、書き込まない
+	w := doIntegrationRequest(t, r, http.MethodPost, "/users/me/import", token, csv)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, false, decodeIntegrationResponse(t, w)["committed"])
+
+	// curl --data-binaryの既定のContent-Typeでもボディをそのまま読み込む
+	{
+		req, err := http.NewRequest(http.MethodPost, API_PATH+"/users/me/import", strings.NewReader(csv))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.EqualValues(t, 2, decodeIntegrationResponse(t, w)["games"])
+	}
+
+	// ヘッダ行が無いCSVは不正なリクエストとして扱う
+	w = doIntegrationRequest(t, r, http.MethodPost, "/users/me/import", token, "")
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/users/me/import?dry_run=false", token, csv)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	ret := decodeIntegrationResponse(t, w)
+	require.Equal(t, true, ret["committed"])
+	require.EqualValues(t, 2, ret["games"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/export", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+	files := readIntegrationZip(t, w)
+	for _, name := range []string{"profile.json", "decks.json", "records.json", "games.json", "battles.json"} {
+		require.Contains(t, files, name)
+	}
+
+	profile := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(files["profile.json"]), &profile))
+	require.Contains(t, profile, "setting")
+	require.Contains(t, profile, "follows")
+	require.Contains(t, profile, "attendances")
+
+	games := []map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(files["games.json"]), &games))
+	require.Len(t, games, 2)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/export?format=csv", token, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	files = readIntegrationZip(t, w)
+	for _, name := range []string{"profile.csv", "follows.csv", "attendances.csv", "decks.csv", "records.csv", "games.csv", "battles.csv"} {
+		require.Contains(t, files, name)
+	}
+
+	// 数式として解釈されるセルは文字列として扱わせる
+	require.Contains(t, files["games.csv"], ",'=1+1,")
+	require.NotContains(t, files["games.csv"], ",=1+1,")
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/export?format=xml", token, nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+func testIntegrationAdmin(t *testing.T, r http.Handler) {
+	token := integrationToken(t, "developer", "")
+
+	w := doIntegrationRequest(t, r, http.MethodPost, "/decks", token, map[string]any{
+		"name": "サーナイトex",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	deckId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+		"deck_id":           deckId,
+		"visibility":        services.VISIBILITY_PRIVATE,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	recordId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/games", token, map[string]any{
+		"record_id": recordId,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	gameId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/battles", token, map[string]any{
+		"game_id": gameId,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	battleId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/stats", token, nil)
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	adminToken := integrationToken(t, "administrator", helpers.ROLE_ADMIN)
+	moderatorToken := integrationToken(t, "administrator", helpers.ROLE_MODERATOR)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/stats", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.EqualValues(t, 1, decodeIntegrationResponse(t, w)["records"])
+
+	// 非公開のRecordも参照できる
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/records/"+recordId, moderatorToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/users/developer/records", moderatorToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/admin/records/"+recordId+"/owner", moderatorToken, map[string]any{
+		"user_id": "opponent",
+	})
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	// 存在しない場合は404、変更先の指定が無い場合は400を返す
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/records/unknown", moderatorToken, nil)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	require.Equal(t, services.ErrNotFound.Error(), decodeIntegrationResponse(t, w)["message"])
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/admin/records/"+recordId+"/owner", adminToken, map[string]any{})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/admin/records/"+recordId+"/owner", adminToken, map[string]any{
+		"user_id": "unknown",
+	})
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	require.Equal(t, services.ErrUserNotFound.Error(), decodeIntegrationResponse(t, w)["message"])
+
+	syncIntegrationUser(t, r, "opponent")
+
+	// 変更前の所有者のDeckは外れ、GameとBattleの所有者も変更される
+	w = doIntegrationRequest(t, r, http.MethodPut, "/admin/records/"+recordId+"/owner", adminToken, map[string]any{
+		"user_id": "opponent",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	ret := decodeIntegrationResponse(t, w)
+	require.Equal(t, "opponent", ret["user_id"])
+	require.Empty(t, ret["deck_id"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/games/"+gameId, moderatorToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "opponent", decodeIntegrationResponse(t, w)["user_id"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/battles/"+battleId, integrationToken(t, "opponent", ""), nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "opponent", decodeIntegrationResponse(t, w)["user_id"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/decks/"+deckId, moderatorToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "developer", decodeIntegrationResponse(t, w)["user_id"])
+
+	// 変更前後の内容が監査ログに記録される
+	w = doIntegrationRequest(t, r, http.MethodGet, "/audit?entity="+services.AUDIT_ENTITY_RECORD+"&entity_id="+recordId, adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	reassigned := 0
+	for _, auditLog := range decodeIntegrationResponse(t, w)["audit_logs"].([]any) {
+		auditLog := auditLog.(map[string]any)
+		if auditLog["action"] != services.AUDIT_ACTION_REASSIGN {
+			continue
+		}
+
+		reassigned++
+		require.Equal(t, "administrator", auditLog["actor_uid"])
+		require.Equal(t, "developer", auditLog["before"].(map[string]any)["user_id"])
+		require.Equal(t, deckId, auditLog["before"].(map[string]any)["deck_id"])
+		require.Equal(t, "opponent", auditLog["after"].(map[string]any)["user_id"])
+		require.Empty(t, auditLog["after"].(map[string]any)["deck_id"])
+	}
+	require.Equal(t, 1, reassigned)
+
+	// Deckの所有者を変更すると、変更前の所有者のRecordからDeckが外れる
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+		"deck_id":           deckId,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	deckRecordId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/admin/decks/"+deckId+"/owner", adminToken, map[string]any{
+		"user_id": "opponent",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "opponent", decodeIntegrationResponse(t, w)["user_id"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/records/"+deckRecordId, moderatorToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Empty(t, decodeIntegrationResponse(t, w)["deck_id"])
+
+	// Gameを削除するとBattleも削除される
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/admin/games/"+gameId, moderatorToken, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/stats", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.EqualValues(t, 0, decodeIntegrationResponse(t, w)["battles"])
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/admin/records/"+recordId, moderatorToken, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/audit", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func testIntegrationErasure(t *testing.T, r http.Handler) {
+	token := integrationToken(t, "developer", "")
+	adminToken := integrationToken(t, "administrator", helpers.ROLE_ADMIN)
+
+	w := doIntegrationRequest(t, r, http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	recordId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	// 他のユーザの対戦相手として記録される
+	opponentToken := integrationToken(t, "opponent", "")
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", opponentToken, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/games", opponentToken, map[string]any{
+		"record_id":         decodeIntegrationResponse(t, w)["id"],
+		"opponents_user_id": "developer",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 運営者による参照
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/records/"+recordId, adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/users/developer/records", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/users/me", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	ret := decodeIntegrationResponse(t, w)
+	require.EqualValues(t, 1, ret["records"])
+	require.NotEmpty(t, ret["secret"])
+
+	// 監査ログにUIDや所有していたRecordの内容が残っていない
+	w = doIntegrationRequest(t, r, http.MethodGet, "/audit", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotContains(t, w.Body.String(), "developer")
+	require.Contains(t, w.Body.String(), services.ERASED_USER_PSEUDONYM_PREFIX)
+	require.NotContains(t, w.Body.String(), ret["uid_hash"].(string))
+
+	// 受領証は削除時に返したsecretか管理者でのみ参照できる
+	w = doIntegrationRequest(t, r, http.MethodGet, "/erasure_receipts/"+ret["id"].(string), "", nil)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/erasure_receipts/"+ret["id"].(string)+"?token=invalid", "", nil)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/erasure_receipts/"+ret["id"].(string)+"?token="+ret["secret"].(string), "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Empty(t, decodeIntegrationResponse(t, w)["secret"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/erasure_receipts/"+ret["id"].(string), adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/admin/erasure_receipts/"+ret["id"].(string), opponentToken, nil)
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Empty(t, decodeIntegrationResponse(t, w)["records"])
+}
+
+func testIntegrationDevelopment(t *testing.T, r http.Handler) {
+	w := doIntegrationRequest(t, r, http.MethodPost, "/dev/tokens", "", map[string]any{
+		"uid": "developer",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	token := decodeIntegrationResponse(t, w)["token"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/dev/tokens", "", map[string]any{
+		"uid": "unknown",
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+func testIntegrationHealth(t *testing.T, r http.Handler) {
+	for _, path := range []string{"/healthz", "/readyz"} {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		if path == "/readyz" {
+			ret := decodeIntegrationResponse(t, w)
+			require.Equal(t, true, ret["ready"])
+			require.NotZero(t, ret["schema_version"])
+			require.Equal(t, services.HEALTH_CHECK_OK, ret["checks"].(map[string]any)[services.HEALTH_CHECK_DATABASE])
+		}
+	}
+}
+
+func testIntegrationMaintenance(t *testing.T, r http.Handler) {
+	token := integrationToken(t, "developer", "")
+	adminToken := integrationToken(t, "administrator", helpers.ROLE_ADMIN)
+
+	w := doIntegrationRequest(t, r, http.MethodPut, "/admin/maintenance", token, map[string]any{
+		"read_only": true,
+	})
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/admin/maintenance", adminToken, map[string]any{
+		"read_only": true,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, true, decodeIntegrationResponse(t, w)["read_only"])
+
+	// 読み取り専用モードでは書き込みのみ拒否する
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+	})
+	require.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/admin/maintenance", adminToken, map[string]any{
+		"read_only": false,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func testIntegrationMetrics(t *testing.T, r http.Handler) {
+	token := integrationToken(t, "developer", "")
+
+	w := doIntegrationRequest(t, r, http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records", "invalid", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/metrics", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	for _, metric := range []string{
+		"vsrecorder_records_created_total",
+		`vsrecorder_active_users{window="1h"}`,
+		`vsrecorder_authorization_failures_total{reason="malformed_token"}`,
+		"go_sql_open_connections",
+	} {
+		require.Contains(t, w.Body.String(), metric)
+	}
+}
+
+func testIntegrationCalendar(t *testing.T, r http.Handler) {
+	w := doIntegrationRequest(t, r, http.MethodGet, "/official_events.ics?start_date=2024-01-01&end_date=2024-01-31", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Contains(t, w.Header().Get("Content-Type"), "text/calendar")
+	require.Contains(t, w.Body.String(), "BEGIN:VCALENDAR\r\n")
+	require.Contains(t, w.Body.String(), "SUMMARY:シティリーグ\r\n")
+	require.Contains(t, w.Body.String(), "DTSTART;VALUE=DATE:20240114\r\n")
+
+	// 日付を指定しない場合は今日以降の大会のみを返す
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events.ics", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotContains(t, w.Body.String(), "BEGIN:VEVENT")
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events.ics?type_id=x", "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/calendar.ics", "", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+
+	token := integrationToken(t, "developer", "")
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/users/me/events/1", token, map[string]any{
+		"status": services.ATTENDANCE_STATUS_PLANNED,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/users/me/calendar_token", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	calendarToken := decodeIntegrationResponse(t, w)["token"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/calendar.ics?token="+calendarToken, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), "SUMMARY:シティリーグ\r\n")
+	require.Contains(t, w.Body.String(), "DESCRIPTION:参加予定\r\n")
+
+	// 発行し直すと以前のトークンは使えなくなる
+	w = doIntegrationRequest(t, r, http.MethodPost, "/users/me/calendar_token", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	rotatedToken := decodeIntegrationResponse(t, w)["token"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/calendar.ics?token="+calendarToken, "", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/calendar.ics?token="+rotatedToken, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/users/me/calendar_token", token, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/calendar.ics?token="+rotatedToken, "", nil)
+	require.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+}
+
+func testIntegrationOfficialEventSummary(t *testing.T, r http.Handler) {
+	token := integrationToken(t, "developer", "")
+
+	w := doIntegrationRequest(t, r, http.MethodPost, "/decks", token, map[string]any{
+		"name": " リザードンex ",
+		"code": "abcdef-123456-ghijkl",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	deckId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+		"deck_id":           deckId,
+		"visibility":        services.VISIBILITY_PUBLIC,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	recordId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	for _, game := range []map[string]any{
+		{"record_id": recordId, "victory_flg": true, "opponents_deck_info": "サーナイトex"},
+		{"record_id": recordId, "victory_flg": false, "final_tournament_flg": true, "opponents_deck_info": "サーナイトex "},
+	} {
+		w = doIntegrationRequest(t, r, http.MethodPost, "/games", token, game)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// 非公開のRecordは本人以外の集計に含めない
+	opponentToken := integrationToken(t, "opponent", "")
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", opponentToken, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+		"visibility":        services.VISIBILITY_PRIVATE,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events/1/summary", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	summary := decodeIntegrationResponse(t, w)
+	require.EqualValues(t, 1, summary["participants"])
+
+	archetypes := summary["archetypes"].([]any)
+	require.Len(t, archetypes, 1)
+	require.Equal(t, "リザードンex", archetypes[0].(map[string]any)["archetype"])
+	require.EqualValues(t, 1, archetypes[0].(map[string]any)["top_cuts"])
+
+	results := summary["results"].([]any)
+	require.Len(t, results, 1)
+	require.EqualValues(t, 1, results[0].(map[string]any)["wins"])
+	require.EqualValues(t, 1, results[0].(map[string]any)["losses"])
+	require.Equal(t, true, results[0].(map[string]any)["top_cut"])
+
+	opponentDecks := summary["opponent_decks"].([]any)
+	require.Len(t, opponentDecks, 1)
+	require.Equal(t, "サーナイトex", opponentDecks[0].(map[string]any)["archetype"])
+	require.EqualValues(t, 2, opponentDecks[0].(map[string]any)["count"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events/1/summary", opponentToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.EqualValues(t, 2, decodeIntegrationResponse(t, w)["participants"])
+
+	// 大会のRecord一覧も閲覧者が参照できるものに限る
+	var records []map[string]any
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events/1/records", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &records), w.Body.String())
+	require.Len(t, records, 1)
+	require.Equal(t, recordId, records[0]["id"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events/1/records", opponentToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &records), w.Body.String())
+	require.Len(t, records, 2)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events/2/summary", "", nil)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/official_events/x/summary", "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+func TestIntegrationEventAttendance(t *testing.T) {
+	s := newIntegrationServer(t)
+	r := s.Handler()
+	token := integrationToken(t, "developer", "")
+
+	w := doIntegrationRequest(t, r, http.MethodPut, "/users/me/events/1", token, map[string]any{
+		"status": services.ATTENDANCE_STATUS_PLANNED,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/users/me/events/1", token, map[string]any{
+		"status": "maybe",
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodPut, "/users/me/events/2", token, map[string]any{
+		"status": services.ATTENDANCE_STATUS_PLANNED,
+	})
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/events?status=planned", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var attendances []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &attendances), w.Body.String())
+	require.Len(t, attendances, 1)
+	require.Empty(t, attendances[0]["record_id"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/events?status=attended", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "[]", w.Body.String())
+
+	// 開催日を迎えると空のRecordが一度だけ作成される
+	created, err := s.eventAttendanceService.CreateDueRecords(context.Background(), time.Date(2024, 1, 13, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 0, created)
+
+	created, err = s.eventAttendanceService.CreateDueRecords(context.Background(), time.Date(2024, 1, 13, 15, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 1, created)
+
+	created, err = s.eventAttendanceService.CreateDueRecords(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 0, created)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/events", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &attendances), w.Body.String())
+	require.Len(t, attendances, 1)
+	require.NotEmpty(t, attendances[0]["record_id"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/records/"+attendances[0]["record_id"].(string), token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/users/me/events/1", token, nil)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/users/me/events", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "[]", w.Body.String())
+}
+
+func TestIntegrationMeta(t *testing.T) {
+	s := newIntegrationServer(t)
+	r := s.Handler()
+	token := integrationToken(t, "developer", "")
+
+	w := doIntegrationRequest(t, r, http.MethodPost, "/decks", token, map[string]any{
+		"name": "リザードンex",
+		"code": "abcdef-123456-ghijkl",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	deckId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+		"deck_id":           deckId,
+		"visibility":        services.VISIBILITY_PUBLIC,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	recordId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	for _, game := range []map[string]any{
+		{"record_id": recordId, "victory_flg": true, "opponents_deck_info": "サーナイトex"},
+		{"record_id": recordId, "victory_flg": false, "opponents_deck_info": "サーナイトex"},
+		{"record_id": recordId, "victory_flg": true, "opponents_deck_info": ""},
+	} {
+		w = doIntegrationRequest(t, r, http.MethodPost, "/games", token, game)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// 非公開のRecordの対戦は集計しない
+	opponentToken := integrationToken(t, "opponent", "")
+	w = doIntegrationRequest(t, r, http.MethodPost, "/records", opponentToken, map[string]any{
+		"visibility": services.VISIBILITY_PRIVATE,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	privateRecordId := decodeIntegrationResponse(t, w)["id"].(string)
+
+	w = doIntegrationRequest(t, r, http.MethodPost, "/games", opponentToken, map[string]any{
+		"record_id":           privateRecordId,
+		"victory_flg":         true,
+		"opponents_deck_info": "ミライドンex",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 対戦は作成日時ではなく大会の開催日(2024-01-14)の週に集計する
+	const metaPath = "/meta?start_date=2023-12-24&end_date=2024-01-20"
+
+	// 集計するまではレポートに反映されない
+	w = doIntegrationRequest(t, r, http.MethodGet, metaPath, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.EqualValues(t, 0, decodeIntegrationResponse(t, w)["appearances"])
+
+	// 開催日が集計の期間より前の大会でも、直近に記録された対戦は集計する
+	aggregated, err := s.metaService.Aggregate(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 3, aggregated)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/meta", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.EqualValues(t, 0, decodeIntegrationResponse(t, w)["appearances"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, metaPath, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	report := decodeIntegrationResponse(t, w)
+	require.EqualValues(t, 5, report["appearances"])
+	require.Len(t, report["weeks"], 4)
+
+	archetypes := report["archetypes"].([]any)
+	require.Len(t, archetypes, 2)
+
+	own := archetypes[0].(map[string]any)
+	require.Equal(t, "リザードンex", own["archetype"])
+	require.EqualValues(t, 3, own["appearances"])
+	require.EqualValues(t, 2, own["wins"])
+	require.Nil(t, own["share_delta"])
+
+	opponent := archetypes[1].(map[string]any)
+	require.Equal(t, "サーナイトex", opponent["archetype"])
+	require.EqualValues(t, 2, opponent["appearances"])
+	require.EqualValues(t, 0.5, opponent["win_rate"])
+
+	// 最終週は前週に使用されていないため、使用率の差分は使用率と等しく、勝率の差分はnullになる
+	weeks := report["weeks"].([]any)
+	lastWeek := weeks[len(weeks)-1].(map[string]any)["archetypes"].([]any)
+	require.Len(t, lastWeek, 2)
+	require.EqualValues(t, 0.6, lastWeek[0].(map[string]any)["share_delta"])
+	require.Nil(t, lastWeek[0].(map[string]any)["win_rate_delta"])
+
+	rebuilt, err := s.RebuildMeta(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, rebuilt)
+
+	w = doIntegrationRequest(t, r, http.MethodGet, metaPath+"&format=1", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.EqualValues(t, 5, decodeIntegrationResponse(t, w)["appearances"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, metaPath+"&format=2", "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.EqualValues(t, 0, decodeIntegrationResponse(t, w)["appearances"])
+
+	// 公開範囲の変更とユーザの削除は集計を待たずに反映する
+	for _, tc := range []struct {
+		visibility  string
+		appearances int
+	}{
+		{services.VISIBILITY_PRIVATE, 0},
+		{services.VISIBILITY_PUBLIC, 5},
+	} {
+		w = doIntegrationRequest(t, r, http.MethodPut, "/records/"+recordId, token, map[string]any{
+			"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+			"deck_id":           deckId,
+			"visibility":        tc.visibility,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doIntegrationRequest(t, r, http.MethodGet, metaPath, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.EqualValues(t, tc.appearances, decodeIntegrationResponse(t, w)["appearances"])
+	}
+
+	w = doIntegrationRequest(t, r, http.MethodDelete, "/users/me", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, metaPath, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.EqualValues(t, 0, decodeIntegrationResponse(t, w)["appearances"])
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/meta?format=x", "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/meta?start_date=2023-01-01&end_date=2024-12-31", "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, r, http.MethodGet, "/meta?start_date=2024-01-01", "", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+func TestRunScheduler(t *testing.T) {
+	s := newIntegrationServer(t)
+
+	// スケジューラの起動後に取り込まれた大会の座標も求める
+	require.NoError(t, s.DB().Create(&oem.OfficialEvent{
+		Id:           INTEGRATION_OFFICIAL_EVENT_ID + 1,
+		Title:        "ジムバトル",
+		Address:      "東京都千代田区",
+		Date:         time.Now(),
+		TypeId:       3,
+		RegulationId: 1,
+	}).Error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.RunScheduler(ctx, time.Hour)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		var count int64
+		err := s.DB().Model(&daos.OfficialEventLocation{}).Count(&count).Error
+		return err == nil && count == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestServerReload(t *testing.T) {
+	s := newIntegrationServer(t)
+	r := s.Handler()
+
+	cfg := *s.config
+	cfg.Maintenance.ReadOnly = true
+	cfg.CORS.AllowOrigins = []string{"https://example.com"}
+	cfg.MetricsPath = "/reloaded"
+
+	// 処理中のリクエストと同時に再読み込みしても設定を読み書きで競合しない
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, API_PATH+"/official_events", nil))
+		}
+	}()
+
+	require.NoError(t, s.Reload(&cfg))
+	<-done
+
+	readOnly, err := s.maintenanceService.IsReadOnly(context.Background())
+	require.NoError(t, err)
+	require.True(t, readOnly)
+	require.True(t, s.allowOrigin("https://example.com"))
+
+	// 再起動が必要な設定は反映しない
+	require.NotEqual(t, cfg.MetricsPath, s.config.MetricsPath)
+
+	// 管理者が解除した読み取り専用モードは、設定ファイルが変わらなければ戻さない
+	require.NoError(t, s.maintenanceService.SetReadOnly(context.Background(), false))
+	require.NoError(t, s.Reload(&cfg))
+	readOnly, err = s.maintenanceService.IsReadOnly(context.Background())
+	require.NoError(t, err)
+	require.False(t, readOnly)
+}
+
+// 読み取り専用モードは同じデータベースを使う全てのインスタンスで共有する
+func TestServerSharedReadOnly(t *testing.T) {
+	s := newIntegrationServer(t)
+
+	other, err := NewServer(s.config)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, other.Close())
+	})
+
+	token := integrationToken(t, "developer", "")
+	adminToken := integrationToken(t, "administrator", helpers.ROLE_ADMIN)
+
+	w := doIntegrationRequest(t, s.Handler(), http.MethodPut, "/admin/maintenance", adminToken, map[string]any{
+		"read_only": true,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, other.Handler(), http.MethodPost, "/records", token, map[string]any{
+		"official_event_id": INTEGRATION_OFFICIAL_EVENT_ID,
+	})
+	require.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+
+	w = doIntegrationRequest(t, other.Handler(), http.MethodGet, "/admin/maintenance", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, true, decodeIntegrationResponse(t, w)["read_only"])
+}
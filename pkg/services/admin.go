@@ -0,0 +1,525 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// 管理者・運営者が指定したRecord・Game・Deckが存在しない
+	ErrNotFound = errors.New("not found")
+)
+
+func adminNotFoundError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+// 管理者・運営者向けの操作
+// 公開範囲やデッキコードの非公開設定に関わらず全てのデータを参照でき、全ての操作を監査ログに記録する
+// uidには操作を行う管理者・運営者のUIDを指定する
+type AdminServiceInterface interface {
+	FindRecordsByUserId(
+		ctx context.Context,
+		userId string,
+		uid string,
+	) ([]*models.Record, error)
+
+	FindGamesByUserId(
+		ctx context.Context,
+		userId string,
+		uid string,
+	) ([]*models.Game, error)
+
+	FindDecksByUserId(
+		ctx context.Context,
+		userId string,
+		uid string,
+	) ([]*models.Deck, error)
+
+	FindRecordById(
+		ctx context.Context,
+		id string,
+		uid string,
+	) (*models.Record, error)
+
+	FindGameById(
+		ctx context.Context,
+		id string,
+		uid string,
+	) (*models.Game, error)
+
+	FindDeckById(
+		ctx context.Context,
+		id string,
+		uid string,
+	) (*models.Deck, error)
+
+	DeleteRecord(
+		ctx context.Context,
+		id string,
+		uid string,
+	) error
+
+	DeleteGame(
+		ctx context.Context,
+		id string,
+		uid string,
+	) error
+
+	DeleteDeck(
+		ctx context.Context,
+		id string,
+		uid string,
+	) error
+
+	ReassignRecord(
+		ctx context.Context,
+		id string,
+		uid string,
+		dto *dtos.Owner,
+	) (*models.Record, error)
+
+	ReassignDeck(
+		ctx context.Context,
+		id string,
+		uid string,
+		dto *dtos.Owner,
+	) (*models.Deck, error)
+
+	FindStats(
+		ctx context.Context,
+		uid string,
+	) (*models.SystemStats, error)
+}
+
+type AdminService struct {
+	transactionRepository repositories.TransactionRepositoryInterface
+	userRepository        repositories.UserRepositoryInterface
+	recordRepository      repositories.RecordRepositoryInterface
+	gameRepository        repositories.GameRepositoryInterface
+	battleRepository      repositories.BattleRepositoryInterface
+	deckRepository        repositories.DeckRepositoryInterface
+	auditLogRepository    repositories.AuditLogRepositoryInterface
+	metaService           MetaServiceInterface
+}
+
+func NewAdminService(
+	transactionRepository repositories.TransactionRepositoryInterface,
+	userRepository repositories.UserRepositoryInterface,
+	recordRepository repositories.RecordRepositoryInterface,
+	gameRepository repositories.GameRepositoryInterface,
+	battleRepository repositories.BattleRepositoryInterface,
+	deckRepository repositories.DeckRepositoryInterface,
+	auditLogRepository repositories.AuditLogRepositoryInterface,
+	metaService MetaServiceInterface,
+) AdminServiceInterface {
+	return &AdminService{
+		transactionRepository,
+		userRepository,
+		recordRepository,
+		gameRepository,
+		battleRepository,
+		deckRepository,
+		auditLogRepository,
+		metaService,
+	}
+}
+
+func (s *AdminService) FindRecordsByUserId(
+	ctx context.Context,
+	userId string,
+	uid string,
+) ([]*models.Record, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.FindRecordsByUserId")
+	defer span.End()
+
+	daos, err := s.recordRepository.FindAllByUID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*models.Record{}
+	for _, dao := range daos {
+		records = append(records, createRecordModel(dao))
+	}
+
+	if err := writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_USER, userId, AUDIT_ACTION_VIEW, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (s *AdminService) FindGamesByUserId(
+	ctx context.Context,
+	userId string,
+	uid string,
+) ([]*models.Game, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.FindGamesByUserId")
+	defer span.End()
+
+	daos, err := s.gameRepository.FindAllByUID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	games := []*models.Game{}
+	for _, dao := range daos {
+		games = append(games, createGameModel(dao))
+	}
+
+	if err := writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_USER, userId, AUDIT_ACTION_VIEW, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return games, nil
+}
+
+func (s *AdminService) FindDecksByUserId(
+	ctx context.Context,
+	userId string,
+	uid string,
+) ([]*models.Deck, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.FindDecksByUserId")
+	defer span.End()
+
+	daos, err := s.deckRepository.FindAllByUID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	decks := []*models.Deck{}
+	for _, dao := range daos {
+		decks = append(decks, createDeckModel(dao))
+	}
+
+	if err := writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_USER, userId, AUDIT_ACTION_VIEW, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return decks, nil
+}
+
+func (s *AdminService) FindRecordById(
+	ctx context.Context,
+	id string,
+	uid string,
+) (*models.Record, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.FindRecordById")
+	defer span.End()
+
+	dao, err := s.recordRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, adminNotFoundError(err)
+	}
+
+	if err := writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_RECORD, id, AUDIT_ACTION_VIEW, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return createRecordModel(dao), nil
+}
+
+func (s *AdminService) FindGameById(
+	ctx context.Context,
+	id string,
+	uid string,
+) (*models.Game, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.FindGameById")
+	defer span.End()
+
+	dao, err := s.gameRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, adminNotFoundError(err)
+	}
+
+	if err := writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_GAME, id, AUDIT_ACTION_VIEW, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return createGameModel(dao), nil
+}
+
+func (s *AdminService) FindDeckById(
+	ctx context.Context,
+	id string,
+	uid string,
+) (*models.Deck, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.FindDeckById")
+	defer span.End()
+
+	dao, err := s.deckRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, adminNotFoundError(err)
+	}
+
+	if err := writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_DECK, id, AUDIT_ACTION_VIEW, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return createDeckModel(dao), nil
+}
+
+func (s *AdminService) DeleteRecord(
+	ctx context.Context,
+	id string,
+	uid string,
+) error {
+	ctx, span := tracer.Start(ctx, "AdminService.DeleteRecord")
+	defer span.End()
+
+	// 指定されたIdのRecordが存在するか確認
+	dao, err := s.recordRepository.FindById(ctx, id)
+	if err != nil {
+		return adminNotFoundError(err)
+	}
+
+	// 削除を環境レポートの集計に反映する
+	return s.metaService.ReaggregateRecords(ctx, []string{id}, func(ctx context.Context) error {
+		if err := s.recordRepository.Delete(ctx, id, dao.UserId); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_RECORD, id, AUDIT_ACTION_DELETE, createRecordModel(dao), nil)
+	})
+}
+
+func (s *AdminService) DeleteGame(
+	ctx context.Context,
+	id string,
+	uid string,
+) error {
+	ctx, span := tracer.Start(ctx, "AdminService.DeleteGame")
+	defer span.End()
+
+	// 指定されたIdのGameが存在するか確認
+	dao, err := s.gameRepository.FindById(ctx, id)
+	if err != nil {
+		return adminNotFoundError(err)
+	}
+
+	// Gameに紐づくBattleもまとめて削除し、削除を環境レポートの集計に反映する
+	return s.metaService.ReaggregateRecords(ctx, []string{dao.RecordId}, func(ctx context.Context) error {
+		battles, err := s.battleRepository.FindByGameId(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		for _, battle := range battles {
+			if err := s.battleRepository.Delete(ctx, battle.ID, battle.UserId); err != nil {
+				return err
+			}
+		}
+
+		if err := s.gameRepository.Delete(ctx, id, dao.UserId); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_GAME, id, AUDIT_ACTION_DELETE, createGameModel(dao), nil)
+	})
+}
+
+func (s *AdminService) DeleteDeck(
+	ctx context.Context,
+	id string,
+	uid string,
+) error {
+	ctx, span := tracer.Start(ctx, "AdminService.DeleteDeck")
+	defer span.End()
+
+	// 指定されたIdのDeckが存在するか確認
+	dao, err := s.deckRepository.FindById(ctx, id)
+	if err != nil {
+		return adminNotFoundError(err)
+	}
+
+	return s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.deckRepository.Delete(ctx, id, dao.UserId); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_DECK, id, AUDIT_ACTION_DELETE, createDeckModel(dao), nil)
+	})
+}
+
+// Recordに紐づくGameとBattleの所有者もまとめて変更する
+// 変更先のユーザのものではないDeckはRecordから外す
+func (s *AdminService) ReassignRecord(
+	ctx context.Context,
+	id string,
+	uid string,
+	dto *dtos.Owner,
+) (*models.Record, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.ReassignRecord")
+	defer span.End()
+
+	// 変更先のユーザが存在するか確認
+	if _, err := s.userRepository.FindById(ctx, dto.UserId); errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	dao, err := s.recordRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, adminNotFoundError(err)
+	}
+
+	before := createRecordModel(dao)
+
+	if dao.DeckId != "" {
+		deck, err := s.deckRepository.FindById(ctx, dao.DeckId)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && deck.UserId != dto.UserId) {
+			dao.DeckId = ""
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	// Deckを外した場合はデッキ名が変わるため、環境レポートの集計に反映する
+	if err := s.metaService.ReaggregateRecords(ctx, []string{id}, func(ctx context.Context) error {
+		dao.UserId = dto.UserId
+		if err := s.recordRepository.Save(ctx, dao); err != nil {
+			return err
+		}
+
+		games, err := s.gameRepository.FindByRecordId(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		for _, game := range games {
+			game.UserId = dto.UserId
+			if err := s.gameRepository.Save(ctx, game); err != nil {
+				return err
+			}
+
+			battles, err := s.battleRepository.FindByGameId(ctx, game.ID)
+			if err != nil {
+				return err
+			}
+
+			for _, battle := range battles {
+				battle.UserId = dto.UserId
+				if err := s.battleRepository.Save(ctx, battle); err != nil {
+					return err
+				}
+			}
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_RECORD, id, AUDIT_ACTION_REASSIGN, before, createRecordModel(dao))
+	}); err != nil {
+		return nil, err
+	}
+
+	return createRecordModel(dao), nil
+}
+
+// 変更前のユーザのRecordからはDeckを外す
+func (s *AdminService) ReassignDeck(
+	ctx context.Context,
+	id string,
+	uid string,
+	dto *dtos.Owner,
+) (*models.Deck, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.ReassignDeck")
+	defer span.End()
+
+	// 変更先のユーザが存在するか確認
+	if _, err := s.userRepository.FindById(ctx, dto.UserId); errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	dao, err := s.deckRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, adminNotFoundError(err)
+	}
+
+	before := createDeckModel(dao)
+
+	records, err := s.recordRepository.FindByDeckId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	recordIds := []string{}
+	for _, record := range records {
+		if record.UserId == before.UserId {
+			recordIds = append(recordIds, record.ID)
+		}
+	}
+
+	// Deckを外したRecordはデッキ名が変わるため、環境レポートの集計に反映する
+	if err := s.metaService.ReaggregateRecords(ctx, recordIds, func(ctx context.Context) error {
+		dao.UserId = dto.UserId
+		if err := s.deckRepository.Save(ctx, dao); err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if record.UserId != before.UserId {
+				continue
+			}
+
+			record.DeckId = ""
+			if err := s.recordRepository.Save(ctx, record); err != nil {
+				return err
+			}
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_DECK, id, AUDIT_ACTION_REASSIGN, before, createDeckModel(dao))
+	}); err != nil {
+		return nil, err
+	}
+
+	return createDeckModel(dao), nil
+}
+
+func (s *AdminService) FindStats(
+	ctx context.Context,
+	uid string,
+) (*models.SystemStats, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.FindStats")
+	defer span.End()
+
+	stats := &models.SystemStats{}
+
+	var err error
+
+	if stats.Users, err = s.recordRepository.CountUsers(ctx); err != nil {
+		return nil, err
+	}
+
+	if stats.Records, err = s.recordRepository.Count(ctx); err != nil {
+		return nil, err
+	}
+
+	if stats.Games, err = s.gameRepository.Count(ctx); err != nil {
+		return nil, err
+	}
+
+	if stats.Battles, err = s.battleRepository.Count(ctx); err != nil {
+		return nil, err
+	}
+
+	if stats.Decks, err = s.deckRepository.Count(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_SYSTEM, "", AUDIT_ACTION_VIEW, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
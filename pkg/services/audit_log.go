@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/logging"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	AUDIT_ACTION_CREATE = "create"
+	AUDIT_ACTION_UPDATE = "update"
+	AUDIT_ACTION_DELETE = "delete"
+
+	// 管理者・運営者による操作
+	AUDIT_ACTION_VIEW     = "view"
+	AUDIT_ACTION_REASSIGN = "reassign"
+
+	AUDIT_ENTITY_RECORD           = "record"
+	AUDIT_ENTITY_GAME             = "game"
+	AUDIT_ENTITY_BATTLE           = "battle"
+	AUDIT_ENTITY_DECK             = "deck"
+	AUDIT_ENTITY_USER             = "user"
+	AUDIT_ENTITY_SYSTEM           = "system"
+	AUDIT_ENTITY_EVENT_ATTENDANCE = "event_attendance"
+	AUDIT_ENTITY_ERASURE_RECEIPT  = "erasure_receipt"
+)
+
+type AuditLogServiceInterface interface {
+	Find(
+		ctx context.Context,
+		entity string,
+		entityId string,
+		actorUID string,
+		startDate time.Time,
+		endDate time.Time,
+		limit int,
+		offset int,
+	) ([]*models.AuditLog, error)
+
+	FindByUID(
+		ctx context.Context,
+		uid string,
+		startDate time.Time,
+		endDate time.Time,
+		limit int,
+		offset int,
+	) ([]*models.AuditLog, error)
+}
+
+type AuditLogService struct {
+	auditLogRepository repositories.AuditLogRepositoryInterface
+}
+
+func NewAuditLogService(
+	auditLogRepository repositories.AuditLogRepositoryInterface,
+) AuditLogServiceInterface {
+	return &AuditLogService{
+		auditLogRepository,
+	}
+}
+
+func rawJSON(value string) json.RawMessage {
+	if value == "" {
+		return json.RawMessage("null")
+	}
+
+	return json.RawMessage(value)
+}
+
+func createAuditLogModel(dao *daos.AuditLog) *models.AuditLog {
+	model := &models.AuditLog{}
+	model.ID = dao.ID
+	model.CreatedAt = dao.CreatedAt
+	model.ActorUID = dao.ActorUID
+	model.Entity = dao.Entity
+	model.EntityId = dao.EntityId
+	model.Action = dao.Action
+	model.Before = rawJSON(dao.Before)
+	model.After = rawJSON(dao.After)
+	model.Diff = rawJSON(dao.Diff)
+	model.RequestId = dao.RequestId
+
+	return model
+}
+
+func (s *AuditLogService) Find(
+	ctx context.Context,
+	entity string,
+	entityId string,
+	actorUID string,
+	startDate time.Time,
+	endDate time.Time,
+	limit int,
+	offset int,
+) ([]*models.AuditLog, error) {
+	ctx, span := tracer.Start(ctx, "AuditLogService.Find")
+	defer span.End()
+
+	condition := &daos.AuditLog{
+		Entity:   entity,
+		EntityId: entityId,
+		ActorUID: actorUID,
+	}
+
+	daos, err := s.auditLogRepository.Find(ctx, condition, startDate, endDate, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLogs := []*models.AuditLog{}
+	for _, dao := range daos {
+		auditLogs = append(auditLogs, createAuditLogModel(dao))
+	}
+
+	return auditLogs, nil
+}
+
+func (s *AuditLogService) FindByUID(
+	ctx context.Context,
+	uid string,
+	startDate time.Time,
+	endDate time.Time,
+	limit int,
+	offset int,
+) ([]*models.AuditLog, error) {
+	ctx, span := tracer.Start(ctx, "AuditLogService.FindByUID")
+	defer span.End()
+
+	daos, err := s.auditLogRepository.FindByUID(ctx, uid, startDate, endDate, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLogs := []*models.AuditLog{}
+	for _, dao := range daos {
+		auditLogs = append(auditLogs, createAuditLogModel(dao))
+	}
+
+	return auditLogs, nil
+}
+
+func toJSONMap(value any) (map[string]any, string, error) {
+	if value == nil {
+		return map[string]any{}, "", nil
+	}
+
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && v.IsNil() {
+		return map[string]any{}, "", nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, "", err
+	}
+
+	m := map[string]any{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, "", err
+	}
+
+	return m, string(b), nil
+}
+
+// 変更があったフィールドのみを {"field": {"before": ..., "after": ...}} の形式で返す
+func diffJSONMap(before map[string]any, after map[string]any) map[string]any {
+	diff := map[string]any{}
+
+	keys := map[string]struct{}{}
+	for key := range before {
+		keys[key] = struct{}{}
+	}
+	for key := range after {
+		keys[key] = struct{}{}
+	}
+
+	for key := range keys {
+		// 更新日時は毎回変わるため差分に含めない
+		if key == "updated_at" {
+			continue
+		}
+
+		b, a := before[key], after[key]
+		if !reflect.DeepEqual(b, a) {
+			diff[key] = map[string]any{
+				"before": b,
+				"after":  a,
+			}
+		}
+	}
+
+	return diff
+}
+
+// before/afterにはそれぞれ変更前後のモデルを渡す(作成時のbefore、削除時のafterはnil)
+func writeAuditLog(
+	ctx context.Context,
+	auditLogRepository repositories.AuditLogRepositoryInterface,
+	actorUID string,
+	entity string,
+	entityId string,
+	action string,
+	before any,
+	after any,
+) error {
+	beforeMap, beforeJSON, err := toJSONMap(before)
+	if err != nil {
+		return err
+	}
+
+	afterMap, afterJSON, err := toJSONMap(after)
+	if err != nil {
+		return err
+	}
+
+	diff, err := json.Marshal(diffJSONMap(beforeMap, afterMap))
+	if err != nil {
+		return err
+	}
+
+	id, err := generateId()
+	if err != nil {
+		return err
+	}
+
+	dao := daos.AuditLog{
+		ID:        id,
+		ActorUID:  actorUID,
+		Entity:    entity,
+		EntityId:  entityId,
+		Action:    action,
+		Before:    beforeJSON,
+		After:     afterJSON,
+		Diff:      string(diff),
+		RequestId: helpers.RequestIdFromContext(ctx),
+	}
+
+	if err := auditLogRepository.Create(ctx, &dao); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "audit",
+		slog.String("actor_uid", actorUID),
+		slog.String("entity", entity),
+		slog.String("entity_id", entityId),
+		slog.String("action", action),
+	)
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+func TestAuditLogDiff(t *testing.T) {
+	before, _, err := toJSONMap(&models.Deck{ID: "id", Name: "before", Code: "code"})
+	require.NoError(t, err)
+
+	after, _, err := toJSONMap(&models.Deck{ID: "id", Name: "after", Code: "code"})
+	require.NoError(t, err)
+
+	// 変更されたフィールドのみ差分に含まれる
+	require.Equal(t, map[string]any{
+		"name": map[string]any{
+			"before": "before",
+			"after":  "after",
+		},
+	}, diffJSONMap(before, after))
+
+	// 作成時は全てのフィールドが差分に含まれる
+	created, _, err := toJSONMap((*models.Deck)(nil))
+	require.NoError(t, err)
+	require.Equal(t, len(after)-1, len(diffJSONMap(created, after)))
+}
@@ -11,9 +11,10 @@ import (
 )
 
 type BattleServiceInterface interface {
-	FindById(
+	FindByIdWithUID(
 		ctx context.Context,
 		id string,
+		uid string,
 	) (*models.Battle, error)
 
 	Create(
@@ -37,14 +38,29 @@ type BattleServiceInterface interface {
 }
 
 type BattleService struct {
-	battleRepository repositories.BattleRepositoryInterface
+	transactionRepository repositories.TransactionRepositoryInterface
+	battleRepository      repositories.BattleRepositoryInterface
+	gameRepository        repositories.GameRepositoryInterface
+	recordRepository      repositories.RecordRepositoryInterface
+	followRepository      repositories.FollowRepositoryInterface
+	auditLogRepository    repositories.AuditLogRepositoryInterface
 }
 
 func NewBattleService(
+	transactionRepository repositories.TransactionRepositoryInterface,
 	battleRepository repositories.BattleRepositoryInterface,
+	gameRepository repositories.GameRepositoryInterface,
+	recordRepository repositories.RecordRepositoryInterface,
+	followRepository repositories.FollowRepositoryInterface,
+	auditLogRepository repositories.AuditLogRepositoryInterface,
 ) BattleServiceInterface {
 	return &BattleService{
+		transactionRepository,
 		battleRepository,
+		gameRepository,
+		recordRepository,
+		followRepository,
+		auditLogRepository,
 	}
 }
 
@@ -65,10 +81,14 @@ func createBattleModel(dao *daos.Battle) *models.Battle {
 	return &model
 }
 
-func (s *BattleService) FindById(
+func (s *BattleService) FindByIdWithUID(
 	ctx context.Context,
 	id string,
+	uid string,
 ) (*models.Battle, error) {
+	ctx, span := tracer.Start(ctx, "BattleService.FindByIdWithUID")
+	defer span.End()
+
 	dao, err := s.battleRepository.FindById(ctx, id)
 
 	if err != nil {
@@ -77,8 +97,27 @@ func (s *BattleService) FindById(
 
 	model := createBattleModel(dao)
 
-	return model, nil
+	// Battleの公開範囲は所属するGameのRecordに従う
+	gameDao, err := s.gameRepository.FindById(ctx, dao.GameId)
+	if err != nil {
+		return nil, err
+	}
 
+	recordDao, err := s.recordRepository.FindById(ctx, gameDao.RecordId)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := newViewer(uid, s.followRepository).viewBattle(ctx, createRecordModel(recordDao), model)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrNotVisible
+	}
+
+	return model, nil
 }
 
 func (s *BattleService) Create(
@@ -86,6 +125,9 @@ func (s *BattleService) Create(
 	uid string,
 	dto *dtos.Battle,
 ) (*models.Battle, error) {
+	ctx, span := tracer.Start(ctx, "BattleService.Create")
+	defer span.End()
+
 	id, err := generateId()
 	if err != nil {
 		return nil, err
@@ -102,12 +144,20 @@ func (s *BattleService) Create(
 		Memo:                dto.Memo,
 	}
 
-	if err := s.battleRepository.Save(ctx, &dao); err != nil {
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.battleRepository.Save(ctx, &dao); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_BATTLE, dao.ID, AUDIT_ACTION_CREATE, nil, createBattleModel(&dao))
+	}); err != nil {
 		return nil, err
 	}
 
 	model := createBattleModel(&dao)
 
+	battlesCreatedTotal.Inc()
+
 	return model, nil
 }
 
@@ -117,6 +167,9 @@ func (s *BattleService) Update(
 	uid string,
 	dto *dtos.Battle,
 ) (*models.Battle, error) {
+	ctx, span := tracer.Start(ctx, "BattleService.Update")
+	defer span.End()
+
 	// 指定されたIdのBattleが存在するか確認
 	dao, err := s.battleRepository.FindById(ctx, id)
 	if err != nil {
@@ -128,6 +181,8 @@ func (s *BattleService) Update(
 		return nil, errors.New("no authority")
 	}
 
+	before := createBattleModel(dao)
+
 	dao.GameId = dto.GameId
 	dao.GoFirst = dto.GoFirst
 	dao.VictoryFlg = dto.VictoryFlg
@@ -135,7 +190,13 @@ func (s *BattleService) Update(
 	dao.OpponentsPrizeCards = dto.OpponentsPrizeCards
 	dao.Memo = dto.Memo
 
-	if err := s.battleRepository.Save(ctx, dao); err != nil {
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.battleRepository.Save(ctx, dao); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_BATTLE, dao.ID, AUDIT_ACTION_UPDATE, before, createBattleModel(dao))
+	}); err != nil {
 		return nil, err
 	}
 
@@ -149,6 +210,9 @@ func (s *BattleService) Delete(
 	id string,
 	uid string,
 ) error {
+	ctx, span := tracer.Start(ctx, "BattleService.Delete")
+	defer span.End()
+
 	// 指定されたIdのBattleが存在するか確認
 	dao, err := s.battleRepository.FindById(ctx, id)
 	if err != nil {
@@ -160,9 +224,11 @@ func (s *BattleService) Delete(
 		return errors.New("no authority")
 	}
 
-	if err := s.battleRepository.Delete(ctx, id, uid); err != nil {
-		return err
-	}
+	return s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.battleRepository.Delete(ctx, id, uid); err != nil {
+			return err
+		}
 
-	return nil
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_BATTLE, id, AUDIT_ACTION_DELETE, createBattleModel(dao), nil)
+	})
 }
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	CALENDAR_TOKEN_BYTES = 32
+)
+
+var (
+	ErrInvalidCalendarToken = errors.New("invalid calendar token")
+)
+
+type CalendarServiceInterface interface {
+	// 購読URLのトークンを発行し直し、以前のトークンを無効にする
+	CreateToken(
+		ctx context.Context,
+		uid string,
+	) (string, error)
+
+	DeleteToken(
+		ctx context.Context,
+		uid string,
+	) error
+
+	// トークンに対応するユーザのUIDを返す
+	Verify(
+		ctx context.Context,
+		token string,
+	) (string, error)
+
+	// 参加予定を登録した大会とRecordを作成した大会を開催日の昇順に返す
+	FindEventsByUID(
+		ctx context.Context,
+		uid string,
+	) ([]*models.CalendarEvent, error)
+}
+
+type CalendarService struct {
+	userSettingRepository     repositories.UserSettingRepositoryInterface
+	eventAttendanceRepository repositories.EventAttendanceRepositoryInterface
+	recordRepository          repositories.RecordRepositoryInterface
+	officialEventRepository   repositories.OfficialEventRepositoryInterface
+}
+
+func NewCalendarService(
+	userSettingRepository repositories.UserSettingRepositoryInterface,
+	eventAttendanceRepository repositories.EventAttendanceRepositoryInterface,
+	recordRepository repositories.RecordRepositoryInterface,
+	officialEventRepository repositories.OfficialEventRepositoryInterface,
+) CalendarServiceInterface {
+	return &CalendarService{
+		userSettingRepository,
+		eventAttendanceRepository,
+		recordRepository,
+		officialEventRepository,
+	}
+}
+
+func generateCalendarToken() (string, error) {
+	b := make([]byte, CALENDAR_TOKEN_BYTES)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// トークンそのものは保存せずハッシュ値のみを保存する
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *CalendarService) CreateToken(
+	ctx context.Context,
+	uid string,
+) (string, error) {
+	ctx, span := tracer.Start(ctx, "CalendarService.CreateToken")
+	defer span.End()
+
+	token, err := generateCalendarToken()
+	if err != nil {
+		return "", err
+	}
+
+	dao, err := s.userSettingRepository.FindByUID(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+
+	dao.CalendarTokenHash = hashCalendarToken(token)
+
+	if err := s.userSettingRepository.Save(ctx, dao); err != nil {
+		return "", err
+	}
+
+	// トークンを返すのは発行時の1回のみ
+	return token, nil
+}
+
+func (s *CalendarService) DeleteToken(
+	ctx context.Context,
+	uid string,
+) error {
+	ctx, span := tracer.Start(ctx, "CalendarService.DeleteToken")
+	defer span.End()
+
+	dao, err := s.userSettingRepository.FindByUID(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	if dao.CalendarTokenHash == "" {
+		return nil
+	}
+
+	dao.CalendarTokenHash = ""
+
+	return s.userSettingRepository.Save(ctx, dao)
+}
+
+func (s *CalendarService) Verify(
+	ctx context.Context,
+	token string,
+) (string, error) {
+	ctx, span := tracer.Start(ctx, "CalendarService.Verify")
+	defer span.End()
+
+	if token == "" {
+		return "", ErrInvalidCalendarToken
+	}
+
+	dao, err := s.userSettingRepository.FindByCalendarTokenHash(ctx, hashCalendarToken(token))
+	if err != nil {
+		return "", ErrInvalidCalendarToken
+	}
+
+	return dao.UserId, nil
+}
+
+func (s *CalendarService) FindEventsByUID(
+	ctx context.Context,
+	uid string,
+) ([]*models.CalendarEvent, error) {
+	ctx, span := tracer.Start(ctx, "CalendarService.FindEventsByUID")
+	defer span.End()
+
+	attendances, err := s.eventAttendanceRepository.FindByUID(ctx, uid, "", time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.recordRepository.FindAllByUID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []uint{}
+	eventMap := map[uint]*models.CalendarEvent{}
+
+	for _, attendance := range attendances {
+		ids = append(ids, attendance.OfficialEventId)
+		eventMap[attendance.OfficialEventId] = &models.CalendarEvent{
+			Status:   attendance.Status,
+			RecordId: attendance.RecordId,
+		}
+	}
+
+	for _, record := range records {
+		// 公式大会以外のRecordはカレンダーに載せない
+		if record.OfficialEventId == 0 {
+			continue
+		}
+
+		if event, ok := eventMap[record.OfficialEventId]; ok {
+			if event.RecordId == "" {
+				event.RecordId = record.ID
+			}
+			continue
+		}
+
+		ids = append(ids, record.OfficialEventId)
+		eventMap[record.OfficialEventId] = &models.CalendarEvent{
+			RecordId: record.ID,
+		}
+	}
+
+	officialEvents, err := s.officialEventRepository.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// 取り込み元から削除された大会は載せない
+	events := []*models.CalendarEvent{}
+	for _, officialEvent := range officialEvents {
+		event := eventMap[officialEvent.Id]
+		event.OfficialEvent = officialEvent
+		events = append(events, event)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OfficialEvent.Date.Before(events[j].OfficialEvent.Date)
+	})
+
+	return events, nil
+}
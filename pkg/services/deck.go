@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
@@ -20,6 +21,8 @@ type DeckServiceInterface interface {
 	FindByUID(
 		ctx context.Context,
 		uid string,
+		startDate time.Time,
+		endDate time.Time,
 		limit int,
 		offset int,
 	) ([]*models.Deck, error)
@@ -29,9 +32,10 @@ type DeckServiceInterface interface {
 		uid string,
 	) ([]*models.Deck, error)
 
-	FindRecordById(
+	FindRecordByIdWithUID(
 		ctx context.Context,
 		id string,
+		uid string,
 	) ([]*models.Record, error)
 
 	Create(
@@ -55,17 +59,26 @@ type DeckServiceInterface interface {
 }
 
 type DeckService struct {
-	deckRepository   repositories.DeckRepositoryInterface
-	recordRepository repositories.RecordRepositoryInterface
+	transactionRepository repositories.TransactionRepositoryInterface
+	deckRepository        repositories.DeckRepositoryInterface
+	recordRepository      repositories.RecordRepositoryInterface
+	followRepository      repositories.FollowRepositoryInterface
+	auditLogRepository    repositories.AuditLogRepositoryInterface
 }
 
 func NewDeckService(
+	transactionRepository repositories.TransactionRepositoryInterface,
 	deckRepository repositories.DeckRepositoryInterface,
 	recordRepository repositories.RecordRepositoryInterface,
+	followRepository repositories.FollowRepositoryInterface,
+	auditLogRepository repositories.AuditLogRepositoryInterface,
 ) DeckServiceInterface {
 	return &DeckService{
+		transactionRepository,
 		deckRepository,
 		recordRepository,
+		followRepository,
+		auditLogRepository,
 	}
 }
 
@@ -88,6 +101,9 @@ func (s *DeckService) FindByIdWithUID(
 	id string,
 	uid string,
 ) (*models.Deck, error) {
+	ctx, span := tracer.Start(ctx, "DeckService.FindByIdWithUID")
+	defer span.End()
+
 	dao, err := s.deckRepository.FindById(ctx, id)
 
 	if err != nil {
@@ -96,8 +112,8 @@ func (s *DeckService) FindByIdWithUID(
 
 	model := createDeckModel(dao)
 
-	if model.PrivateCodeFlg && uid != model.UserId {
-		model.Code = "ZZZZZZ-YYYYYY-ZZZZZZ"
+	if err := newViewer(uid, s.followRepository).maskDeck(ctx, model); err != nil {
+		return nil, err
 	}
 
 	return model, nil
@@ -106,10 +122,15 @@ func (s *DeckService) FindByIdWithUID(
 func (s *DeckService) FindByUID(
 	ctx context.Context,
 	uid string,
+	startDate time.Time,
+	endDate time.Time,
 	limit int,
 	offset int,
 ) ([]*models.Deck, error) {
-	daos, err := s.deckRepository.FindByUID(ctx, uid, limit, offset)
+	ctx, span := tracer.Start(ctx, "DeckService.FindByUID")
+	defer span.End()
+
+	daos, err := s.deckRepository.FindByUID(ctx, uid, startDate, endDate, limit, offset)
 
 	if err != nil {
 		return nil, err
@@ -128,6 +149,9 @@ func (s *DeckService) FindAllByUID(
 	ctx context.Context,
 	uid string,
 ) ([]*models.Deck, error) {
+	ctx, span := tracer.Start(ctx, "DeckService.FindAllByUID")
+	defer span.End()
+
 	daos, err := s.deckRepository.FindAllByUID(ctx, uid)
 
 	if err != nil {
@@ -143,10 +167,14 @@ func (s *DeckService) FindAllByUID(
 	return decks, nil
 }
 
-func (s *DeckService) FindRecordById(
+func (s *DeckService) FindRecordByIdWithUID(
 	ctx context.Context,
 	id string,
+	uid string,
 ) ([]*models.Record, error) {
+	ctx, span := tracer.Start(ctx, "DeckService.FindRecordByIdWithUID")
+	defer span.End()
+
 	// 指定されたIdのDeckが存在するか確認
 	if _, err := s.deckRepository.FindById(ctx, id); err != nil {
 		return nil, err
@@ -162,7 +190,7 @@ func (s *DeckService) FindRecordById(
 		records = append(records, createRecordModel(dao))
 	}
 
-	return records, nil
+	return newViewer(uid, s.followRepository).filterRecords(ctx, records)
 }
 
 func (s *DeckService) Create(
@@ -170,6 +198,9 @@ func (s *DeckService) Create(
 	uid string,
 	dto *dtos.Deck,
 ) (*models.Deck, error) {
+	ctx, span := tracer.Start(ctx, "DeckService.Create")
+	defer span.End()
+
 	id, err := generateId()
 	if err != nil {
 		return nil, err
@@ -183,7 +214,13 @@ func (s *DeckService) Create(
 		PrivateCodeFlg: dto.PrivateCodeFlg,
 	}
 
-	if err := s.deckRepository.Save(ctx, &dao); err != nil {
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.deckRepository.Save(ctx, &dao); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_DECK, dao.ID, AUDIT_ACTION_CREATE, nil, createDeckModel(&dao))
+	}); err != nil {
 		return nil, err
 	}
 
@@ -198,6 +235,9 @@ func (s *DeckService) Update(
 	uid string,
 	dto *dtos.Deck,
 ) (*models.Deck, error) {
+	ctx, span := tracer.Start(ctx, "DeckService.Update")
+	defer span.End()
+
 	// TODO: 指定されたdto.Codeがトレーナーズウェブサイト上に存在するか確認したい(有効なデッキコードか否か)
 	// https://www.pokemon-card.com/deck/result.html/deckID/{dto.Code}
 
@@ -212,11 +252,19 @@ func (s *DeckService) Update(
 		return nil, errors.New("no authority")
 	}
 
+	before := createDeckModel(dao)
+
 	dao.Name = dto.Name
 	dao.Code = dto.Code
 	dao.PrivateCodeFlg = dto.PrivateCodeFlg
 
-	if err := s.deckRepository.Save(ctx, dao); err != nil {
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.deckRepository.Save(ctx, dao); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_DECK, dao.ID, AUDIT_ACTION_UPDATE, before, createDeckModel(dao))
+	}); err != nil {
 		return nil, err
 	}
 
@@ -230,6 +278,9 @@ func (s *DeckService) Delete(
 	id string,
 	uid string,
 ) error {
+	ctx, span := tracer.Start(ctx, "DeckService.Delete")
+	defer span.End()
+
 	// 指定されたIdのDeckが存在するか確認
 	dao, err := s.deckRepository.FindById(ctx, id)
 	if err != nil {
@@ -241,5 +292,11 @@ func (s *DeckService) Delete(
 		return errors.New("no authority")
 	}
 
-	return s.deckRepository.Delete(ctx, id, uid)
+	return s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.deckRepository.Delete(ctx, id, uid); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_DECK, id, AUDIT_ACTION_DELETE, createDeckModel(dao), nil)
+	})
 }
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+	"gorm.io/gorm"
+)
+
+// 開発モードでのみ使う
+type DevelopmentServiceInterface interface {
+	// テスト用のJWTを発行できるフィクスチャのユーザを返す
+	// usersテーブルに取り込まれていないユーザも返す
+	FindFixtureUser(
+		ctx context.Context,
+		uid string,
+	) (*models.User, error)
+}
+
+type DevelopmentService struct {
+	userRepository repositories.LocalUserRepositoryInterface
+}
+
+func NewDevelopmentService(
+	userRepository repositories.LocalUserRepositoryInterface,
+) DevelopmentServiceInterface {
+	return &DevelopmentService{userRepository}
+}
+
+func (s *DevelopmentService) FindFixtureUser(
+	ctx context.Context,
+	uid string,
+) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "DevelopmentService.FindFixtureUser")
+	defer span.End()
+
+	dao, err := s.userRepository.FindFixtureById(ctx, uid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return createUserModel(dao), nil
+}
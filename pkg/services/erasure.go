@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/logging"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	ERASED_USER_PSEUDONYM_PREFIX = "erased:"
+
+	// 仮名・受領証の秘密の値に使う乱数のバイト数
+	ERASURE_RANDOM_BYTES = 32
+)
+
+var (
+	ErrInvalidErasureReceiptSecret = errors.New("invalid erasure receipt secret")
+)
+
+type ErasureServiceInterface interface {
+	// secretは削除時に受領証とともに返した値
+	FindReceiptById(
+		ctx context.Context,
+		id string,
+		secret string,
+	) (*models.ErasureReceipt, error)
+
+	// 管理者による参照は監査ログに記録する
+	FindReceiptByIdAsAdministrator(
+		ctx context.Context,
+		id string,
+		uid string,
+	) (*models.ErasureReceipt, error)
+
+	Erase(
+		ctx context.Context,
+		uid string,
+	) (*models.ErasureReceipt, error)
+}
+
+type ErasureService struct {
+	userRepository                repositories.UserRepositoryInterface
+	recordRepository              repositories.RecordRepositoryInterface
+	gameRepository                repositories.GameRepositoryInterface
+	battleRepository              repositories.BattleRepositoryInterface
+	deckRepository                repositories.DeckRepositoryInterface
+	personalAccessTokenRepository repositories.PersonalAccessTokenRepositoryInterface
+	userSettingRepository         repositories.UserSettingRepositoryInterface
+	followRepository              repositories.FollowRepositoryInterface
+	eventAttendanceRepository     repositories.EventAttendanceRepositoryInterface
+	auditLogRepository            repositories.AuditLogRepositoryInterface
+	erasureReceiptRepository      repositories.ErasureReceiptRepositoryInterface
+	metaService                   MetaServiceInterface
+}
+
+func NewErasureService(
+	userRepository repositories.UserRepositoryInterface,
+	recordRepository repositories.RecordRepositoryInterface,
+	gameRepository repositories.GameRepositoryInterface,
+	battleRepository repositories.BattleRepositoryInterface,
+	deckRepository repositories.DeckRepositoryInterface,
+	personalAccessTokenRepository repositories.PersonalAccessTokenRepositoryInterface,
+	userSettingRepository repositories.UserSettingRepositoryInterface,
+	followRepository repositories.FollowRepositoryInterface,
+	eventAttendanceRepository repositories.EventAttendanceRepositoryInterface,
+	auditLogRepository repositories.AuditLogRepositoryInterface,
+	erasureReceiptRepository repositories.ErasureReceiptRepositoryInterface,
+	metaService MetaServiceInterface,
+) ErasureServiceInterface {
+	return &ErasureService{
+		userRepository,
+		recordRepository,
+		gameRepository,
+		battleRepository,
+		deckRepository,
+		personalAccessTokenRepository,
+		userSettingRepository,
+		followRepository,
+		eventAttendanceRepository,
+		auditLogRepository,
+		erasureReceiptRepository,
+		metaService,
+	}
+}
+
+func createErasureReceiptModel(dao *daos.ErasureReceipt) *models.ErasureReceipt {
+	model := &models.ErasureReceipt{}
+	model.ID = dao.ID
+	model.CreatedAt = dao.CreatedAt
+	model.UIDHash = dao.UIDHash
+	model.Records = dao.Records
+	model.Games = dao.Games
+	model.Battles = dao.Battles
+	model.Decks = dao.Decks
+	model.PersonalAccessTokens = dao.PersonalAccessTokens
+	model.Users = dao.Users
+	model.UserSettings = dao.UserSettings
+	model.Follows = dao.Follows
+	model.EventAttendances = dao.EventAttendances
+	model.ScrubbedGames = dao.ScrubbedGames
+	model.AnonymizedAuditLogs = dao.AnonymizedAuditLogs
+
+	return model
+}
+
+// 削除したユーザ本人がUIDから受領証を照合できるようにUIDのハッシュ値を残す
+func hashUID(uid string) string {
+	sum := sha256.Sum256([]byte(uid))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateErasureRandom() (string, error) {
+	b := make([]byte, ERASURE_RANDOM_BYTES)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// 秘密の値そのものは保存せずハッシュ値のみを保存する
+func hashErasureReceiptSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *ErasureService) FindReceiptById(
+	ctx context.Context,
+	id string,
+	secret string,
+) (*models.ErasureReceipt, error) {
+	ctx, span := tracer.Start(ctx, "ErasureService.FindReceiptById")
+	defer span.End()
+
+	dao, err := s.erasureReceiptRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 秘密の値を持たない以前の受領証は管理者のみが参照できる
+	if secret == "" || dao.SecretHash == "" ||
+		subtle.ConstantTimeCompare([]byte(hashErasureReceiptSecret(secret)), []byte(dao.SecretHash)) != 1 {
+		return nil, ErrInvalidErasureReceiptSecret
+	}
+
+	return createErasureReceiptModel(dao), nil
+}
+
+func (s *ErasureService) FindReceiptByIdAsAdministrator(
+	ctx context.Context,
+	id string,
+	uid string,
+) (*models.ErasureReceipt, error) {
+	ctx, span := tracer.Start(ctx, "ErasureService.FindReceiptByIdAsAdministrator")
+	defer span.End()
+
+	dao, err := s.erasureReceiptRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_ERASURE_RECEIPT, id, AUDIT_ACTION_VIEW, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return createErasureReceiptModel(dao), nil
+}
+
+// 指定されたUIDが所有する全てのデータを1つのトランザクションで削除する
+func (s *ErasureService) Erase(
+	ctx context.Context,
+	uid string,
+) (*models.ErasureReceipt, error) {
+	ctx, span := tracer.Start(ctx, "ErasureService.Erase")
+	defer span.End()
+
+	// UIDが空の場合は全件が対象になってしまうため拒否する
+	if uid == "" {
+		return nil, errors.New("uid is required")
+	}
+
+	id, err := generateId()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateErasureRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	// UIDから求められないように、監査ログには乱数の仮名を残す
+	pseudonym, err := generateErasureRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	dao := daos.ErasureReceipt{
+		ID:         id,
+		UIDHash:    hashUID(uid),
+		SecretHash: hashErasureReceiptSecret(secret),
+	}
+
+	// 削除した対戦が環境レポートに残らないように、同じトランザクションで集計し直す
+	if err := s.metaService.ReaggregateUser(ctx, uid, func(ctx context.Context) error {
+		var err error
+
+		// 所有していたエンティティについての監査ログを特定するため、削除する前に匿名化する
+		if dao.AnonymizedAuditLogs, err = s.auditLogRepository.AnonymizeByUID(ctx, uid, ERASED_USER_PSEUDONYM_PREFIX+pseudonym, map[string]any{
+			AUDIT_ENTITY_RECORD: &daos.Record{},
+			AUDIT_ENTITY_GAME:   &daos.Game{},
+			AUDIT_ENTITY_BATTLE: &daos.Battle{},
+			AUDIT_ENTITY_DECK:   &daos.Deck{},
+		}); err != nil {
+			return err
+		}
+
+		if dao.Battles, err = s.battleRepository.DeleteAllByUID(ctx, uid); err != nil {
+			return err
+		}
+
+		if dao.Games, err = s.gameRepository.DeleteAllByUID(ctx, uid); err != nil {
+			return err
+		}
+
+		if dao.Records, err = s.recordRepository.DeleteAllByUID(ctx, uid); err != nil {
+			return err
+		}
+
+		if dao.Decks, err = s.deckRepository.DeleteAllByUID(ctx, uid); err != nil {
+			return err
+		}
+
+		if dao.PersonalAccessTokens, err = s.personalAccessTokenRepository.DeleteAllByUID(ctx, uid); err != nil {
+			return err
+		}
+
+		if dao.Users, err = s.userRepository.DeleteByUID(ctx, uid); err != nil {
+			return err
+		}
+
+		if dao.UserSettings, err = s.userSettingRepository.DeleteByUID(ctx, uid); err != nil {
+			return err
+		}
+
+		if dao.Follows, err = s.followRepository.DeleteAllByUID(ctx, uid); err != nil {
+			return err
+		}
+
+		if dao.EventAttendances, err = s.eventAttendanceRepository.DeleteAllByUID(ctx, uid); err != nil {
+			return err
+		}
+
+		if dao.ScrubbedGames, err = s.gameRepository.ScrubOpponentsUserId(ctx, uid); err != nil {
+			return err
+		}
+
+		return s.erasureReceiptRepository.Create(ctx, &dao)
+	}); err != nil {
+		return nil, err
+	}
+
+	// 削除したユーザが取り込み直されないように、取り込み元のユーザも削除する
+	// 失敗した場合は、削除をやり直せるようにエラーを返す(既に削除したデータは0件として数えられる)
+	if err := s.userRepository.DeleteProvidedUser(ctx, uid); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "erased user data",
+		slog.String("uid", uid),
+		slog.String("receipt_id", dao.ID),
+	)
+
+	receipt := createErasureReceiptModel(&dao)
+	receipt.Secret = secret
+
+	return receipt, nil
+}
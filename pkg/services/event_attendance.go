@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/logging"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	ATTENDANCE_STATUS_PLANNED  = "planned"
+	ATTENDANCE_STATUS_ENTERED  = "entered"
+	ATTENDANCE_STATUS_ATTENDED = "attended"
+
+	// 開催日を迎えたRecordを一度に作成する件数
+	DUE_RECORD_BATCH_SIZE = 500
+)
+
+var (
+	ErrInvalidAttendanceStatus = errors.New("invalid attendance status")
+
+	// 他のサーバが先にRecordを作成した
+	errAttendanceRecordExists = errors.New("record already exists")
+)
+
+func IsValidAttendanceStatus(status string) bool {
+	switch status {
+	case ATTENDANCE_STATUS_PLANNED, ATTENDANCE_STATUS_ENTERED, ATTENDANCE_STATUS_ATTENDED:
+		return true
+	default:
+		return false
+	}
+}
+
+type EventAttendanceServiceInterface interface {
+	FindByUID(
+		ctx context.Context,
+		uid string,
+		status string,
+		startDate time.Time,
+		endDate time.Time,
+	) ([]*models.EventAttendance, error)
+
+	Save(
+		ctx context.Context,
+		officialEventId uint,
+		uid string,
+		dto *dtos.EventAttendance,
+	) (*models.EventAttendance, error)
+
+	Delete(
+		ctx context.Context,
+		officialEventId uint,
+		uid string,
+	) error
+
+	// 参加予定・エントリー済みの大会の開催日を迎えたら空のRecordを作成し、作成した件数を返す
+	CreateDueRecords(
+		ctx context.Context,
+		now time.Time,
+	) (int, error)
+}
+
+type EventAttendanceService struct {
+	transactionRepository     repositories.TransactionRepositoryInterface
+	eventAttendanceRepository repositories.EventAttendanceRepositoryInterface
+	officialEventRepository   repositories.OfficialEventRepositoryInterface
+	recordRepository          repositories.RecordRepositoryInterface
+	recordService             RecordServiceInterface
+	auditLogRepository        repositories.AuditLogRepositoryInterface
+}
+
+func NewEventAttendanceService(
+	transactionRepository repositories.TransactionRepositoryInterface,
+	eventAttendanceRepository repositories.EventAttendanceRepositoryInterface,
+	officialEventRepository repositories.OfficialEventRepositoryInterface,
+	recordRepository repositories.RecordRepositoryInterface,
+	recordService RecordServiceInterface,
+	auditLogRepository repositories.AuditLogRepositoryInterface,
+) EventAttendanceServiceInterface {
+	return &EventAttendanceService{
+		transactionRepository,
+		eventAttendanceRepository,
+		officialEventRepository,
+		recordRepository,
+		recordService,
+		auditLogRepository,
+	}
+}
+
+func createEventAttendanceModel(dao *daos.EventAttendance, officialEvent *oem.OfficialEvent) *models.EventAttendance {
+	model := &models.EventAttendance{}
+	model.UserId = dao.UserId
+	model.OfficialEventId = dao.OfficialEventId
+	model.CreatedAt = dao.CreatedAt
+	model.UpdatedAt = dao.UpdatedAt
+	model.Status = dao.Status
+	model.RecordId = dao.RecordId
+	model.OfficialEvent = officialEvent
+
+	return model
+}
+
+func (s *EventAttendanceService) FindByUID(
+	ctx context.Context,
+	uid string,
+	status string,
+	startDate time.Time,
+	endDate time.Time,
+) ([]*models.EventAttendance, error) {
+	ctx, span := tracer.Start(ctx, "EventAttendanceService.FindByUID")
+	defer span.End()
+
+	if status != "" && !IsValidAttendanceStatus(status) {
+		return nil, ErrInvalidAttendanceStatus
+	}
+
+	daos, err := s.eventAttendanceRepository.FindByUID(ctx, uid, status, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []uint{}
+	for _, dao := range daos {
+		ids = append(ids, dao.OfficialEventId)
+	}
+
+	officialEvents, err := s.officialEventRepository.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	officialEventMap := map[uint]*oem.OfficialEvent{}
+	for _, officialEvent := range officialEvents {
+		officialEventMap[officialEvent.Id] = officialEvent
+	}
+
+	attendances := []*models.EventAttendance{}
+	for _, dao := range daos {
+		attendances = append(attendances, createEventAttendanceModel(dao, officialEventMap[dao.OfficialEventId]))
+	}
+
+	return attendances, nil
+}
+
+func (s *EventAttendanceService) Save(
+	ctx context.Context,
+	officialEventId uint,
+	uid string,
+	dto *dtos.EventAttendance,
+) (*models.EventAttendance, error) {
+	ctx, span := tracer.Start(ctx, "EventAttendanceService.Save")
+	defer span.End()
+
+	if !IsValidAttendanceStatus(dto.Status) {
+		return nil, ErrInvalidAttendanceStatus
+	}
+
+	// 指定されたofficialEventIdのOfficialEventが存在するか確認
+	officialEvent, err := s.officialEventRepository.FindById(ctx, officialEventId)
+	if err != nil {
+		return nil, err
+	}
+
+	dao, err := s.eventAttendanceRepository.FindById(ctx, uid, officialEventId)
+	if err != nil {
+		return nil, err
+	}
+
+	var before *models.EventAttendance
+	if !dao.CreatedAt.IsZero() {
+		before = createEventAttendanceModel(dao, nil)
+	}
+
+	dao.Status = dto.Status
+
+	action := AUDIT_ACTION_UPDATE
+	if before == nil {
+		action = AUDIT_ACTION_CREATE
+	}
+
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.eventAttendanceRepository.Save(ctx, dao); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_EVENT_ATTENDANCE, strconv.FormatUint(uint64(officialEventId), 10), action, before, createEventAttendanceModel(dao, nil))
+	}); err != nil {
+		return nil, err
+	}
+
+	return createEventAttendanceModel(dao, officialEvent), nil
+}
+
+// 作成済みのRecordは削除しない
+func (s *EventAttendanceService) Delete(
+	ctx context.Context,
+	officialEventId uint,
+	uid string,
+) error {
+	ctx, span := tracer.Start(ctx, "EventAttendanceService.Delete")
+	defer span.End()
+
+	dao, err := s.eventAttendanceRepository.FindById(ctx, uid, officialEventId)
+	if err != nil {
+		return err
+	}
+
+	// 未作成の場合は何もしない
+	if dao.CreatedAt.IsZero() {
+		return nil
+	}
+
+	return s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.eventAttendanceRepository.Delete(ctx, uid, officialEventId); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_EVENT_ATTENDANCE, strconv.FormatUint(uint64(officialEventId), 10), AUDIT_ACTION_DELETE, createEventAttendanceModel(dao, nil), nil)
+	})
+}
+
+// 作成に失敗したものは次回の実行で再び対象になる
+func (s *EventAttendanceService) CreateDueRecords(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+	ctx, span := tracer.Start(ctx, "EventAttendanceService.CreateDueRecords")
+	defer span.End()
+
+	daos, err := s.eventAttendanceRepository.FindDue(
+		ctx,
+		[]string{ATTENDANCE_STATUS_PLANNED, ATTENDANCE_STATUS_ENTERED},
+		now,
+		DUE_RECORD_BATCH_SIZE,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	logger := logging.FromContext(ctx)
+
+	created := 0
+	for _, dao := range daos {
+		if err := s.createDueRecord(ctx, dao); errors.Is(err, errAttendanceRecordExists) {
+			continue
+		} else if err != nil {
+			logger.Error("failed to create record for event attendance",
+				slog.String("uid", dao.UserId),
+				slog.Uint64("official_event_id", uint64(dao.OfficialEventId)),
+				slog.Any("error", err),
+			)
+			continue
+		}
+
+		created++
+	}
+
+	if created != 0 {
+		logger.Info("created records for event attendances", slog.Int("created", created))
+	}
+
+	return created, nil
+}
+
+// 既にユーザがその大会のRecordを作成している場合は新たに作成せずに紐付ける
+func (s *EventAttendanceService) createDueRecord(
+	ctx context.Context,
+	dao *daos.EventAttendance,
+) error {
+	return s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		recordId := ""
+
+		records, err := s.recordRepository.FindByOfficialEventId(ctx, dao.OfficialEventId)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if record.UserId == dao.UserId {
+				recordId = record.ID
+				break
+			}
+		}
+
+		if recordId == "" {
+			record, err := s.recordService.Create(ctx, dao.UserId, &dtos.Record{OfficialEventId: dao.OfficialEventId})
+			if err != nil {
+				return err
+			}
+
+			recordId = record.ID
+		}
+
+		ok, err := s.eventAttendanceRepository.SetRecordId(ctx, dao.UserId, dao.OfficialEventId, recordId)
+		if err != nil {
+			return err
+		} else if !ok {
+			return errAttendanceRecordExists
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// 一度に読み込む件数
+	EXPORT_PAGE_SIZE = 500
+
+	EXPORT_FORMAT_JSON = "json"
+	EXPORT_FORMAT_CSV  = "csv"
+)
+
+var (
+	ErrInvalidExportFormat = errors.New("invalid export format")
+)
+
+// エクスポートするZIPの各ファイルの書き出し先
+// 全件をメモリに載せないように、Decks・Records・Games・BattlesはEXPORT_PAGE_SIZEごとに
+// WriteProfile、WriteDecks、WriteRecords、WriteGames、WriteBattlesの順に呼び出す(0件でも1回は呼び出す)
+type exportWriter interface {
+	WriteProfile(profile *models.ExportProfile) error
+	WriteDecks(decks []*models.Deck) error
+	WriteRecords(records []*models.ExportRecord) error
+	WriteGames(games []*models.Game) error
+	WriteBattles(battles []*models.Battle) error
+	Close() error
+}
+
+type ExportServiceInterface interface {
+	// uidのユーザのデータをformatの形式のファイルにまとめたZIPをwに書き出す
+	// formatが不正な場合はwに書き込む前にErrInvalidExportFormatを返す
+	Export(
+		ctx context.Context,
+		uid string,
+		format string,
+		w io.Writer,
+	) error
+}
+
+type ExportService struct {
+	userRepository            repositories.UserRepositoryInterface
+	userSettingRepository     repositories.UserSettingRepositoryInterface
+	followRepository          repositories.FollowRepositoryInterface
+	eventAttendanceRepository repositories.EventAttendanceRepositoryInterface
+	deckRepository            repositories.DeckRepositoryInterface
+	recordRepository          repositories.RecordRepositoryInterface
+	gameRepository            repositories.GameRepositoryInterface
+	battleRepository          repositories.BattleRepositoryInterface
+	officialEventRepository   repositories.OfficialEventRepositoryInterface
+}
+
+func NewExportService(
+	userRepository repositories.UserRepositoryInterface,
+	userSettingRepository repositories.UserSettingRepositoryInterface,
+	followRepository repositories.FollowRepositoryInterface,
+	eventAttendanceRepository repositories.EventAttendanceRepositoryInterface,
+	deckRepository repositories.DeckRepositoryInterface,
+	recordRepository repositories.RecordRepositoryInterface,
+	gameRepository repositories.GameRepositoryInterface,
+	battleRepository repositories.BattleRepositoryInterface,
+	officialEventRepository repositories.OfficialEventRepositoryInterface,
+) ExportServiceInterface {
+	return &ExportService{
+		userRepository,
+		userSettingRepository,
+		followRepository,
+		eventAttendanceRepository,
+		deckRepository,
+		recordRepository,
+		gameRepository,
+		battleRepository,
+		officialEventRepository,
+	}
+}
+
+func (s *ExportService) findOfficialEventMap(
+	ctx context.Context,
+	ids []uint,
+) (map[uint]*oem.OfficialEvent, error) {
+	officialEvents, err := s.officialEventRepository.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	officialEventMap := map[uint]*oem.OfficialEvent{}
+	for _, officialEvent := range officialEvents {
+		officialEventMap[officialEvent.Id] = officialEvent
+	}
+
+	return officialEventMap, nil
+}
+
+func (s *ExportService) findProfile(
+	ctx context.Context,
+	uid string,
+) (*models.ExportProfile, error) {
+	profile := &models.ExportProfile{
+		Follows:     []*models.ExportFollow{},
+		Attendances: []*models.EventAttendance{},
+	}
+
+	user, err := s.userRepository.FindById(ctx, uid)
+	if err == nil {
+		profile.User = createUserModel(user)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	setting, err := s.userSettingRepository.FindByUID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	profile.Setting = createUserSettingModel(setting)
+
+	follows, err := s.followRepository.FindByUID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, follow := range follows {
+		profile.Follows = append(profile.Follows, &models.ExportFollow{
+			FolloweeId: follow.FolloweeId,
+			CreatedAt:  follow.CreatedAt,
+		})
+	}
+
+	attendances, err := s.eventAttendanceRepository.FindByUID(ctx, uid, "", time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	officialEventIds := []uint{}
+	for _, attendance := range attendances {
+		officialEventIds = append(officialEventIds, attendance.OfficialEventId)
+	}
+
+	officialEventMap, err := s.findOfficialEventMap(ctx, officialEventIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attendance := range attendances {
+		profile.Attendances = append(profile.Attendances, createEventAttendanceModel(attendance, officialEventMap[attendance.OfficialEventId]))
+	}
+
+	return profile, nil
+}
+
+func (s *ExportService) exportDecks(
+	ctx context.Context,
+	uid string,
+	w exportWriter,
+) error {
+	for afterId := ""; ; {
+		daos, err := s.deckRepository.FindPageByUID(ctx, uid, afterId, EXPORT_PAGE_SIZE)
+		if err != nil {
+			return err
+		}
+
+		decks := []*models.Deck{}
+		for _, dao := range daos {
+			decks = append(decks, createDeckModel(dao))
+		}
+
+		if err := w.WriteDecks(decks); err != nil {
+			return err
+		}
+
+		if len(daos) < EXPORT_PAGE_SIZE {
+			return nil
+		}
+
+		afterId = daos[len(daos)-1].ID
+	}
+}
+
+func (s *ExportService) exportRecords(
+	ctx context.Context,
+	uid string,
+	w exportWriter,
+) error {
+	for afterId := ""; ; {
+		daos, err := s.recordRepository.FindPageByUID(ctx, uid, afterId, EXPORT_PAGE_SIZE)
+		if err != nil {
+			return err
+		}
+
+		// ページ内のRecordのOfficialEventはまとめて取得する
+		officialEventIds := []uint{}
+		for _, dao := range daos {
+			officialEventIds = append(officialEventIds, dao.OfficialEventId)
+		}
+
+		officialEventMap, err := s.findOfficialEventMap(ctx, officialEventIds)
+		if err != nil {
+			return err
+		}
+
+		records := []*models.ExportRecord{}
+		for _, dao := range daos {
+			records = append(records, &models.ExportRecord{
+				Record:        *createRecordModel(dao),
+				OfficialEvent: officialEventMap[dao.OfficialEventId],
+			})
+		}
+
+		if err := w.WriteRecords(records); err != nil {
+			return err
+		}
+
+		if len(daos) < EXPORT_PAGE_SIZE {
+			return nil
+		}
+
+		afterId = daos[len(daos)-1].ID
+	}
+}
+
+func (s *ExportService) exportGames(
+	ctx context.Context,
+	uid string,
+	w exportWriter,
+) error {
+	for afterId := ""; ; {
+		daos, err := s.gameRepository.FindPageByUID(ctx, uid, afterId, EXPORT_PAGE_SIZE)
+		if err != nil {
+			return err
+		}
+
+		games := []*models.Game{}
+		for _, dao := range daos {
+			games = append(games, createGameModel(dao))
+		}
+
+		if err := w.WriteGames(games); err != nil {
+			return err
+		}
+
+		if len(daos) < EXPORT_PAGE_SIZE {
+			return nil
+		}
+
+		afterId = daos[len(daos)-1].ID
+	}
+}
+
+func (s *ExportService) exportBattles(
+	ctx context.Context,
+	uid string,
+	w exportWriter,
+) error {
+	for afterId := ""; ; {
+		daos, err := s.battleRepository.FindPageByUID(ctx, uid, afterId, EXPORT_PAGE_SIZE)
+		if err != nil {
+			return err
+		}
+
+		battles := []*models.Battle{}
+		for _, dao := range daos {
+			battles = append(battles, createBattleModel(dao))
+		}
+
+		if err := w.WriteBattles(battles); err != nil {
+			return err
+		}
+
+		if len(daos) < EXPORT_PAGE_SIZE {
+			return nil
+		}
+
+		afterId = daos[len(daos)-1].ID
+	}
+}
+
+// formatが空の場合はJSONで書き出す
+func (s *ExportService) Export(
+	ctx context.Context,
+	uid string,
+	format string,
+	out io.Writer,
+) error {
+	ctx, span := tracer.Start(ctx, "ExportService.Export")
+	defer span.End()
+
+	archive := &exportArchive{zw: zip.NewWriter(out)}
+
+	var w exportWriter
+	switch format {
+	case "", EXPORT_FORMAT_JSON:
+		w = &exportJSONWriter{exportArchive: archive}
+	case EXPORT_FORMAT_CSV:
+		w = &exportCSVWriter{exportArchive: archive}
+	default:
+		return ErrInvalidExportFormat
+	}
+
+	profile, err := s.findProfile(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	if err := w.WriteProfile(profile); err != nil {
+		return err
+	}
+
+	if err := s.exportDecks(ctx, uid, w); err != nil {
+		return err
+	}
+
+	if err := s.exportRecords(ctx, uid, w); err != nil {
+		return err
+	}
+
+	if err := s.exportGames(ctx, uid, w); err != nil {
+		return err
+	}
+
+	if err := s.exportBattles(ctx, uid, w); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,515 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	// 表計算ソフトで数式として解釈される先頭の文字
+	CSV_FORMULA_PREFIXES = "=+-@\t\r"
+)
+
+var (
+	// ヘッダを先に書き出すため、OfficialEventの列は型から決める
+	officialEventCSVKeys = sortedJSONKeys(reflect.TypeOf(oem.OfficialEvent{}))
+)
+
+// ZIPのエントリは1つずつ順に書き出す
+type exportArchive struct {
+	zw   *zip.Writer
+	name string
+}
+
+func (a *exportArchive) create(name string) (io.Writer, error) {
+	a.name = name
+
+	return a.zw.Create(name)
+}
+
+func (a *exportArchive) close() error {
+	return a.zw.Close()
+}
+
+// 配列はページごとに要素を追記し、ファイルを切り替えるときに閉じる
+type exportJSONWriter struct {
+	*exportArchive
+	w     io.Writer
+	count int
+}
+
+func (w *exportJSONWriter) endArray() error {
+	if w.w == nil {
+		return nil
+	}
+
+	end := "]\n"
+	if w.count > 0 {
+		end = "\n]\n"
+	}
+
+	_, err := io.WriteString(w.w, end)
+	w.w = nil
+
+	return err
+}
+
+func writeJSONArray[T any](w *exportJSONWriter, name string, values []*T) error {
+	if w.w == nil || w.name != name {
+		if err := w.endArray(); err != nil {
+			return err
+		}
+
+		fw, err := w.create(name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(fw, "["); err != nil {
+			return err
+		}
+
+		w.w = fw
+		w.count = 0
+	}
+
+	for _, value := range values {
+		b, err := json.MarshalIndent(value, "  ", "  ")
+		if err != nil {
+			return err
+		}
+
+		separator := ",\n  "
+		if w.count == 0 {
+			separator = "\n  "
+		}
+
+		if _, err := io.WriteString(w.w, separator); err != nil {
+			return err
+		}
+
+		if _, err := w.w.Write(b); err != nil {
+			return err
+		}
+
+		w.count++
+	}
+
+	return nil
+}
+
+func (w *exportJSONWriter) WriteProfile(profile *models.ExportProfile) error {
+	fw, err := w.create("profile.json")
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(fw)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(profile)
+}
+
+func (w *exportJSONWriter) WriteDecks(decks []*models.Deck) error {
+	return writeJSONArray(w, "decks.json", decks)
+}
+
+func (w *exportJSONWriter) WriteRecords(records []*models.ExportRecord) error {
+	return writeJSONArray(w, "records.json", records)
+}
+
+func (w *exportJSONWriter) WriteGames(games []*models.Game) error {
+	return writeJSONArray(w, "games.json", games)
+}
+
+func (w *exportJSONWriter) WriteBattles(battles []*models.Battle) error {
+	return writeJSONArray(w, "battles.json", battles)
+}
+
+func (w *exportJSONWriter) Close() error {
+	if err := w.endArray(); err != nil {
+		return err
+	}
+
+	return w.close()
+}
+
+func formatCSVTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+// 表計算ソフトで開いたときに数式として実行されないように、先頭に'を付けて文字列として扱わせる
+func escapeCSVCell(cell string) string {
+	if cell != "" && strings.ContainsRune(CSV_FORMULA_PREFIXES, rune(cell[0])) {
+		return "'" + cell
+	}
+
+	return cell
+}
+
+type exportCSVWriter struct {
+	*exportArchive
+	cw *csv.Writer
+}
+
+func (w *exportCSVWriter) flush() error {
+	if w.cw == nil {
+		return nil
+	}
+
+	w.cw.Flush()
+	err := w.cw.Error()
+	w.cw = nil
+
+	return err
+}
+
+// nameのファイルを書き出し中でなければ、ファイルを作成してヘッダを書き出す
+func (w *exportCSVWriter) open(name string, header []string) error {
+	if w.cw != nil && w.name == name {
+		return nil
+	}
+
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	fw, err := w.create(name)
+	if err != nil {
+		return err
+	}
+
+	// Excelで開いたときに文字化けしないようにBOMを付ける
+	if _, err := io.WriteString(fw, "\ufeff"); err != nil {
+		return err
+	}
+
+	w.cw = csv.NewWriter(fw)
+
+	return w.cw.Write(header)
+}
+
+func (w *exportCSVWriter) write(row []string) error {
+	for i, cell := range row {
+		row[i] = escapeCSVCell(cell)
+	}
+
+	return w.cw.Write(row)
+}
+
+// JSONのキーを埋め込まれた構造体も含めて昇順に返す
+func sortedJSONKeys(t reflect.Type) []string {
+	keys := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			keys = append(keys, sortedJSONKeys(field.Type)...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		keys = append(keys, name)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// OfficialEventはフィールドをそのまま列に展開する
+func flattenOfficialEvent(officialEvent *oem.OfficialEvent) (map[string]string, error) {
+	ret := map[string]string{}
+	if officialEvent == nil {
+		return ret, nil
+	}
+
+	b, err := json.Marshal(officialEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]any{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	for key, v := range m {
+		switch v := v.(type) {
+		case nil:
+			ret[key] = ""
+		case string:
+			ret[key] = v
+		default:
+			ret[key] = fmt.Sprint(v)
+		}
+	}
+
+	return ret, nil
+}
+
+func officialEventCSVHeader(header []string) []string {
+	for _, key := range officialEventCSVKeys {
+		header = append(header, "official_event_"+key)
+	}
+
+	return header
+}
+
+func officialEventCSVRow(row []string, officialEvent *oem.OfficialEvent) ([]string, error) {
+	m, err := flattenOfficialEvent(officialEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range officialEventCSVKeys {
+		row = append(row, m[key])
+	}
+
+	return row, nil
+}
+
+func (w *exportCSVWriter) WriteProfile(profile *models.ExportProfile) error {
+	user := profile.User
+	if user == nil {
+		user = &models.User{}
+	}
+
+	if err := w.open("profile.csv", []string{
+		"uid",
+		"display_name",
+		"photo_url",
+		"ptcgl_name",
+		"player_id",
+		"prefecture",
+		"bio",
+		"favorite_deck_id",
+		"default_visibility",
+		"default_memo_visibility",
+		"time_zone",
+	}); err != nil {
+		return err
+	}
+
+	if err := w.write([]string{
+		user.UID,
+		user.DisplayName,
+		user.PhotoURL,
+		user.PTCGLName,
+		user.PlayerId,
+		user.Prefecture,
+		user.Bio,
+		user.FavoriteDeckId,
+		profile.Setting.DefaultVisibility,
+		profile.Setting.DefaultMemoVisibility,
+		profile.Setting.TimeZone,
+	}); err != nil {
+		return err
+	}
+
+	if err := w.open("follows.csv", []string{
+		"followee_id",
+		"created_at",
+	}); err != nil {
+		return err
+	}
+
+	for _, follow := range profile.Follows {
+		if err := w.write([]string{
+			follow.FolloweeId,
+			formatCSVTime(follow.CreatedAt),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := w.open("attendances.csv", officialEventCSVHeader([]string{
+		"official_event_id",
+		"created_at",
+		"updated_at",
+		"status",
+		"record_id",
+	})); err != nil {
+		return err
+	}
+
+	for _, attendance := range profile.Attendances {
+		row, err := officialEventCSVRow([]string{
+			strconv.FormatUint(uint64(attendance.OfficialEventId), 10),
+			formatCSVTime(attendance.CreatedAt),
+			formatCSVTime(attendance.UpdatedAt),
+			attendance.Status,
+			attendance.RecordId,
+		}, attendance.OfficialEvent)
+		if err != nil {
+			return err
+		}
+
+		if err := w.write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *exportCSVWriter) WriteDecks(decks []*models.Deck) error {
+	if err := w.open("decks.csv", []string{
+		"id",
+		"created_at",
+		"updated_at",
+		"name",
+		"code",
+		"private_code_flg",
+	}); err != nil {
+		return err
+	}
+
+	for _, deck := range decks {
+		if err := w.write([]string{
+			deck.ID,
+			formatCSVTime(deck.CreatedAt),
+			formatCSVTime(deck.UpdatedAt),
+			deck.Name,
+			deck.Code,
+			strconv.FormatBool(deck.PrivateCodeFlg),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *exportCSVWriter) WriteRecords(records []*models.ExportRecord) error {
+	if err := w.open("records.csv", officialEventCSVHeader([]string{
+		"id",
+		"created_at",
+		"updated_at",
+		"official_event_id",
+		"deck_id",
+	})); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		row, err := officialEventCSVRow([]string{
+			record.ID,
+			formatCSVTime(record.CreatedAt),
+			formatCSVTime(record.UpdatedAt),
+			strconv.FormatUint(uint64(record.OfficialEventId), 10),
+			record.DeckId,
+		}, record.OfficialEvent)
+		if err != nil {
+			return err
+		}
+
+		if err := w.write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *exportCSVWriter) WriteGames(games []*models.Game) error {
+	if err := w.open("games.csv", []string{
+		"id",
+		"created_at",
+		"updated_at",
+		"record_id",
+		"opponents_user_id",
+		"bo3_flg",
+		"qualifying_round_flg",
+		"final_tournament_flg",
+		"victory_flg",
+		"opponents_deck_info",
+		"memo",
+	}); err != nil {
+		return err
+	}
+
+	for _, game := range games {
+		if err := w.write([]string{
+			game.ID,
+			formatCSVTime(game.CreatedAt),
+			formatCSVTime(game.UpdatedAt),
+			game.RecordId,
+			game.OpponentsUserId,
+			strconv.FormatBool(game.BO3Flg),
+			strconv.FormatBool(game.QualifyingRoundFlg),
+			strconv.FormatBool(game.FinalTournamentFlg),
+			strconv.FormatBool(game.VictoryFlg),
+			game.OpponentsDeckInfo,
+			game.Memo,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *exportCSVWriter) WriteBattles(battles []*models.Battle) error {
+	if err := w.open("battles.csv", []string{
+		"id",
+		"created_at",
+		"updated_at",
+		"game_id",
+		"go_first",
+		"victory_flg",
+		"your_prize_cards",
+		"opponents_prize_cards",
+		"memo",
+	}); err != nil {
+		return err
+	}
+
+	for _, battle := range battles {
+		if err := w.write([]string{
+			battle.ID,
+			formatCSVTime(battle.CreatedAt),
+			formatCSVTime(battle.UpdatedAt),
+			battle.GameId,
+			strconv.FormatBool(battle.GoFirst),
+			strconv.FormatBool(battle.VictoryFlg),
+			strconv.FormatUint(uint64(battle.YourPrizeCards), 10),
+			strconv.FormatUint(uint64(battle.OpponentsPrizeCards), 10),
+			battle.Memo,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *exportCSVWriter) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	return w.close()
+}
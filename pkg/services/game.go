@@ -11,14 +11,16 @@ import (
 )
 
 type GameServiceInterface interface {
-	FindById(
+	FindByIdWithUID(
 		ctx context.Context,
 		id string,
+		uid string,
 	) (*models.Game, error)
 
-	FindBattleById(
+	FindBattleByIdWithUID(
 		ctx context.Context,
 		id string,
+		uid string,
 	) ([]*models.Battle, error)
 
 	Create(
@@ -42,20 +44,29 @@ type GameServiceInterface interface {
 }
 
 type GameService struct {
-	gameRepository   repositories.GameRepositoryInterface
-	recordRepository repositories.RecordRepositoryInterface
-	battleRepository repositories.BattleRepositoryInterface
+	transactionRepository repositories.TransactionRepositoryInterface
+	gameRepository        repositories.GameRepositoryInterface
+	recordRepository      repositories.RecordRepositoryInterface
+	battleRepository      repositories.BattleRepositoryInterface
+	followRepository      repositories.FollowRepositoryInterface
+	auditLogRepository    repositories.AuditLogRepositoryInterface
 }
 
 func NewGameService(
+	transactionRepository repositories.TransactionRepositoryInterface,
 	gameRepository repositories.GameRepositoryInterface,
 	recordRepository repositories.RecordRepositoryInterface,
 	battleRepository repositories.BattleRepositoryInterface,
+	followRepository repositories.FollowRepositoryInterface,
+	auditLogRepository repositories.AuditLogRepositoryInterface,
 ) GameServiceInterface {
 	return &GameService{
+		transactionRepository,
 		gameRepository,
 		recordRepository,
 		battleRepository,
+		followRepository,
+		auditLogRepository,
 	}
 }
 
@@ -78,30 +89,65 @@ func createGameModel(dao *daos.Game) *models.Game {
 	return model
 }
 
-func (s *GameService) FindById(
+// 公開範囲の判定に使うため、Gameと合わせて所属するRecordも返す
+func (s *GameService) findByIdWithRecord(
 	ctx context.Context,
 	id string,
-) (*models.Game, error) {
+) (*models.Game, *models.Record, error) {
 	dao, err := s.gameRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recordDao, err := s.recordRepository.FindById(ctx, dao.RecordId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return createGameModel(dao), createRecordModel(recordDao), nil
+}
 
+func (s *GameService) FindByIdWithUID(
+	ctx context.Context,
+	id string,
+	uid string,
+) (*models.Game, error) {
+	ctx, span := tracer.Start(ctx, "GameService.FindByIdWithUID")
+	defer span.End()
+
+	model, record, err := s.findByIdWithRecord(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	model := createGameModel(dao)
+	ok, err := newViewer(uid, s.followRepository).viewGame(ctx, record, model)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrNotVisible
+	}
 
 	return model, nil
 }
 
-func (s *GameService) FindBattleById(
+func (s *GameService) FindBattleByIdWithUID(
 	ctx context.Context,
 	id string,
+	uid string,
 ) ([]*models.Battle, error) {
+	ctx, span := tracer.Start(ctx, "GameService.FindBattleByIdWithUID")
+	defer span.End()
+
 	// 指定されたIdのGameが存在するか確認
-	if _, err := s.FindById(ctx, id); err != nil {
+	_, record, err := s.findByIdWithRecord(ctx, id)
+	if err != nil {
 		return nil, err
 	}
 
+	viewer := newViewer(uid, s.followRepository)
+
 	daos, err := s.battleRepository.FindByGameId(ctx, id)
 
 	if err != nil {
@@ -110,7 +156,18 @@ func (s *GameService) FindBattleById(
 
 	battles := []*models.Battle{}
 	for _, dao := range daos {
-		battles = append(battles, createBattleModel(dao))
+		battle := createBattleModel(dao)
+
+		ok, err := viewer.viewBattle(ctx, record, battle)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, ErrNotVisible
+		}
+
+		battles = append(battles, battle)
 	}
 
 	return battles, nil
@@ -121,6 +178,9 @@ func (s *GameService) Create(
 	uid string,
 	dto *dtos.Game,
 ) (*models.Game, error) {
+	ctx, span := tracer.Start(ctx, "GameService.Create")
+	defer span.End()
+
 	// 指定されたdto.RecordIdのRecordが存在するか確認
 	if _, err := s.recordRepository.FindById(ctx, dto.RecordId); err != nil {
 		return nil, err
@@ -144,12 +204,20 @@ func (s *GameService) Create(
 		Memo:               dto.Memo,
 	}
 
-	if err := s.gameRepository.Save(ctx, &dao); err != nil {
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.gameRepository.Save(ctx, &dao); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_GAME, dao.ID, AUDIT_ACTION_CREATE, nil, createGameModel(&dao))
+	}); err != nil {
 		return nil, err
 	}
 
 	model := createGameModel(&dao)
 
+	gamesCreatedTotal.Inc()
+
 	return model, nil
 
 }
@@ -160,6 +228,9 @@ func (s *GameService) Update(
 	uid string,
 	dto *dtos.Game,
 ) (*models.Game, error) {
+	ctx, span := tracer.Start(ctx, "GameService.Update")
+	defer span.End()
+
 	// 指定されたdto.RecordIdのRecordが存在するか確認
 	if _, err := s.recordRepository.FindById(ctx, dto.RecordId); err != nil {
 		return nil, err
@@ -176,6 +247,8 @@ func (s *GameService) Update(
 		return nil, errors.New("no authority")
 	}
 
+	before := createGameModel(dao)
+
 	dao.RecordId = dto.RecordId
 	dao.OpponentsUserId = dto.OpponentsUserId
 	dao.BO3Flg = dto.BO3Flg
@@ -185,7 +258,13 @@ func (s *GameService) Update(
 	dao.OpponentsDeckInfo = dto.OpponentsDeckInfo
 	dao.Memo = dto.Memo
 
-	if err := s.gameRepository.Save(ctx, dao); err != nil {
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.gameRepository.Save(ctx, dao); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_GAME, dao.ID, AUDIT_ACTION_UPDATE, before, createGameModel(dao))
+	}); err != nil {
 		return nil, err
 	}
 
@@ -199,6 +278,9 @@ func (s *GameService) Delete(
 	id string,
 	uid string,
 ) error {
+	ctx, span := tracer.Start(ctx, "GameService.Delete")
+	defer span.End()
+
 	// 指定されたIdのGameが存在するか確認
 	dao, err := s.gameRepository.FindById(ctx, id)
 	if err != nil {
@@ -210,5 +292,11 @@ func (s *GameService) Delete(
 		return errors.New("no authority")
 	}
 
-	return s.gameRepository.Delete(ctx, id, uid)
+	return s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.gameRepository.Delete(ctx, id, uid); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_GAME, id, AUDIT_ACTION_DELETE, createGameModel(dao), nil)
+	})
 }
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"math"
+)
+
+const (
+	EARTH_RADIUS_KM = 6371.0
+
+	// 緯度1度あたりの距離
+	KM_PER_LATITUDE_DEGREE = 111.0
+)
+
+func toRadians(degree float64) float64 {
+	return degree * math.Pi / 180
+}
+
+// 2点間の大円距離(km)
+func distanceKm(latitude1 float64, longitude1 float64, latitude2 float64, longitude2 float64) float64 {
+	dLatitude := toRadians(latitude2 - latitude1)
+	dLongitude := toRadians(longitude2 - longitude1)
+
+	a := math.Sin(dLatitude/2)*math.Sin(dLatitude/2) +
+		math.Cos(toRadians(latitude1))*math.Cos(toRadians(latitude2))*math.Sin(dLongitude/2)*math.Sin(dLongitude/2)
+
+	return 2 * EARTH_RADIUS_KM * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// 中心から半径radiusKmの円を含む緯度・経度の範囲
+// 日付変更線をまたぐ範囲は考慮せず、経度の範囲を切り詰める
+func boundingBox(latitude float64, longitude float64, radiusKm float64) (float64, float64, float64, float64) {
+	dLatitude := radiusKm / KM_PER_LATITUDE_DEGREE
+
+	dLongitude := 180.0
+	if c := math.Cos(toRadians(latitude)); c > 0.01 {
+		dLongitude = math.Min(180, radiusKm/(KM_PER_LATITUDE_DEGREE*c))
+	}
+
+	return math.Max(-90, latitude-dLatitude),
+		math.Min(90, latitude+dLatitude),
+		math.Max(-180, longitude-dLongitude),
+		math.Min(180, longitude+dLongitude)
+}
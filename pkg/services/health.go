@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	HEALTH_CHECK_OK      = "ok"
+	HEALTH_CHECK_TIMEOUT = time.Duration(2) * time.Second
+
+	// Firebaseへの問い合わせはレート制限があるため、この間隔より短い間は前回の結果を返す
+	HEALTH_CHECK_USERS_INTERVAL = time.Duration(1) * time.Minute
+
+	HEALTH_CHECK_DATABASE = "database"
+	HEALTH_CHECK_USERS    = "users"
+	HEALTH_CHECK_SCHEMA   = "schema"
+	HEALTH_CHECK_SERVER   = "server"
+)
+
+var (
+	ErrShuttingDown = errors.New("shutting down")
+)
+
+type HealthServiceInterface interface {
+	Readiness(
+		ctx context.Context,
+	) *models.Readiness
+
+	// 終了処理中はリクエストを振り分けられないようにする
+	SetShuttingDown()
+}
+
+type HealthService struct {
+	healthRepository repositories.HealthRepositoryInterface
+	userRepository   repositories.UserRepositoryInterface
+	shuttingDown     atomic.Bool
+
+	// 起動時に確認したマイグレーションのバージョン
+	schemaVersion uint
+
+	usersMutex     sync.Mutex
+	usersCheckedAt time.Time
+	usersErr       error
+	timeNowFn      func() time.Time
+}
+
+func NewHealthService(
+	healthRepository repositories.HealthRepositoryInterface,
+	userRepository repositories.UserRepositoryInterface,
+	schemaVersion uint,
+) HealthServiceInterface {
+	return &HealthService{
+		healthRepository: healthRepository,
+		userRepository:   userRepository,
+		schemaVersion:    schemaVersion,
+		timeNowFn:        time.Now,
+	}
+}
+
+// 同時に問い合わせないように、前回の結果が古い場合のみ1つのリクエストで問い合わせる
+func (s *HealthService) pingUsers(
+	ctx context.Context,
+) error {
+	s.usersMutex.Lock()
+	defer s.usersMutex.Unlock()
+
+	now := s.timeNowFn()
+	if !s.usersCheckedAt.IsZero() && now.Sub(s.usersCheckedAt) < HEALTH_CHECK_USERS_INTERVAL {
+		return s.usersErr
+	}
+
+	s.usersErr = s.userRepository.Ping(ctx)
+	s.usersCheckedAt = now
+
+	return s.usersErr
+}
+
+func (s *HealthService) Readiness(
+	ctx context.Context,
+) *models.Readiness {
+	ctx, cancel := context.WithTimeout(ctx, HEALTH_CHECK_TIMEOUT)
+	defer cancel()
+
+	ret := &models.Readiness{
+		Ready:  true,
+		Checks: map[string]string{},
+	}
+
+	check := func(name string, err error) {
+		if err != nil {
+			ret.Ready = false
+			ret.Checks[name] = err.Error()
+			return
+		}
+
+		ret.Checks[name] = HEALTH_CHECK_OK
+	}
+
+	if s.shuttingDown.Load() {
+		check(HEALTH_CHECK_SERVER, ErrShuttingDown)
+	}
+
+	check(HEALTH_CHECK_DATABASE, s.healthRepository.Ping(ctx))
+
+	// スキーマは起動時に確認しているため、リクエストごとには確認しない
+	ret.SchemaVersion = s.schemaVersion
+	check(HEALTH_CHECK_SCHEMA, nil)
+
+	check(HEALTH_CHECK_USERS, s.pingUsers(ctx))
+
+	return ret
+}
+
+func (s *HealthService) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
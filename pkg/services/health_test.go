@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+)
+
+type pingHealthRepository struct {
+	repositories.HealthRepositoryInterface
+}
+
+func (r *pingHealthRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+type pingUserRepository struct {
+	repositories.UserRepositoryInterface
+	count int
+	err   error
+}
+
+func (r *pingUserRepository) Ping(ctx context.Context) error {
+	r.count++
+	return r.err
+}
+
+func TestHealthServiceReadiness(t *testing.T) {
+	now := time.Now()
+
+	userRepository := &pingUserRepository{}
+	service := NewHealthService(&pingHealthRepository{}, userRepository, 3).(*HealthService)
+	service.timeNowFn = func() time.Time { return now }
+
+	ret := service.Readiness(context.Background())
+	require.True(t, ret.Ready)
+	require.Equal(t, uint(3), ret.SchemaVersion)
+	require.Equal(t, HEALTH_CHECK_OK, ret.Checks[HEALTH_CHECK_SCHEMA])
+	require.Equal(t, 1, userRepository.count)
+
+	// 間隔より短い間は問い合わせずに前回の結果を返す
+	userRepository.err = errors.New("unavailable")
+	now = now.Add(HEALTH_CHECK_USERS_INTERVAL - time.Second)
+	require.True(t, service.Readiness(context.Background()).Ready)
+	require.Equal(t, 1, userRepository.count)
+
+	now = now.Add(time.Second)
+	ret = service.Readiness(context.Background())
+	require.False(t, ret.Ready)
+	require.Equal(t, "unavailable", ret.Checks[HEALTH_CHECK_USERS])
+	require.Equal(t, 2, userRepository.count)
+}
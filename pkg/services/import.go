@@ -0,0 +1,502 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/logging"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	IMPORT_MAX_ROWS    = 5000
+	IMPORT_DATE_LAYOUT = "2006-01-02"
+
+	IMPORT_COLUMN_OFFICIAL_EVENT_ID     = "official_event_id"
+	IMPORT_COLUMN_EVENT_NAME            = "event_name"
+	IMPORT_COLUMN_EVENT_DATE            = "event_date"
+	IMPORT_COLUMN_DECK_NAME             = "deck_name"
+	IMPORT_COLUMN_ROUND                 = "round"
+	IMPORT_COLUMN_OPPONENT_DECK         = "opponent_deck"
+	IMPORT_COLUMN_RESULT                = "result"
+	IMPORT_COLUMN_GO_FIRST              = "go_first"
+	IMPORT_COLUMN_PRIZES                = "prizes"
+	IMPORT_COLUMN_YOUR_PRIZE_CARDS      = "your_prize_cards"
+	IMPORT_COLUMN_OPPONENTS_PRIZE_CARDS = "opponents_prize_cards"
+)
+
+var (
+	// CSVの形式や行数が不正な場合に返す(行毎の検証エラーはImportReport.Errorsで返す)
+	ErrInvalidImportFile = errors.New("invalid import file")
+)
+
+type ImportServiceInterface interface {
+	Import(
+		ctx context.Context,
+		uid string,
+		reader io.Reader,
+		dryRun bool,
+	) (*models.ImportReport, error)
+}
+
+type ImportService struct {
+	transactionRepository   repositories.TransactionRepositoryInterface
+	officialEventRepository repositories.OfficialEventRepositoryInterface
+	deckRepository          repositories.DeckRepositoryInterface
+	deckService             DeckServiceInterface
+	recordService           RecordServiceInterface
+	gameService             GameServiceInterface
+	battleService           BattleServiceInterface
+}
+
+func NewImportService(
+	transactionRepository repositories.TransactionRepositoryInterface,
+	officialEventRepository repositories.OfficialEventRepositoryInterface,
+	deckRepository repositories.DeckRepositoryInterface,
+	deckService DeckServiceInterface,
+	recordService RecordServiceInterface,
+	gameService GameServiceInterface,
+	battleService BattleServiceInterface,
+) ImportServiceInterface {
+	return &ImportService{
+		transactionRepository,
+		officialEventRepository,
+		deckRepository,
+		deckService,
+		recordService,
+		gameService,
+		battleService,
+	}
+}
+
+// CSVの1行(=1試合)
+type importRow struct {
+	line                int
+	officialEventId     uint
+	eventName           string
+	eventDate           string
+	deckName            string
+	round               string
+	opponentDeck        string
+	victoryFlg          bool
+	goFirst             bool
+	yourPrizeCards      uint
+	opponentsPrizeCards uint
+}
+
+// 同じ大会・同じラウンドの複数行はBO3の1ゲームとしてまとめる
+type importGame struct {
+	round string
+	rows  []*importRow
+}
+
+type importRecord struct {
+	officialEventId uint
+	deckName        string
+	games           []*importGame
+}
+
+func parseImportBool(value string, truthy []string, falsy []string) (bool, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+
+	for _, t := range truthy {
+		if v == t {
+			return true, nil
+		}
+	}
+
+	for _, f := range falsy {
+		if v == f {
+			return false, nil
+		}
+	}
+
+	return false, fmt.Errorf("invalid value: %s", value)
+}
+
+func parseImportUint(value string) (uint, error) {
+	v := strings.TrimSpace(value)
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", value)
+	}
+
+	return uint(n), nil
+}
+
+// 予選ラウンドは数字、決勝トーナメントは"top8"や"final"などで指定する
+func isFinalTournamentRound(round string) bool {
+	r := strings.ToLower(strings.TrimSpace(round))
+	if _, err := strconv.Atoi(r); err == nil {
+		return false
+	}
+
+	return strings.HasPrefix(r, "top") || strings.HasPrefix(r, "final") || strings.HasPrefix(r, "決勝")
+}
+
+func parseImportRow(line int, get func(column string) string) (*importRow, error) {
+	row := &importRow{
+		line:         line,
+		eventName:    strings.TrimSpace(get(IMPORT_COLUMN_EVENT_NAME)),
+		eventDate:    strings.TrimSpace(get(IMPORT_COLUMN_EVENT_DATE)),
+		deckName:     strings.TrimSpace(get(IMPORT_COLUMN_DECK_NAME)),
+		round:        strings.TrimSpace(get(IMPORT_COLUMN_ROUND)),
+		opponentDeck: strings.TrimSpace(get(IMPORT_COLUMN_OPPONENT_DECK)),
+	}
+
+	officialEventId, err := parseImportUint(get(IMPORT_COLUMN_OFFICIAL_EVENT_ID))
+	if err != nil {
+		return nil, err
+	}
+	row.officialEventId = officialEventId
+
+	if row.officialEventId == 0 && (row.eventName == "" || row.eventDate == "") {
+		return nil, errors.New("official_event_id or event_name and event_date are required")
+	}
+
+	if row.eventDate != "" {
+		if _, err := time.Parse(IMPORT_DATE_LAYOUT, row.eventDate); err != nil {
+			return nil, fmt.Errorf("invalid event_date: %s", row.eventDate)
+		}
+	}
+
+	if row.round == "" {
+		return nil, errors.New("round is required")
+	}
+
+	if row.victoryFlg, err = parseImportBool(
+		get(IMPORT_COLUMN_RESULT),
+		[]string{"win", "w", "victory", "1", "勝ち", "勝"},
+		[]string{"lose", "loss", "l", "defeat", "0", "負け", "負"},
+	); err != nil {
+		return nil, fmt.Errorf("invalid result: %s", get(IMPORT_COLUMN_RESULT))
+	}
+
+	if row.goFirst, err = parseImportBool(
+		get(IMPORT_COLUMN_GO_FIRST),
+		[]string{"true", "1", "yes", "first", "先攻"},
+		[]string{"false", "0", "no", "second", "後攻", ""},
+	); err != nil {
+		return nil, fmt.Errorf("invalid go_first: %s", get(IMPORT_COLUMN_GO_FIRST))
+	}
+
+	// prizesは"自分-相手"の形式で指定する
+	if prizes := strings.TrimSpace(get(IMPORT_COLUMN_PRIZES)); prizes != "" {
+		yours, opponents, found := strings.Cut(prizes, "-")
+		if !found {
+			return nil, fmt.Errorf("invalid prizes: %s", prizes)
+		}
+
+		if row.yourPrizeCards, err = parseImportUint(yours); err != nil {
+			return nil, fmt.Errorf("invalid prizes: %s", prizes)
+		}
+
+		if row.opponentsPrizeCards, err = parseImportUint(opponents); err != nil {
+			return nil, fmt.Errorf("invalid prizes: %s", prizes)
+		}
+	} else {
+		if row.yourPrizeCards, err = parseImportUint(get(IMPORT_COLUMN_YOUR_PRIZE_CARDS)); err != nil {
+			return nil, err
+		}
+
+		if row.opponentsPrizeCards, err = parseImportUint(get(IMPORT_COLUMN_OPPONENTS_PRIZE_CARDS)); err != nil {
+			return nil, err
+		}
+	}
+
+	return row, nil
+}
+
+func (s *ImportService) resolveOfficialEventId(
+	ctx context.Context,
+	row *importRow,
+	cache map[string]uint,
+) (uint, error) {
+	key := fmt.Sprintf("%d|%s|%s", row.officialEventId, row.eventName, row.eventDate)
+	if id, ok := cache[key]; ok {
+		return id, nil
+	}
+
+	if row.officialEventId != 0 {
+		if _, err := s.officialEventRepository.FindById(ctx, row.officialEventId); err != nil {
+			return 0, fmt.Errorf("official event not found: %d", row.officialEventId)
+		}
+
+		cache[key] = row.officialEventId
+		return row.officialEventId, nil
+	}
+
+	// 大会の日付は開催地(日本)の日付として解釈する
+	jst, err := time.LoadLocation(repositories.OFFICIAL_EVENT_TIME_ZONE)
+	if err != nil {
+		return 0, err
+	}
+
+	date, err := time.ParseInLocation(IMPORT_DATE_LAYOUT, row.eventDate, jst)
+	if err != nil {
+		return 0, err
+	}
+
+	officialEvents, err := s.officialEventRepository.FindByTitleAndDate(ctx, row.eventName, date, date.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(officialEvents) == 0 {
+		return 0, fmt.Errorf("official event not found: %s (%s)", row.eventName, row.eventDate)
+	}
+
+	if len(officialEvents) > 1 {
+		return 0, fmt.Errorf("multiple official events found: %s (%s), use official_event_id instead", row.eventName, row.eventDate)
+	}
+
+	cache[key] = officialEvents[0].Id
+	return officialEvents[0].Id, nil
+}
+
+func (s *ImportService) Import(
+	ctx context.Context,
+	uid string,
+	reader io.Reader,
+	dryRun bool,
+) (*models.ImportReport, error) {
+	ctx, span := tracer.Start(ctx, "ImportService.Import")
+	defer span.End()
+
+	report, err := s.importCSV(ctx, uid, reader, dryRun)
+	importJobsTotal.WithLabelValues(importResult(report, err)).Inc()
+
+	return report, err
+}
+
+func importResult(report *models.ImportReport, err error) string {
+	switch {
+	case err != nil:
+		return IMPORT_RESULT_FAILED
+	case len(report.Errors) != 0:
+		return IMPORT_RESULT_INVALID
+	case report.DryRun:
+		return IMPORT_RESULT_DRY_RUN
+	default:
+		return IMPORT_RESULT_COMMITTED
+	}
+}
+
+func (s *ImportService) importCSV(
+	ctx context.Context,
+	uid string,
+	reader io.Reader,
+	dryRun bool,
+) (*models.ImportReport, error) {
+	report := &models.ImportReport{
+		DryRun:    dryRun,
+		NewDecks:  []string{},
+		RecordIds: []string{},
+		Errors:    []*models.ImportError{},
+	}
+
+	r := csv.NewReader(reader)
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if err == io.EOF || errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("%w: failed to read csv header", ErrInvalidImportFile)
+		}
+
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		// 先頭のBOMを取り除く
+		column = strings.TrimPrefix(column, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	// 既存のDeckは名前で照合する
+	decks, err := s.deckRepository.FindAllByUID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	deckIds := map[string]string{}
+	for _, deck := range decks {
+		deckIds[deck.Name] = deck.ID
+	}
+
+	eventCache := map[string]uint{}
+	recordMap := map[string]*importRecord{}
+	records := []*importRecord{}
+
+	for line := 2; ; line++ {
+		values, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		// 読み込みに失敗した場合は同じエラーが返り続けるため、CSVの構文エラー以外は中断する
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.Errors = append(report.Errors, &models.ImportError{Line: line, Message: err.Error()})
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		report.Rows++
+		if report.Rows > IMPORT_MAX_ROWS {
+			return nil, fmt.Errorf("%w: too many rows: max %d", ErrInvalidImportFile, IMPORT_MAX_ROWS)
+		}
+
+		get := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(values) {
+				return values[i]
+			}
+
+			return ""
+		}
+
+		row, err := parseImportRow(line, get)
+		if err != nil {
+			report.Errors = append(report.Errors, &models.ImportError{Line: line, Message: err.Error()})
+			continue
+		}
+
+		officialEventId, err := s.resolveOfficialEventId(ctx, row, eventCache)
+		if err != nil {
+			report.Errors = append(report.Errors, &models.ImportError{Line: line, Message: err.Error()})
+			continue
+		}
+
+		if _, ok := deckIds[row.deckName]; !ok && row.deckName != "" {
+			deckIds[row.deckName] = ""
+			report.NewDecks = append(report.NewDecks, row.deckName)
+		}
+
+		recordKey := fmt.Sprintf("%d|%s", officialEventId, row.deckName)
+		record, ok := recordMap[recordKey]
+		if !ok {
+			record = &importRecord{
+				officialEventId: officialEventId,
+				deckName:        row.deckName,
+			}
+			recordMap[recordKey] = record
+			records = append(records, record)
+		}
+
+		var game *importGame
+		for _, g := range record.games {
+			if g.round == row.round {
+				game = g
+			}
+		}
+
+		if game == nil {
+			game = &importGame{round: row.round}
+			record.games = append(record.games, game)
+		}
+
+		game.rows = append(game.rows, row)
+	}
+
+	for _, record := range records {
+		report.Records++
+		for _, game := range record.games {
+			report.Games++
+			report.Battles += len(game.rows)
+		}
+	}
+
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		for _, name := range report.NewDecks {
+			deck, err := s.deckService.Create(ctx, uid, &dtos.Deck{Name: name})
+			if err != nil {
+				return err
+			}
+
+			deckIds[name] = deck.ID
+		}
+
+		for _, record := range records {
+			recordModel, err := s.recordService.Create(ctx, uid, &dtos.Record{
+				OfficialEventId: record.officialEventId,
+				DeckId:          deckIds[record.deckName],
+			})
+			if err != nil {
+				return err
+			}
+
+			report.RecordIds = append(report.RecordIds, recordModel.ID)
+
+			for _, game := range record.games {
+				// BO3の場合は2本先取した方を勝者とする
+				victories := 0
+				for _, row := range game.rows {
+					if row.victoryFlg {
+						victories++
+					}
+				}
+
+				gameModel, err := s.gameService.Create(ctx, uid, &dtos.Game{
+					RecordId:           recordModel.ID,
+					BO3Flg:             len(game.rows) > 1,
+					QualifyingRoundFlg: !isFinalTournamentRound(game.round),
+					FinalTournamentFlg: isFinalTournamentRound(game.round),
+					VictoryFlg:         victories*2 > len(game.rows),
+					OpponentsDeckInfo:  game.rows[0].opponentDeck,
+				})
+				if err != nil {
+					return err
+				}
+
+				for _, row := range game.rows {
+					if _, err := s.battleService.Create(ctx, uid, &dtos.Battle{
+						GameId:              gameModel.ID,
+						GoFirst:             row.goFirst,
+						VictoryFlg:          row.victoryFlg,
+						YourPrizeCards:      row.yourPrizeCards,
+						OpponentsPrizeCards: row.opponentsPrizeCards,
+					}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	report.Committed = true
+
+	logging.FromContext(ctx).InfoContext(ctx, "imported records",
+		slog.String("uid", uid),
+		slog.Int("records", report.Records),
+		slog.Int("games", report.Games),
+		slog.Int("battles", report.Battles),
+	)
+
+	return report, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	AUDIT_ENTITY_ID_MAINTENANCE = "maintenance"
+)
+
+// 読み取り専用モードの間は書き込みを受け付けない
+// 読み取り専用モードはデータベースに保存し、全てのインスタンスで共有する
+type MaintenanceServiceInterface interface {
+	IsReadOnly(
+		ctx context.Context,
+	) (bool, error)
+
+	// 設定ファイルのmaintenance.read_onlyを反映する
+	// 監査ログには記録しない
+	SetReadOnly(
+		ctx context.Context,
+		readOnly bool,
+	) error
+
+	Find(
+		ctx context.Context,
+	) (*models.Maintenance, error)
+
+	// 管理者による切り替えは監査ログに記録する
+	Update(
+		ctx context.Context,
+		uid string,
+		dto *dtos.Maintenance,
+	) (*models.Maintenance, error)
+}
+
+type MaintenanceService struct {
+	maintenanceSettingRepository repositories.MaintenanceSettingRepositoryInterface
+	auditLogRepository           repositories.AuditLogRepositoryInterface
+	transactionRepository        repositories.TransactionRepositoryInterface
+}
+
+func NewMaintenanceService(
+	maintenanceSettingRepository repositories.MaintenanceSettingRepositoryInterface,
+	auditLogRepository repositories.AuditLogRepositoryInterface,
+	transactionRepository repositories.TransactionRepositoryInterface,
+) MaintenanceServiceInterface {
+	return &MaintenanceService{
+		maintenanceSettingRepository,
+		auditLogRepository,
+		transactionRepository,
+	}
+}
+
+func createMaintenanceModel(dao *daos.MaintenanceSetting) *models.Maintenance {
+	return &models.Maintenance{ReadOnly: dao.ReadOnly}
+}
+
+func (s *MaintenanceService) IsReadOnly(
+	ctx context.Context,
+) (bool, error) {
+	dao, err := s.maintenanceSettingRepository.Find(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return dao.ReadOnly, nil
+}
+
+func (s *MaintenanceService) SetReadOnly(
+	ctx context.Context,
+	readOnly bool,
+) error {
+	ctx, span := tracer.Start(ctx, "MaintenanceService.SetReadOnly")
+	defer span.End()
+
+	return s.maintenanceSettingRepository.Save(ctx, &daos.MaintenanceSetting{ReadOnly: readOnly})
+}
+
+func (s *MaintenanceService) Find(
+	ctx context.Context,
+) (*models.Maintenance, error) {
+	ctx, span := tracer.Start(ctx, "MaintenanceService.Find")
+	defer span.End()
+
+	dao, err := s.maintenanceSettingRepository.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return createMaintenanceModel(dao), nil
+}
+
+func (s *MaintenanceService) Update(
+	ctx context.Context,
+	uid string,
+	dto *dtos.Maintenance,
+) (*models.Maintenance, error) {
+	ctx, span := tracer.Start(ctx, "MaintenanceService.Update")
+	defer span.End()
+
+	before, err := s.maintenanceSettingRepository.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	after := &daos.MaintenanceSetting{ReadOnly: *dto.ReadOnly}
+
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.maintenanceSettingRepository.Save(ctx, after); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_SYSTEM, AUDIT_ENTITY_ID_MAINTENANCE, AUDIT_ACTION_UPDATE, createMaintenanceModel(before), createMaintenanceModel(after))
+	}); err != nil {
+		return nil, err
+	}
+
+	return createMaintenanceModel(after), nil
+}
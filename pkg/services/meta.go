@@ -0,0 +1,466 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	META_SIDE_OWN      = "own"
+	META_SIDE_OPPONENT = "opponent"
+
+	// 直近の対戦は変更・削除を反映するため、この期間を毎回集計し直す
+	// それより前に開催された大会の対戦は、この期間に作成・変更・削除された期間のみ集計し直す
+	META_AGGREGATE_WINDOW = time.Duration(7*24) * time.Hour
+
+	META_AGGREGATE_BUCKET = time.Hour
+
+	// 環境レポートで指定できる期間の上限
+	META_MAX_DAYS = 366
+
+	// 1つの集計に載せるアーキタイプの数
+	META_ARCHETYPE_LIMIT = 50
+
+	// daos.MetaAggregateのArchetypeの長さの上限
+	META_ARCHETYPE_MAX_LENGTH = 191
+)
+
+var (
+	ErrInvalidMetaRange = errors.New("invalid date range")
+)
+
+type MetaServiceInterface interface {
+	// startDate以上endDate未満の期間を7日ごとに区切り、アーキタイプ別の使用率・勝率と前週との差分を返す
+	// regulationIdが0の場合は全てのレギュレーションを集計する
+	Find(
+		ctx context.Context,
+		startDate time.Time,
+		endDate time.Time,
+		regulationId uint,
+	) (*models.MetaReport, error)
+
+	// 直近の対戦を集計し直し、集計した対戦の数を返す
+	Aggregate(
+		ctx context.Context,
+		now time.Time,
+	) (int, error)
+
+	// fnと同じトランザクションで、recordIdsのRecordの対戦が集計される期間をfnの実行前後について集計し直す
+	// 公開範囲の変更や削除をすぐにレポートに反映するために使う
+	ReaggregateRecords(
+		ctx context.Context,
+		recordIds []string,
+		fn func(ctx context.Context) error,
+	) error
+
+	// fnと同じトランザクションで、uidのユーザの対戦が集計される期間をfnの実行前後について集計し直す
+	ReaggregateUser(
+		ctx context.Context,
+		uid string,
+		fn func(ctx context.Context) error,
+	) error
+
+	// 全ての対戦を集計し直し、集計した対戦の数を返す
+	Rebuild(
+		ctx context.Context,
+		now time.Time,
+	) (int, error)
+}
+
+type MetaService struct {
+	transactionRepository   repositories.TransactionRepositoryInterface
+	metaAggregateRepository repositories.MetaAggregateRepositoryInterface
+}
+
+func NewMetaService(
+	transactionRepository repositories.TransactionRepositoryInterface,
+	metaAggregateRepository repositories.MetaAggregateRepositoryInterface,
+) MetaServiceInterface {
+	return &MetaService{
+		transactionRepository,
+		metaAggregateRepository,
+	}
+}
+
+type metaCount struct {
+	games int64
+	wins  int64
+}
+
+// 自分のデッキとして記録された数と対戦相手のデッキとして記録された数をアーキタイプごとに合計する
+func sumMetaCounts(sums []*repositories.MetaAggregateSum) map[string]*metaCount {
+	counts := map[string]*metaCount{}
+	for _, sum := range sums {
+		count, ok := counts[sum.Archetype]
+		if !ok {
+			count = &metaCount{}
+			counts[sum.Archetype] = count
+		}
+
+		count.games += sum.Games
+		count.wins += sum.Wins
+	}
+
+	return counts
+}
+
+// previousがnilの場合は前週との差分を含めない
+func createMetaArchetypes(
+	counts map[string]*metaCount,
+	previous map[string]*metaCount,
+) (int64, []*models.MetaArchetype) {
+	total := int64(0)
+	for _, count := range counts {
+		total += count.games
+	}
+
+	previousTotal := int64(0)
+	for _, count := range previous {
+		previousTotal += count.games
+	}
+
+	archetypes := []*models.MetaArchetype{}
+	for archetype, count := range counts {
+		model := &models.MetaArchetype{
+			Archetype:   archetype,
+			Appearances: count.games,
+			Share:       ratio(count.games, total),
+			Wins:        count.wins,
+			Losses:      count.games - count.wins,
+			WinRate:     ratio(count.wins, count.games),
+		}
+
+		if previous != nil {
+			previousCount, ok := previous[archetype]
+			if !ok {
+				previousCount = &metaCount{}
+			}
+
+			shareDelta := model.Share - ratio(previousCount.games, previousTotal)
+			model.ShareDelta = &shareDelta
+
+			if previousCount.games != 0 {
+				winRateDelta := model.WinRate - ratio(previousCount.wins, previousCount.games)
+				model.WinRateDelta = &winRateDelta
+			}
+		}
+
+		archetypes = append(archetypes, model)
+	}
+
+	sort.Slice(archetypes, func(i, j int) bool {
+		if archetypes[i].Appearances != archetypes[j].Appearances {
+			return archetypes[i].Appearances > archetypes[j].Appearances
+		}
+
+		return archetypes[i].Archetype < archetypes[j].Archetype
+	})
+
+	if len(archetypes) > META_ARCHETYPE_LIMIT {
+		archetypes = archetypes[:META_ARCHETYPE_LIMIT]
+	}
+
+	return total, archetypes
+}
+
+func (s *MetaService) Find(
+	ctx context.Context,
+	startDate time.Time,
+	endDate time.Time,
+	regulationId uint,
+) (*models.MetaReport, error) {
+	ctx, span := tracer.Start(ctx, "MetaService.Find")
+	defer span.End()
+
+	if !startDate.Before(endDate) || endDate.Sub(startDate) > time.Duration(META_MAX_DAYS*24)*time.Hour {
+		return nil, ErrInvalidMetaRange
+	}
+
+	// 最初の週の差分を求めるため、期間の直前の週も集計する
+	previous, err := s.metaAggregateRepository.Sum(ctx, regulationId, startDate.AddDate(0, 0, -7), startDate)
+	if err != nil {
+		return nil, err
+	}
+
+	previousCounts := sumMetaCounts(previous)
+
+	report := &models.MetaReport{
+		StartDate:    startDate,
+		EndDate:      endDate,
+		RegulationId: regulationId,
+		Weeks:        []*models.MetaWeek{},
+	}
+
+	totalCounts := map[string]*metaCount{}
+	for weekStart := startDate; weekStart.Before(endDate); weekStart = weekStart.AddDate(0, 0, 7) {
+		weekEnd := weekStart.AddDate(0, 0, 7)
+		if weekEnd.After(endDate) {
+			weekEnd = endDate
+		}
+
+		sums, err := s.metaAggregateRepository.Sum(ctx, regulationId, weekStart, weekEnd)
+		if err != nil {
+			return nil, err
+		}
+
+		counts := sumMetaCounts(sums)
+
+		week := &models.MetaWeek{
+			StartDate: weekStart,
+			EndDate:   weekEnd,
+		}
+		week.Appearances, week.Archetypes = createMetaArchetypes(counts, previousCounts)
+
+		report.Weeks = append(report.Weeks, week)
+		previousCounts = counts
+
+		for archetype, count := range counts {
+			totalCount, ok := totalCounts[archetype]
+			if !ok {
+				totalCount = &metaCount{}
+				totalCounts[archetype] = totalCount
+			}
+
+			totalCount.games += count.games
+			totalCount.wins += count.wins
+		}
+	}
+
+	report.Appearances, report.Archetypes = createMetaArchetypes(totalCounts, nil)
+
+	return report, nil
+}
+
+// 長いアーキタイプは保存できる長さに切り詰める
+func truncateArchetype(archetype string) string {
+	if utf8.RuneCountInString(archetype) <= META_ARCHETYPE_MAX_LENGTH {
+		return archetype
+	}
+
+	return string([]rune(archetype)[:META_ARCHETYPE_MAX_LENGTH])
+}
+
+// 集計する日時がstartDate以上endDate未満の対戦を集計し、同じ期間の集計を置き換える
+// startDate・endDateはMETA_AGGREGATE_BUCKETの境界であること
+func (s *MetaService) aggregate(
+	ctx context.Context,
+	startDate time.Time,
+	endDate time.Time,
+) (int, error) {
+	games, err := s.metaAggregateRepository.FindGames(ctx, startDate, endDate)
+	if err != nil {
+		return 0, err
+	}
+
+	type key struct {
+		bucket       time.Time
+		regulationId uint
+		side         string
+		archetype    string
+	}
+
+	aggregates := []*daos.MetaAggregate{}
+	aggregateMap := map[key]*daos.MetaAggregate{}
+
+	add := func(game *repositories.MetaGame, side string, archetype string, win bool) {
+		archetype = truncateArchetype(normalizeArchetype(archetype))
+		if archetype == "" {
+			return
+		}
+
+		k := key{game.PlayedAt.UTC().Truncate(META_AGGREGATE_BUCKET), game.RegulationId, side, archetype}
+
+		aggregate, ok := aggregateMap[k]
+		if !ok {
+			aggregate = &daos.MetaAggregate{
+				Bucket:       k.bucket,
+				RegulationId: k.regulationId,
+				Side:         k.side,
+				Archetype:    k.archetype,
+			}
+			aggregates = append(aggregates, aggregate)
+			aggregateMap[k] = aggregate
+		}
+
+		aggregate.Games++
+		if win {
+			aggregate.Wins++
+		}
+	}
+
+	for _, game := range games {
+		add(game, META_SIDE_OWN, game.DeckName, game.VictoryFlg)
+		add(game, META_SIDE_OPPONENT, game.OpponentsDeckInfo, !game.VictoryFlg)
+	}
+
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		return s.metaAggregateRepository.Replace(ctx, startDate, endDate, aggregates)
+	}); err != nil {
+		return 0, err
+	}
+
+	return len(games), nil
+}
+
+// playedAtsを含むMETA_AGGREGATE_BUCKETをそれぞれ集計し直す
+// startDate以上endDate未満のものは除く
+func (s *MetaService) aggregateBuckets(
+	ctx context.Context,
+	playedAts []time.Time,
+	startDate time.Time,
+	endDate time.Time,
+) (int, error) {
+	buckets := map[time.Time]struct{}{}
+	for _, playedAt := range playedAts {
+		bucket := playedAt.UTC().Truncate(META_AGGREGATE_BUCKET)
+		if !bucket.Before(startDate) && bucket.Before(endDate) {
+			continue
+		}
+
+		buckets[bucket] = struct{}{}
+	}
+
+	aggregated := 0
+	for bucket := range buckets {
+		count, err := s.aggregate(ctx, bucket, bucket.Add(META_AGGREGATE_BUCKET))
+		if err != nil {
+			return aggregated, err
+		}
+
+		aggregated += count
+	}
+
+	return aggregated, nil
+}
+
+// 開催日の古い大会に後から記録された対戦も反映する
+func (s *MetaService) Aggregate(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+	ctx, span := tracer.Start(ctx, "MetaService.Aggregate")
+	defer span.End()
+
+	endDate := now.UTC().Truncate(META_AGGREGATE_BUCKET).Add(META_AGGREGATE_BUCKET)
+	startDate := endDate.Add(-META_AGGREGATE_WINDOW)
+
+	playedAts, err := s.metaAggregateRepository.FindPlayedAtUpdatedSince(ctx, startDate)
+	if err != nil {
+		return 0, err
+	}
+
+	aggregated, err := s.aggregate(ctx, startDate, endDate)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := s.aggregateBuckets(ctx, playedAts, startDate, endDate)
+	if err != nil {
+		return aggregated, err
+	}
+
+	return aggregated + count, nil
+}
+
+func (s *MetaService) reaggregate(
+	ctx context.Context,
+	find func(ctx context.Context) ([]time.Time, error),
+	fn func(ctx context.Context) error,
+) error {
+	return s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		// 削除される対戦の期間も集計し直すため、変更前の期間を先に求める
+		before, err := find(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(ctx); err != nil {
+			return err
+		}
+
+		after, err := find(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.aggregateBuckets(ctx, append(before, after...), time.Time{}, time.Time{})
+
+		return err
+	})
+}
+
+func (s *MetaService) ReaggregateRecords(
+	ctx context.Context,
+	recordIds []string,
+	fn func(ctx context.Context) error,
+) error {
+	ctx, span := tracer.Start(ctx, "MetaService.ReaggregateRecords")
+	defer span.End()
+
+	return s.reaggregate(ctx, func(ctx context.Context) ([]time.Time, error) {
+		return s.metaAggregateRepository.FindPlayedAtByRecordIds(ctx, recordIds)
+	}, fn)
+}
+
+func (s *MetaService) ReaggregateUser(
+	ctx context.Context,
+	uid string,
+	fn func(ctx context.Context) error,
+) error {
+	ctx, span := tracer.Start(ctx, "MetaService.ReaggregateUser")
+	defer span.End()
+
+	return s.reaggregate(ctx, func(ctx context.Context) ([]time.Time, error) {
+		return s.metaAggregateRepository.FindPlayedAtByUID(ctx, uid)
+	}, fn)
+}
+
+// 一度に読み込む対戦の数を抑えるため、META_AGGREGATE_WINDOWごとに区切って集計する
+// 対戦の削除や集計する日時の変更で、最も古い対戦より前に残った集計は削除する
+func (s *MetaService) Rebuild(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+	ctx, span := tracer.Start(ctx, "MetaService.Rebuild")
+	defer span.End()
+
+	firstPlayedAt, err := s.metaAggregateRepository.FindFirstPlayedAt(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	endDate := now.UTC().Truncate(META_AGGREGATE_BUCKET).Add(META_AGGREGATE_BUCKET)
+
+	startDate := endDate
+	if !firstPlayedAt.IsZero() {
+		startDate = firstPlayedAt.UTC().Truncate(META_AGGREGATE_BUCKET)
+	}
+
+	if err := s.metaAggregateRepository.DeleteBefore(ctx, startDate); err != nil {
+		return 0, err
+	}
+
+	aggregated := 0
+	for ; startDate.Before(endDate); startDate = startDate.Add(META_AGGREGATE_WINDOW) {
+		windowEnd := startDate.Add(META_AGGREGATE_WINDOW)
+		if windowEnd.After(endDate) {
+			windowEnd = endDate
+		}
+
+		count, err := s.aggregate(ctx, startDate, windowEnd)
+		if err != nil {
+			return aggregated, err
+		}
+
+		aggregated += count
+	}
+
+	return aggregated, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	METRICS_NAMESPACE = "vsrecorder"
+
+	IMPORT_RESULT_DRY_RUN   = "dry_run"
+	IMPORT_RESULT_INVALID   = "invalid"
+	IMPORT_RESULT_COMMITTED = "committed"
+	IMPORT_RESULT_FAILED    = "failed"
+)
+
+var (
+	// 1時間あたりの作成数はrate(...[1h])などで集計する
+	recordsCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "records_created_total",
+		Help:      "Number of records created.",
+	})
+
+	gamesCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "games_created_total",
+		Help:      "Number of games created.",
+	})
+
+	battlesCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "battles_created_total",
+		Help:      "Number of battles created.",
+	})
+
+	importJobsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "import_jobs_total",
+		Help:      "Number of CSV import jobs by result.",
+	}, []string{"result"})
+
+	authorizationFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "authorization_failures_total",
+		Help:      "Number of rejected requests by reason.",
+	}, []string{"reason"})
+
+	activeUsers = newActiveUserTracker(map[string]time.Duration{
+		"1h":  time.Duration(1) * time.Hour,
+		"24h": time.Duration(24) * time.Hour,
+	})
+
+	activeUsersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(METRICS_NAMESPACE, "", "active_users"),
+		"Number of distinct authenticated users seen within the window.",
+		[]string{"window"},
+		nil,
+	)
+)
+
+func init() {
+	prometheus.MustRegister(activeUsers)
+}
+
+// 認証に成功したユーザを最後に確認した時刻で管理し、期間毎のアクティブユーザ数を公開する
+// プロセス毎の値のため、複数のインスタンスがある場合は合算せず最大値などで集計する
+type activeUserTracker struct {
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+	windows  map[string]time.Duration
+	maxAge   time.Duration
+
+	timeNowFn func() time.Time
+}
+
+func newActiveUserTracker(windows map[string]time.Duration) *activeUserTracker {
+	t := &activeUserTracker{
+		lastSeen:  map[string]time.Time{},
+		windows:   windows,
+		timeNowFn: time.Now,
+	}
+
+	for _, d := range windows {
+		if d > t.maxAge {
+			t.maxAge = d
+		}
+	}
+
+	return t
+}
+
+func (t *activeUserTracker) observe(uid string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastSeen[uid] = t.timeNowFn()
+}
+
+func (t *activeUserTracker) count(window time.Duration) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.timeNowFn()
+
+	count := 0
+	for uid, seenAt := range t.lastSeen {
+		elapsed := now.Sub(seenAt)
+
+		// 最も長い期間を過ぎたユーザは集計時に削除する
+		if elapsed > t.maxAge {
+			delete(t.lastSeen, uid)
+			continue
+		}
+
+		if elapsed <= window {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (t *activeUserTracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeUsersDesc
+}
+
+func (t *activeUserTracker) Collect(ch chan<- prometheus.Metric) {
+	for window, d := range t.windows {
+		ch <- prometheus.MustNewConstMetric(activeUsersDesc, prometheus.GaugeValue, float64(t.count(d)), window)
+	}
+}
+
+// middlewares.AuthorizationObserverの実装
+type AuthorizationMetrics struct{}
+
+func NewAuthorizationMetrics() *AuthorizationMetrics {
+	return &AuthorizationMetrics{}
+}
+
+func (m *AuthorizationMetrics) ObserveAuthorized(uid string) {
+	activeUsers.observe(uid)
+}
+
+func (m *AuthorizationMetrics) ObserveAuthorizationFailure(reason string) {
+	authorizationFailuresTotal.WithLabelValues(reason).Inc()
+}
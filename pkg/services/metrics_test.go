@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActiveUserTracker(t *testing.T) {
+	now := time.Now()
+
+	tracker := newActiveUserTracker(map[string]time.Duration{
+		"1h":  time.Hour,
+		"24h": 24 * time.Hour,
+	})
+	tracker.timeNowFn = func() time.Time { return now }
+
+	tracker.observe("user1")
+	tracker.observe("user1")
+	now = now.Add(2 * time.Hour)
+	tracker.observe("user2")
+
+	require.Equal(t, 1, tracker.count(time.Hour))
+	require.Equal(t, 2, tracker.count(24*time.Hour))
+
+	// 最も長い期間を過ぎたユーザは削除される
+	now = now.Add(23 * time.Hour)
+	require.Equal(t, 1, tracker.count(24*time.Hour))
+	require.Len(t, tracker.lastSeen, 1)
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditLog struct {
+	ID        string          `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	ActorUID  string          `json:"actor_uid"`
+	Entity    string          `json:"entity"`
+	EntityId  string          `json:"entity_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	Diff      json.RawMessage `json:"diff"`
+	RequestId string          `json:"request_id"`
+}
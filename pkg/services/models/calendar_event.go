@@ -0,0 +1,13 @@
+package models
+
+import (
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+)
+
+// ユーザのカレンダーに載せる大会
+// 参加予定を登録していない、Recordのみがある大会のStatusは空になる
+type CalendarEvent struct {
+	OfficialEvent *oem.OfficialEvent `json:"official_event"`
+	Status        string             `json:"status"`
+	RecordId      string             `json:"record_id"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type ErasureReceipt struct {
+	ID                   string    `json:"id"`
+	CreatedAt            time.Time `json:"created_at"`
+	UIDHash              string    `json:"uid_hash"`
+	Records              int64     `json:"records"`
+	Games                int64     `json:"games"`
+	Battles              int64     `json:"battles"`
+	Decks                int64     `json:"decks"`
+	PersonalAccessTokens int64     `json:"personal_access_tokens"`
+	Users                int64     `json:"users"`
+	UserSettings         int64     `json:"user_settings"`
+	Follows              int64     `json:"follows"`
+	EventAttendances     int64     `json:"event_attendances"`
+	ScrubbedGames        int64     `json:"scrubbed_games"`
+	AnonymizedAuditLogs  int64     `json:"anonymized_audit_logs"`
+
+	// 受領証を参照するための秘密の値(削除時のみ返す)
+	Secret string `json:"secret,omitempty"`
+}
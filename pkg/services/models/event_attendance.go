@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+)
+
+type EventAttendance struct {
+	UserId          string             `json:"user_id"`
+	OfficialEventId uint               `json:"official_event_id"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	Status          string             `json:"status"`
+	RecordId        string             `json:"record_id"`
+	OfficialEvent   *oem.OfficialEvent `json:"official_event"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+)
+
+type ExportRecord struct {
+	Record
+	OfficialEvent *oem.OfficialEvent `json:"official_event"`
+}
+
+type ExportFollow struct {
+	FolloweeId string    `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// 件数が少ないユーザ単位のデータはまとめて書き出す
+// Userは同期していない場合はnilになる
+type ExportProfile struct {
+	User        *User              `json:"user"`
+	Setting     *UserSetting       `json:"setting"`
+	Follows     []*ExportFollow    `json:"follows"`
+	Attendances []*EventAttendance `json:"attendances"`
+}
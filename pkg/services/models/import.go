@@ -0,0 +1,18 @@
+package models
+
+type ImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	DryRun    bool           `json:"dry_run"`
+	Committed bool           `json:"committed"`
+	Rows      int            `json:"rows"`
+	Records   int            `json:"records"`
+	Games     int            `json:"games"`
+	Battles   int            `json:"battles"`
+	NewDecks  []string       `json:"new_decks"`
+	RecordIds []string       `json:"record_ids"`
+	Errors    []*ImportError `json:"errors"`
+}
@@ -0,0 +1,5 @@
+package models
+
+type Maintenance struct {
+	ReadOnly bool `json:"read_only"`
+}
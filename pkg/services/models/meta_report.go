@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// 公開されている対戦から集計したアーキタイプ別の使用率・勝率
+type MetaReport struct {
+	StartDate    time.Time        `json:"start_date"`
+	EndDate      time.Time        `json:"end_date"`
+	RegulationId uint             `json:"regulation_id"`
+	Appearances  int64            `json:"appearances"`
+	Archetypes   []*MetaArchetype `json:"archetypes"`
+	Weeks        []*MetaWeek      `json:"weeks"`
+}
+
+type MetaWeek struct {
+	StartDate   time.Time        `json:"start_date"`
+	EndDate     time.Time        `json:"end_date"`
+	Appearances int64            `json:"appearances"`
+	Archetypes  []*MetaArchetype `json:"archetypes"`
+}
+
+// 使用数は自分のデッキとして記録された数と対戦相手のデッキとして記録された数の合計
+// 前週との差分は週ごとの集計にのみ含め、前週に使用されていない場合の勝率の差分はnullになる
+type MetaArchetype struct {
+	Archetype    string   `json:"archetype"`
+	Appearances  int64    `json:"appearances"`
+	Share        float64  `json:"share"`
+	Wins         int64    `json:"wins"`
+	Losses       int64    `json:"losses"`
+	WinRate      float64  `json:"win_rate"`
+	ShareDelta   *float64 `json:"share_delta"`
+	WinRateDelta *float64 `json:"win_rate_delta"`
+}
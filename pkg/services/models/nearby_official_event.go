@@ -0,0 +1,12 @@
+package models
+
+import (
+	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+)
+
+type NearbyOfficialEvent struct {
+	OfficialEvent *oem.OfficialEvent `json:"official_event"`
+	Latitude      float64            `json:"latitude"`
+	Longitude     float64            `json:"longitude"`
+	DistanceKm    float64            `json:"distance_km"`
+}
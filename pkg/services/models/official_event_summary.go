@@ -0,0 +1,36 @@
+package models
+
+// 大会に参加したバトレコのユーザの戦績から集計した大会の結果
+// 閲覧者が参照できないRecordは集計に含めない
+type OfficialEventSummary struct {
+	OfficialEventId uint                   `json:"official_event_id"`
+	Participants    int                    `json:"participants"`
+	Archetypes      []*ArchetypeSummary    `json:"archetypes"`
+	Results         []*ParticipantResult   `json:"results"`
+	OpponentDecks   []*OpponentDeckSummary `json:"opponent_decks"`
+}
+
+type ArchetypeSummary struct {
+	Archetype    string  `json:"archetype"`
+	Participants int     `json:"participants"`
+	Share        float64 `json:"share"`
+	Wins         int     `json:"wins"`
+	Losses       int     `json:"losses"`
+	TopCuts      int     `json:"top_cuts"`
+}
+
+type ParticipantResult struct {
+	RecordId  string `json:"record_id"`
+	UserId    string `json:"user_id"`
+	DeckId    string `json:"deck_id"`
+	Archetype string `json:"archetype"`
+	Wins      int    `json:"wins"`
+	Losses    int    `json:"losses"`
+	TopCut    bool   `json:"top_cut"`
+}
+
+type OpponentDeckSummary struct {
+	Archetype string  `json:"archetype"`
+	Count     int     `json:"count"`
+	Share     float64 `json:"share"`
+}
@@ -0,0 +1,8 @@
+package models
+
+type Readiness struct {
+	Ready bool `json:"ready"`
+	// 確認項目毎の結果("ok"またはエラーメッセージ)
+	Checks        map[string]string `json:"checks"`
+	SchemaVersion uint              `json:"schema_version"`
+}
@@ -9,4 +9,6 @@ type Record struct {
 	OfficialEventId uint      `json:"official_event_id"`
 	UserId          string    `json:"user_id"`
 	DeckId          string    `json:"deck_id"`
+	Visibility      string    `json:"visibility"`
+	MemoVisibility  string    `json:"memo_visibility"`
 }
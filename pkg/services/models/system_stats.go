@@ -0,0 +1,9 @@
+package models
+
+type SystemStats struct {
+	Users   int64 `json:"users"`
+	Records int64 `json:"records"`
+	Games   int64 `json:"games"`
+	Battles int64 `json:"battles"`
+	Decks   int64 `json:"decks"`
+}
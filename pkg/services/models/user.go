@@ -1,7 +1,12 @@
 package models
 
 type User struct {
-	UID         string `json:"uid"`
-	DisplayName string `json:"display_name"`
-	PhotoURL    string `json:"photo_url"`
+	UID            string `json:"uid"`
+	DisplayName    string `json:"display_name"`
+	PhotoURL       string `json:"photo_url"`
+	PTCGLName      string `json:"ptcgl_name"`
+	PlayerId       string `json:"player_id"`
+	Prefecture     string `json:"prefecture"`
+	Bio            string `json:"bio"`
+	FavoriteDeckId string `json:"favorite_deck_id"`
 }
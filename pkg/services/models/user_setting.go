@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type UserSetting struct {
+	UserId                string    `json:"user_id"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+	DefaultVisibility     string    `json:"default_visibility"`
+	DefaultMemoVisibility string    `json:"default_memo_visibility"`
+	TimeZone              string    `json:"time_zone"`
+}
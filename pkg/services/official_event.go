@@ -2,10 +2,18 @@ package services
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	oem "github.com/vsrecorder/import-officialevent-bat/pkg/models"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/logging"
 	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
 )
 
@@ -27,24 +35,86 @@ type OfficialEventServiceInterface interface {
 		endDate time.Time,
 	) ([]*oem.OfficialEvent, error)
 
+	// 閲覧者(uidが空の場合は未ログイン)が参照できるRecordのみを返す
 	FindRecordById(
 		ctx context.Context,
 		id uint,
+		uid string,
 	) ([]*models.Record, error)
+
+	// 参加者のデッキの分布・戦績・決勝トーナメントへの進出・対戦相手のデッキを集計する
+	FindSummaryById(
+		ctx context.Context,
+		id uint,
+		uid string,
+	) (*models.OfficialEventSummary, error)
+
+	Search(
+		ctx context.Context,
+		dto *dtos.OfficialEventSearch,
+		limit int,
+		offset int,
+	) ([]*oem.OfficialEvent, error)
+
+	FindNearby(
+		ctx context.Context,
+		latitude float64,
+		longitude float64,
+		radiusKm float64,
+		startDate time.Time,
+		endDate time.Time,
+		limit int,
+		offset int,
+	) ([]*models.NearbyOfficialEvent, error)
+
+	// 座標を求めていない大会の会場の座標を求めて保存し、保存した件数を返す
+	Geocode(
+		ctx context.Context,
+	) (int, error)
 }
 
+const (
+	DEFAULT_NEARBY_RADIUS_KM = 30.0
+	MAX_NEARBY_RADIUS_KM     = 300.0
+
+	// ジオコーディングで一度に読み込む大会の件数
+	GEOCODE_BATCH_SIZE = 500
+
+	// 大会の結果に載せる対戦相手のデッキの数
+	SUMMARY_OPPONENT_DECK_LIMIT = 10
+)
+
+var (
+	ErrInvalidSearchCondition = errors.New("invalid search condition")
+)
+
 type OfficialEventService struct {
-	officialEventRepository repositories.OfficialEventRepositoryInterface
-	recordRepository        repositories.RecordRepositoryInterface
+	officialEventRepository         repositories.OfficialEventRepositoryInterface
+	recordRepository                repositories.RecordRepositoryInterface
+	gameRepository                  repositories.GameRepositoryInterface
+	deckRepository                  repositories.DeckRepositoryInterface
+	followRepository                repositories.FollowRepositoryInterface
+	officialEventLocationRepository repositories.OfficialEventLocationRepositoryInterface
+	geocoder                        repositories.GeocoderInterface
 }
 
 func NewOfficialEventService(
 	officialEventRepository repositories.OfficialEventRepositoryInterface,
 	recordRepository repositories.RecordRepositoryInterface,
+	gameRepository repositories.GameRepositoryInterface,
+	deckRepository repositories.DeckRepositoryInterface,
+	followRepository repositories.FollowRepositoryInterface,
+	officialEventLocationRepository repositories.OfficialEventLocationRepositoryInterface,
+	geocoder repositories.GeocoderInterface,
 ) OfficialEventServiceInterface {
 	return &OfficialEventService{
 		officialEventRepository,
 		recordRepository,
+		gameRepository,
+		deckRepository,
+		followRepository,
+		officialEventLocationRepository,
+		geocoder,
 	}
 }
 
@@ -53,6 +123,9 @@ func (s *OfficialEventService) Find(
 	limit int,
 	offset int,
 ) ([]*oem.OfficialEvent, error) {
+	ctx, span := tracer.Start(ctx, "OfficialEventService.Find")
+	defer span.End()
+
 	ret, err := s.officialEventRepository.Find(ctx, limit, offset)
 	if err != nil {
 		return nil, err
@@ -66,6 +139,9 @@ func (s *OfficialEventService) FindById(
 	ctx context.Context,
 	id uint,
 ) (*oem.OfficialEvent, error) {
+	ctx, span := tracer.Start(ctx, "OfficialEventService.FindById")
+	defer span.End()
+
 	ret, err := s.officialEventRepository.FindById(ctx, id)
 	if err != nil {
 		return nil, err
@@ -79,6 +155,9 @@ func (s *OfficialEventService) FindByDate(
 	startDate time.Time,
 	endDate time.Time,
 ) ([]*oem.OfficialEvent, error) {
+	ctx, span := tracer.Start(ctx, "OfficialEventService.FindByDate")
+	defer span.End()
+
 	ret, err := s.officialEventRepository.FindByDate(ctx, startDate, endDate)
 	if err != nil {
 		return nil, err
@@ -90,7 +169,11 @@ func (s *OfficialEventService) FindByDate(
 func (s *OfficialEventService) FindRecordById(
 	ctx context.Context,
 	id uint,
+	uid string,
 ) ([]*models.Record, error) {
+	ctx, span := tracer.Start(ctx, "OfficialEventService.FindRecordById")
+	defer span.End()
+
 	// 指定されたidのOfficialEventが存在するか確認
 	if _, err := s.FindById(ctx, id); err != nil {
 		return nil, err
@@ -106,5 +189,360 @@ func (s *OfficialEventService) FindRecordById(
 		records = append(records, createRecordModel(dao))
 	}
 
-	return records, nil
+	return newViewer(uid, s.followRepository).filterRecords(ctx, records)
+}
+
+// デッキを登録していない参加者のアーキタイプは空になる
+func (s *OfficialEventService) FindSummaryById(
+	ctx context.Context,
+	id uint,
+	uid string,
+) (*models.OfficialEventSummary, error) {
+	ctx, span := tracer.Start(ctx, "OfficialEventService.FindSummaryById")
+	defer span.End()
+
+	// 指定されたidのOfficialEventが存在するか確認
+	if _, err := s.FindById(ctx, id); err != nil {
+		return nil, err
+	}
+
+	recordDaos, err := s.recordRepository.FindByOfficialEventId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*models.Record{}
+	for _, dao := range recordDaos {
+		records = append(records, createRecordModel(dao))
+	}
+
+	// 閲覧者が参照できないRecordは集計に含めない
+	records, err = newViewer(uid, s.followRepository).filterRecords(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	recordIds := []string{}
+	deckIds := []string{}
+	for _, record := range records {
+		recordIds = append(recordIds, record.ID)
+		if record.DeckId != "" {
+			deckIds = append(deckIds, record.DeckId)
+		}
+	}
+
+	games, err := s.gameRepository.FindByRecordIds(ctx, recordIds)
+	if err != nil {
+		return nil, err
+	}
+
+	decks, err := s.deckRepository.FindByIds(ctx, deckIds)
+	if err != nil {
+		return nil, err
+	}
+
+	archetypeMap := map[string]string{}
+	for _, deck := range decks {
+		archetypeMap[deck.ID] = normalizeArchetype(deck.Name)
+	}
+
+	results := []*models.ParticipantResult{}
+	resultMap := map[string]*models.ParticipantResult{}
+	for _, record := range records {
+		result := &models.ParticipantResult{
+			RecordId:  record.ID,
+			UserId:    record.UserId,
+			DeckId:    record.DeckId,
+			Archetype: archetypeMap[record.DeckId],
+		}
+
+		results = append(results, result)
+		resultMap[record.ID] = result
+	}
+
+	opponentDeckCounts := map[string]int{}
+	opponentDeckTotal := 0
+	for _, game := range games {
+		result, ok := resultMap[game.RecordId]
+		if !ok {
+			continue
+		}
+
+		if game.VictoryFlg {
+			result.Wins++
+		} else {
+			result.Losses++
+		}
+
+		if game.FinalTournamentFlg {
+			result.TopCut = true
+		}
+
+		if opponentDeck := normalizeArchetype(game.OpponentsDeckInfo); opponentDeck != "" {
+			opponentDeckCounts[opponentDeck]++
+			opponentDeckTotal++
+		}
+	}
+
+	archetypes := []*models.ArchetypeSummary{}
+	archetypeSummaryMap := map[string]*models.ArchetypeSummary{}
+	for _, result := range results {
+		summary, ok := archetypeSummaryMap[result.Archetype]
+		if !ok {
+			summary = &models.ArchetypeSummary{Archetype: result.Archetype}
+			archetypes = append(archetypes, summary)
+			archetypeSummaryMap[result.Archetype] = summary
+		}
+
+		summary.Participants++
+		summary.Wins += result.Wins
+		summary.Losses += result.Losses
+		if result.TopCut {
+			summary.TopCuts++
+		}
+	}
+
+	for _, summary := range archetypes {
+		summary.Share = ratio(summary.Participants, len(results))
+	}
+
+	sort.SliceStable(archetypes, func(i, j int) bool {
+		if archetypes[i].Participants != archetypes[j].Participants {
+			return archetypes[i].Participants > archetypes[j].Participants
+		}
+
+		return archetypes[i].Archetype < archetypes[j].Archetype
+	})
+
+	// 決勝トーナメントに進出した参加者から順に、勝ち数の多い順に並べる
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].TopCut != results[j].TopCut {
+			return results[i].TopCut
+		}
+
+		if results[i].Wins != results[j].Wins {
+			return results[i].Wins > results[j].Wins
+		}
+
+		return results[i].Losses < results[j].Losses
+	})
+
+	opponentDecks := []*models.OpponentDeckSummary{}
+	for archetype, count := range opponentDeckCounts {
+		opponentDecks = append(opponentDecks, &models.OpponentDeckSummary{
+			Archetype: archetype,
+			Count:     count,
+			Share:     ratio(count, opponentDeckTotal),
+		})
+	}
+
+	sort.Slice(opponentDecks, func(i, j int) bool {
+		if opponentDecks[i].Count != opponentDecks[j].Count {
+			return opponentDecks[i].Count > opponentDecks[j].Count
+		}
+
+		return opponentDecks[i].Archetype < opponentDecks[j].Archetype
+	})
+
+	if len(opponentDecks) > SUMMARY_OPPONENT_DECK_LIMIT {
+		opponentDecks = opponentDecks[:SUMMARY_OPPONENT_DECK_LIMIT]
+	}
+
+	return &models.OfficialEventSummary{
+		OfficialEventId: id,
+		Participants:    len(results),
+		Archetypes:      archetypes,
+		Results:         results,
+		OpponentDecks:   opponentDecks,
+	}, nil
+}
+
+// キーワード・都道府県・種別・リーグ・レギュレーション・主催店舗・開催日を組み合わせて大会を検索する
+func (s *OfficialEventService) Search(
+	ctx context.Context,
+	dto *dtos.OfficialEventSearch,
+	limit int,
+	offset int,
+) ([]*oem.OfficialEvent, error) {
+	ctx, span := tracer.Start(ctx, "OfficialEventService.Search")
+	defer span.End()
+
+	if dto.Prefecture != "" && !IsValidPrefecture(dto.Prefecture) {
+		return nil, ErrInvalidSearchCondition
+	}
+
+	if dto.Sort != "" && !repositories.IsValidOfficialEventSort(dto.Sort) {
+		return nil, ErrInvalidSearchCondition
+	}
+
+	condition := &repositories.OfficialEventCondition{
+		Keywords:     strings.Fields(dto.Keyword),
+		Prefecture:   dto.Prefecture,
+		TypeId:       dto.TypeId,
+		LeagueId:     dto.LeagueId,
+		RegulationId: dto.RegulationId,
+		ShopId:       dto.ShopId,
+		StartDate:    dto.StartDate,
+		EndDate:      dto.EndDate,
+		Sort:         dto.Sort,
+	}
+
+	ret, err := s.officialEventRepository.Search(ctx, condition, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// 中心から半径radiusKm以内で開催される大会を近い順に返す
+func (s *OfficialEventService) FindNearby(
+	ctx context.Context,
+	latitude float64,
+	longitude float64,
+	radiusKm float64,
+	startDate time.Time,
+	endDate time.Time,
+	limit int,
+	offset int,
+) ([]*models.NearbyOfficialEvent, error) {
+	ctx, span := tracer.Start(ctx, "OfficialEventService.FindNearby")
+	defer span.End()
+
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return nil, ErrInvalidSearchCondition
+	}
+
+	if radiusKm == 0 {
+		radiusKm = DEFAULT_NEARBY_RADIUS_KM
+	} else if radiusKm < 0 || radiusKm > MAX_NEARBY_RADIUS_KM {
+		return nil, ErrInvalidSearchCondition
+	}
+
+	// 範囲を緯度・経度で大まかに絞り込んでから距離を求める
+	minLatitude, maxLatitude, minLongitude, maxLongitude := boundingBox(latitude, longitude, radiusKm)
+	locations, err := s.officialEventLocationRepository.FindByBoundingBox(
+		ctx,
+		minLatitude,
+		maxLatitude,
+		minLongitude,
+		maxLongitude,
+		startDate,
+		endDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	nearbyOfficialEvents := []*models.NearbyOfficialEvent{}
+	for _, location := range locations {
+		distance := distanceKm(latitude, longitude, location.Latitude, location.Longitude)
+		if distance > radiusKm {
+			continue
+		}
+
+		nearbyOfficialEvents = append(nearbyOfficialEvents, &models.NearbyOfficialEvent{
+			OfficialEvent: &oem.OfficialEvent{Id: location.OfficialEventId},
+			Latitude:      location.Latitude,
+			Longitude:     location.Longitude,
+			DistanceKm:    math.Round(distance*10) / 10,
+		})
+	}
+
+	sort.SliceStable(nearbyOfficialEvents, func(i, j int) bool {
+		if nearbyOfficialEvents[i].DistanceKm != nearbyOfficialEvents[j].DistanceKm {
+			return nearbyOfficialEvents[i].DistanceKm < nearbyOfficialEvents[j].DistanceKm
+		}
+
+		return nearbyOfficialEvents[i].OfficialEvent.Id < nearbyOfficialEvents[j].OfficialEvent.Id
+	})
+
+	if offset >= len(nearbyOfficialEvents) {
+		return []*models.NearbyOfficialEvent{}, nil
+	}
+
+	nearbyOfficialEvents = nearbyOfficialEvents[offset:]
+	if len(nearbyOfficialEvents) > limit {
+		nearbyOfficialEvents = nearbyOfficialEvents[:limit]
+	}
+
+	ids := []uint{}
+	for _, nearbyOfficialEvent := range nearbyOfficialEvents {
+		ids = append(ids, nearbyOfficialEvent.OfficialEvent.Id)
+	}
+
+	officialEvents, err := s.officialEventRepository.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	officialEventMap := map[uint]*oem.OfficialEvent{}
+	for _, officialEvent := range officialEvents {
+		officialEventMap[officialEvent.Id] = officialEvent
+	}
+
+	for _, nearbyOfficialEvent := range nearbyOfficialEvents {
+		if officialEvent, ok := officialEventMap[nearbyOfficialEvent.OfficialEvent.Id]; ok {
+			nearbyOfficialEvent.OfficialEvent = officialEvent
+		}
+	}
+
+	return nearbyOfficialEvents, nil
+}
+
+// import-officialevent-batで取り込まれた大会を対象に、スケジューラから定期的に実行する
+// 座標を求められなかった大会は次回も対象になる
+func (s *OfficialEventService) Geocode(
+	ctx context.Context,
+) (int, error) {
+	ctx, span := tracer.Start(ctx, "OfficialEventService.Geocode")
+	defer span.End()
+
+	located := 0
+	unmatched := 0
+
+	afterId := uint(0)
+	for {
+		officialEvents, err := s.officialEventLocationRepository.FindUnlocated(ctx, afterId, GEOCODE_BATCH_SIZE)
+		if err != nil {
+			return located, err
+		}
+
+		if len(officialEvents) == 0 {
+			break
+		}
+
+		for _, officialEvent := range officialEvents {
+			afterId = officialEvent.Id
+
+			entry, ok, err := s.geocoder.Geocode(ctx, officialEvent.Address)
+			if err != nil {
+				return located, err
+			}
+
+			if !ok {
+				unmatched++
+				continue
+			}
+
+			if err := s.officialEventLocationRepository.Save(ctx, &daos.OfficialEventLocation{
+				OfficialEventId: officialEvent.Id,
+				Latitude:        entry.Latitude,
+				Longitude:       entry.Longitude,
+				MatchedName:     entry.Name,
+			}); err != nil {
+				return located, err
+			}
+
+			located++
+		}
+	}
+
+	logging.FromContext(ctx).Info(
+		"geocoded official events",
+		slog.Int("located", located),
+		slog.Int("unmatched", unmatched),
+	)
+
+	return located, nil
 }
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/helpers"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	PERSONAL_ACCESS_TOKEN_BYTES = 32
+
+	// LastUsedAtの更新頻度(リクエストの度に書き込まないようにする)
+	PERSONAL_ACCESS_TOKEN_TOUCH_INTERVAL = time.Duration(1) * time.Minute
+)
+
+type PersonalAccessTokenServiceInterface interface {
+	FindAllByUID(
+		ctx context.Context,
+		uid string,
+	) ([]*models.PersonalAccessToken, error)
+
+	Create(
+		ctx context.Context,
+		uid string,
+		dto *dtos.PersonalAccessToken,
+	) (*models.PersonalAccessToken, error)
+
+	Delete(
+		ctx context.Context,
+		id string,
+		uid string,
+	) error
+
+	Verify(
+		ctx context.Context,
+		token string,
+	) (string, []string, error)
+}
+
+type PersonalAccessTokenService struct {
+	personalAccessTokenRepository repositories.PersonalAccessTokenRepositoryInterface
+}
+
+func NewPersonalAccessTokenService(
+	personalAccessTokenRepository repositories.PersonalAccessTokenRepositoryInterface,
+) PersonalAccessTokenServiceInterface {
+	return &PersonalAccessTokenService{
+		personalAccessTokenRepository,
+	}
+}
+
+func createPersonalAccessTokenModel(dao *daos.PersonalAccessToken) *models.PersonalAccessToken {
+	model := &models.PersonalAccessToken{}
+	model.ID = dao.ID
+	model.CreatedAt = dao.CreatedAt
+	model.UpdatedAt = dao.UpdatedAt
+	model.UserId = dao.UserId
+	model.Name = dao.Name
+	model.Scopes = strings.Fields(dao.Scopes)
+	model.LastUsedAt = dao.LastUsedAt
+	model.ExpiresAt = dao.ExpiresAt
+
+	return model
+}
+
+func generatePersonalAccessToken() (string, error) {
+	b := make([]byte, PERSONAL_ACCESS_TOKEN_BYTES)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return helpers.PERSONAL_ACCESS_TOKEN_PREFIX + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// トークンそのものは保存せずハッシュ値のみを保存する
+func hashPersonalAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *PersonalAccessTokenService) FindAllByUID(
+	ctx context.Context,
+	uid string,
+) ([]*models.PersonalAccessToken, error) {
+	ctx, span := tracer.Start(ctx, "PersonalAccessTokenService.FindAllByUID")
+	defer span.End()
+
+	daos, err := s.personalAccessTokenRepository.FindAllByUID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := []*models.PersonalAccessToken{}
+	for _, dao := range daos {
+		tokens = append(tokens, createPersonalAccessTokenModel(dao))
+	}
+
+	return tokens, nil
+}
+
+func (s *PersonalAccessTokenService) Create(
+	ctx context.Context,
+	uid string,
+	dto *dtos.PersonalAccessToken,
+) (*models.PersonalAccessToken, error) {
+	ctx, span := tracer.Start(ctx, "PersonalAccessTokenService.Create")
+	defer span.End()
+
+	// 指定されたdto.Scopesが有効なスコープか確認
+	if len(dto.Scopes) == 0 {
+		return nil, errors.New("scopes are required")
+	}
+	for _, scope := range dto.Scopes {
+		if !helpers.IsValidScope(scope) {
+			return nil, errors.New("invalid scope: " + scope)
+		}
+	}
+
+	id, err := generateId()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generatePersonalAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	dao := daos.PersonalAccessToken{
+		ID:        id,
+		UserId:    uid,
+		Name:      dto.Name,
+		TokenHash: hashPersonalAccessToken(token),
+		Scopes:    strings.Join(dto.Scopes, " "),
+	}
+
+	if dto.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, int(dto.ExpiresInDays))
+		dao.ExpiresAt = &expiresAt
+	}
+
+	if err := s.personalAccessTokenRepository.Save(ctx, &dao); err != nil {
+		return nil, err
+	}
+
+	// トークンを返すのは作成時の1回のみ
+	model := createPersonalAccessTokenModel(&dao)
+	model.Token = token
+
+	return model, nil
+}
+
+func (s *PersonalAccessTokenService) Delete(
+	ctx context.Context,
+	id string,
+	uid string,
+) error {
+	ctx, span := tracer.Start(ctx, "PersonalAccessTokenService.Delete")
+	defer span.End()
+
+	// 指定されたIdのPersonalAccessTokenが存在するか確認
+	dao, err := s.personalAccessTokenRepository.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// 指定されたUIDと取得したdaoのUserIdが一致しているか確認
+	if dao.UserId != uid {
+		return errors.New("no authority")
+	}
+
+	return s.personalAccessTokenRepository.Delete(ctx, id, uid)
+}
+
+func (s *PersonalAccessTokenService) Verify(
+	ctx context.Context,
+	token string,
+) (string, []string, error) {
+	ctx, span := tracer.Start(ctx, "PersonalAccessTokenService.Verify")
+	defer span.End()
+
+	dao, err := s.personalAccessTokenRepository.FindByTokenHash(ctx, hashPersonalAccessToken(token))
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+
+	if dao.ExpiresAt != nil && dao.ExpiresAt.Before(now) {
+		return "", nil, errors.New("token expired")
+	}
+
+	if dao.LastUsedAt == nil || now.Sub(*dao.LastUsedAt) > PERSONAL_ACCESS_TOKEN_TOUCH_INTERVAL {
+		dao.LastUsedAt = &now
+		if err := s.personalAccessTokenRepository.Save(ctx, dao); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return dao.UserId, strings.Fields(dao.Scopes), nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
@@ -13,25 +14,31 @@ import (
 type RecordServiceInterface interface {
 	Find(
 		ctx context.Context,
+		startDate time.Time,
+		endDate time.Time,
 		limit int,
 		offset int,
 	) ([]*models.Record, error)
 
-	FindById(
+	FindByIdWithUID(
 		ctx context.Context,
 		id string,
+		uid string,
 	) (*models.Record, error)
 
 	FindByUID(
 		ctx context.Context,
 		uid string,
+		startDate time.Time,
+		endDate time.Time,
 		limit int,
 		offset int,
 	) ([]*models.Record, error)
 
-	FindGameById(
+	FindGameByIdWithUID(
 		ctx context.Context,
 		id string,
+		uid string,
 	) ([]*models.Game, error)
 
 	Create(
@@ -55,20 +62,35 @@ type RecordServiceInterface interface {
 }
 
 type RecordService struct {
+	transactionRepository   repositories.TransactionRepositoryInterface
 	recordRepository        repositories.RecordRepositoryInterface
 	gameRepository          repositories.GameRepositoryInterface
 	officialEventRepository repositories.OfficialEventRepositoryInterface
+	userSettingRepository   repositories.UserSettingRepositoryInterface
+	followRepository        repositories.FollowRepositoryInterface
+	auditLogRepository      repositories.AuditLogRepositoryInterface
+	metaService             MetaServiceInterface
 }
 
 func NewRecordService(
+	transactionRepository repositories.TransactionRepositoryInterface,
 	recordRepository repositories.RecordRepositoryInterface,
 	gameRepository repositories.GameRepositoryInterface,
 	officialEventRepository repositories.OfficialEventRepositoryInterface,
+	userSettingRepository repositories.UserSettingRepositoryInterface,
+	followRepository repositories.FollowRepositoryInterface,
+	auditLogRepository repositories.AuditLogRepositoryInterface,
+	metaService MetaServiceInterface,
 ) RecordServiceInterface {
 	return &RecordService{
+		transactionRepository,
 		recordRepository,
 		gameRepository,
 		officialEventRepository,
+		userSettingRepository,
+		followRepository,
+		auditLogRepository,
+		metaService,
 	}
 }
 
@@ -81,16 +103,24 @@ func createRecordModel(dao *daos.Record) *models.Record {
 	record.OfficialEventId = dao.OfficialEventId
 	record.UserId = dao.UserId
 	record.DeckId = dao.DeckId
+	record.Visibility = dao.Visibility
+	record.MemoVisibility = dao.MemoVisibility
 
 	return &record
 }
 
 func (s *RecordService) Find(
 	ctx context.Context,
+	startDate time.Time,
+	endDate time.Time,
 	limit int,
 	offset int,
 ) ([]*models.Record, error) {
-	daos, err := s.recordRepository.Find(ctx, limit, offset)
+	ctx, span := tracer.Start(ctx, "RecordService.Find")
+	defer span.End()
+
+	// ユーザを指定しない一覧には公開のRecordのみを含める
+	daos, err := s.recordRepository.FindByVisibility(ctx, VISIBILITY_PUBLIC, startDate, endDate, limit, offset)
 
 	if err != nil {
 		return nil, err
@@ -105,10 +135,14 @@ func (s *RecordService) Find(
 	return records, nil
 }
 
-func (s *RecordService) FindById(
+func (s *RecordService) FindByIdWithUID(
 	ctx context.Context,
 	id string,
+	uid string,
 ) (*models.Record, error) {
+	ctx, span := tracer.Start(ctx, "RecordService.FindByIdWithUID")
+	defer span.End()
+
 	dao, err := s.recordRepository.FindById(ctx, id)
 
 	if err != nil {
@@ -117,16 +151,30 @@ func (s *RecordService) FindById(
 
 	record := createRecordModel(dao)
 
+	ok, err := newViewer(uid, s.followRepository).canViewRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrNotVisible
+	}
+
 	return record, nil
 }
 
 func (s *RecordService) FindByUID(
 	ctx context.Context,
 	uid string,
+	startDate time.Time,
+	endDate time.Time,
 	limit int,
 	offset int,
 ) ([]*models.Record, error) {
-	daos, err := s.recordRepository.FindByUID(ctx, uid, limit, offset)
+	ctx, span := tracer.Start(ctx, "RecordService.FindByUID")
+	defer span.End()
+
+	daos, err := s.recordRepository.FindByUID(ctx, uid, startDate, endDate, limit, offset)
 
 	if err != nil {
 		return nil, err
@@ -141,15 +189,33 @@ func (s *RecordService) FindByUID(
 	return records, nil
 }
 
-func (s *RecordService) FindGameById(
+func (s *RecordService) FindGameByIdWithUID(
 	ctx context.Context,
 	id string,
+	uid string,
 ) ([]*models.Game, error) {
+	ctx, span := tracer.Start(ctx, "RecordService.FindGameByIdWithUID")
+	defer span.End()
+
 	// 指定されたIdのRecordが存在するか確認
-	if _, err := s.FindById(ctx, id); err != nil {
+	dao, err := s.recordRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	record := createRecordModel(dao)
+	viewer := newViewer(uid, s.followRepository)
+
+	// Gameが無い場合も閲覧できないRecordの存在を知られないようにする
+	ok, err := viewer.canViewRecord(ctx, record)
+	if err != nil {
 		return nil, err
 	}
 
+	if !ok {
+		return nil, ErrNotVisible
+	}
+
 	daos, err := s.gameRepository.FindByRecordId(ctx, id)
 	if err != nil {
 		return nil, err
@@ -157,7 +223,18 @@ func (s *RecordService) FindGameById(
 
 	games := []*models.Game{}
 	for _, dao := range daos {
-		games = append(games, createGameModel(dao))
+		game := createGameModel(dao)
+
+		ok, err := viewer.viewGame(ctx, record, game)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, ErrNotVisible
+		}
+
+		games = append(games, game)
 	}
 
 	return games, nil
@@ -169,6 +246,9 @@ func (s *RecordService) Create(
 	uid string,
 	dto *dtos.Record,
 ) (*models.Record, error) {
+	ctx, span := tracer.Start(ctx, "RecordService.Create")
+	defer span.End()
+
 	// 指定されたdto.OfficialEventIdのOfficialEventが存在するか確認
 	if _, err := s.officialEventRepository.FindById(ctx, dto.OfficialEventId); err != nil {
 		return nil, err
@@ -176,6 +256,11 @@ func (s *RecordService) Create(
 
 	// TODO: 既に指定されたdto.OfficialEventIdでRecordが作成されているか確認
 
+	visibility, memoVisibility, err := resolveVisibility(ctx, s.userSettingRepository, uid, dto.Visibility, dto.MemoVisibility)
+	if err != nil {
+		return nil, err
+	}
+
 	id, err := generateId()
 	if err != nil {
 		return nil, err
@@ -186,14 +271,24 @@ func (s *RecordService) Create(
 		OfficialEventId: dto.OfficialEventId,
 		UserId:          uid,
 		DeckId:          dto.DeckId,
+		Visibility:      visibility,
+		MemoVisibility:  memoVisibility,
 	}
 
-	if err := s.recordRepository.Save(ctx, &dao); err != nil {
+	if err := s.transactionRepository.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.recordRepository.Save(ctx, &dao); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_RECORD, dao.ID, AUDIT_ACTION_CREATE, nil, createRecordModel(&dao))
+	}); err != nil {
 		return nil, err
 	}
 
 	record := createRecordModel(&dao)
 
+	recordsCreatedTotal.Inc()
+
 	return record, nil
 }
 
@@ -203,6 +298,9 @@ func (s *RecordService) Update(
 	uid string,
 	dto *dtos.Record,
 ) (*models.Record, error) {
+	ctx, span := tracer.Start(ctx, "RecordService.Update")
+	defer span.End()
+
 	// 指定されたdto.OfficialEventIdのOfficialEventが存在するか確認
 	if _, err := s.officialEventRepository.FindById(ctx, dto.OfficialEventId); err != nil {
 		return nil, err
@@ -219,10 +317,34 @@ func (s *RecordService) Update(
 		return nil, errors.New("no authority")
 	}
 
+	before := createRecordModel(dao)
+
+	// 指定されていない公開範囲は変更しない
+	if dto.Visibility != "" {
+		if !IsValidVisibility(dto.Visibility) {
+			return nil, errors.New("invalid visibility")
+		}
+		dao.Visibility = dto.Visibility
+	}
+
+	if dto.MemoVisibility != "" {
+		if !IsValidVisibility(dto.MemoVisibility) {
+			return nil, errors.New("invalid visibility")
+		}
+		dao.MemoVisibility = dto.MemoVisibility
+	}
+
 	dao.OfficialEventId = dto.OfficialEventId
 	dao.DeckId = dto.DeckId
 
-	if err := s.recordRepository.Save(ctx, dao); err != nil {
+	// 公開範囲・大会・デッキの変更を環境レポートの集計に反映する
+	if err := s.metaService.ReaggregateRecords(ctx, []string{dao.ID}, func(ctx context.Context) error {
+		if err := s.recordRepository.Save(ctx, dao); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_RECORD, dao.ID, AUDIT_ACTION_UPDATE, before, createRecordModel(dao))
+	}); err != nil {
 		return nil, err
 	}
 
@@ -236,6 +358,9 @@ func (s *RecordService) Delete(
 	id string,
 	uid string,
 ) error {
+	ctx, span := tracer.Start(ctx, "RecordService.Delete")
+	defer span.End()
+
 	// 指定されたIdのRecordが存在するか確認
 	dao, err := s.recordRepository.FindById(ctx, id)
 	if err != nil {
@@ -247,5 +372,12 @@ func (s *RecordService) Delete(
 		return errors.New("no authority")
 	}
 
-	return s.recordRepository.Delete(ctx, id, uid)
+	// 削除を環境レポートの集計に反映する
+	return s.metaService.ReaggregateRecords(ctx, []string{id}, func(ctx context.Context) error {
+		if err := s.recordRepository.Delete(ctx, id, uid); err != nil {
+			return err
+		}
+
+		return writeAuditLog(ctx, s.auditLogRepository, uid, AUDIT_ENTITY_RECORD, id, AUDIT_ACTION_DELETE, createRecordModel(dao), nil)
+	})
 }
@@ -34,9 +34,17 @@ func TestRecordService(t *testing.T) {
 	require.NoError(t, err)
 
 	s := NewRecordService(
+		repositories.NewTransactionRepository(db),
 		repositories.NewRecordRepository(db),
 		repositories.NewGameRepository(db),
 		repositories.NewOfficialEventRepository(db),
+		repositories.NewUserSettingRepository(db),
+		repositories.NewFollowRepository(db),
+		repositories.NewAuditLogRepository(db),
+		NewMetaService(
+			repositories.NewTransactionRepository(db),
+			repositories.NewMetaAggregateRepository(db),
+		),
 	)
 
 	for scenario, fn := range map[string]func(
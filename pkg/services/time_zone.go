@@ -0,0 +1,35 @@
+package services
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// ユーザがタイムゾーンを設定していない場合の既定値
+	DEFAULT_TIME_ZONE = "Asia/Tokyo"
+)
+
+var (
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+)
+
+// IANAのタイムゾーン名を読み込む
+// 空の場合は既定のタイムゾーンを返す
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		name = DEFAULT_TIME_ZONE
+	}
+
+	// time.LoadLocationは"Local"を受け付けるが、サーバの設定に依存するため認めない
+	if name == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+
+	return loc, nil
+}
@@ -2,9 +2,29 @@ package services
 
 import (
 	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
 
+	"github.com/vsrecorder/vsr-apiserver/pkg/controllers/dtos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
 	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+	"gorm.io/gorm"
+)
+
+const (
+	USER_PTCGL_NAME_MAX_LENGTH = 32
+	USER_PLAYER_ID_MAX_LENGTH  = 16
+	USER_BIO_MAX_LENGTH        = 500
+
+	// FindByIdsで一度に取得できるユーザ数の上限
+	USER_FIND_BY_IDS_LIMIT = 100
+)
+
+var (
+	// 一度も認証していないユーザ、削除済みのユーザは存在しないものとして扱う
+	ErrUserNotFound = errors.New("user not found")
 )
 
 type UserServiceInterface interface {
@@ -13,28 +33,87 @@ type UserServiceInterface interface {
 		id string,
 	) (*models.User, error)
 
-	FindRecordsById(
+	FindByIds(
+		ctx context.Context,
+		ids []string,
+	) ([]*models.User, error)
+
+	Sync(
+		ctx context.Context,
+		uid string,
+	) (*models.User, error)
+
+	Register(
+		ctx context.Context,
+		uid string,
+	) error
+
+	UpdateProfile(
+		ctx context.Context,
+		uid string,
+		dto *dtos.UserProfile,
+	) (*models.User, error)
+
+	FindRecordsByIdWithUID(
 		ctx context.Context,
 		id string,
+		uid string,
+		startDate time.Time,
+		endDate time.Time,
 	) ([]*models.Record, error)
 
-	FindGamesById(
+	FindGamesByIdWithUID(
 		ctx context.Context,
 		id string,
+		uid string,
+		startDate time.Time,
+		endDate time.Time,
 	) ([]*models.Game, error)
 
 	FindDecksByIdWithUID(
 		ctx context.Context,
 		id string,
 		uid string,
+		startDate time.Time,
+		endDate time.Time,
 	) ([]*models.Deck, error)
+
+	FindSettingByUID(
+		ctx context.Context,
+		uid string,
+	) (*models.UserSetting, error)
+
+	UpdateSetting(
+		ctx context.Context,
+		uid string,
+		dto *dtos.UserSetting,
+	) (*models.UserSetting, error)
+
+	FindTimeZoneByUID(
+		ctx context.Context,
+		uid string,
+	) (*time.Location, error)
+
+	Follow(
+		ctx context.Context,
+		id string,
+		uid string,
+	) error
+
+	Unfollow(
+		ctx context.Context,
+		id string,
+		uid string,
+	) error
 }
 
 type UserService struct {
-	userRepository   repositories.UserRepositoryInterface
-	recordRepository repositories.RecordRepositoryInterface
-	gameRepository   repositories.GameRepositoryInterface
-	deckRepository   repositories.DeckRepositoryInterface
+	userRepository        repositories.UserRepositoryInterface
+	recordRepository      repositories.RecordRepositoryInterface
+	gameRepository        repositories.GameRepositoryInterface
+	deckRepository        repositories.DeckRepositoryInterface
+	userSettingRepository repositories.UserSettingRepositoryInterface
+	followRepository      repositories.FollowRepositoryInterface
 }
 
 func NewUserService(
@@ -42,37 +121,186 @@ func NewUserService(
 	recordRepository repositories.RecordRepositoryInterface,
 	gameRepository repositories.GameRepositoryInterface,
 	deckRepository repositories.DeckRepositoryInterface,
+	userSettingRepository repositories.UserSettingRepositoryInterface,
+	followRepository repositories.FollowRepositoryInterface,
 ) UserServiceInterface {
 	return &UserService{
 		userRepository,
 		recordRepository,
 		gameRepository,
 		deckRepository,
+		userSettingRepository,
+		followRepository,
 	}
 }
 
+func createUserModel(dao *daos.User) *models.User {
+	model := &models.User{}
+	model.UID = dao.UID
+	model.DisplayName = dao.DisplayName
+	model.PhotoURL = dao.PhotoURL
+	model.PTCGLName = dao.PTCGLName
+	model.PlayerId = dao.PlayerId
+	model.Prefecture = dao.Prefecture
+	model.Bio = dao.Bio
+	model.FavoriteDeckId = dao.FavoriteDeckId
+
+	return model
+}
+
 func (s *UserService) FindById(
 	ctx context.Context,
 	id string,
 ) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.FindById")
+	defer span.End()
+
 	dao, err := s.userRepository.FindById(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return createUserModel(dao), nil
+}
+
+func (s *UserService) FindByIds(
+	ctx context.Context,
+	ids []string,
+) ([]*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.FindByIds")
+	defer span.End()
+
+	if len(ids) > USER_FIND_BY_IDS_LIMIT {
+		return nil, errors.New("too many ids")
+	}
+
+	daos, err := s.userRepository.FindByIds(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
 
-	model := &models.User{}
-	model.UID = dao.UID
-	model.DisplayName = dao.DisplayName
-	model.PhotoURL = dao.PhotoURL
+	users := []*models.User{}
+	for _, dao := range daos {
+		users = append(users, createUserModel(dao))
+	}
+
+	return users, nil
+}
+
+// ログイン時に呼び出し、Firebase Authenticationで変更された表示名・画像を反映する
+func (s *UserService) Sync(
+	ctx context.Context,
+	uid string,
+) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.Sync")
+	defer span.End()
+
+	dao, err := s.userRepository.Sync(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return createUserModel(dao), nil
+}
 
-	return model, nil
+// 認証したリクエスト毎に呼び出し、一度も取り込んでいないユーザの場合のみ表示名・画像を取り込む
+// POST /users/me/syncを呼ばないクライアントのユーザもプロフィールを参照できるようにする
+func (s *UserService) Register(
+	ctx context.Context,
+	uid string,
+) error {
+	ctx, span := tracer.Start(ctx, "UserService.Register")
+	defer span.End()
+
+	dao, err := s.userRepository.FindById(ctx, uid)
+	if err == nil && !dao.SyncedAt.IsZero() {
+		return nil
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	_, err = s.userRepository.Sync(ctx, uid)
+	return err
 }
 
-func (s *UserService) FindRecordsById(
+func (s *UserService) UpdateProfile(
+	ctx context.Context,
+	uid string,
+	dto *dtos.UserProfile,
+) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "UserService.UpdateProfile")
+	defer span.End()
+
+	if utf8.RuneCountInString(dto.PTCGLName) > USER_PTCGL_NAME_MAX_LENGTH {
+		return nil, errors.New("ptcgl_name is too long")
+	}
+
+	if len(dto.PlayerId) > USER_PLAYER_ID_MAX_LENGTH {
+		return nil, errors.New("player_id is too long")
+	}
+
+	for _, c := range dto.PlayerId {
+		if c < '0' || '9' < c {
+			return nil, errors.New("player_id must be numeric")
+		}
+	}
+
+	if dto.Prefecture != "" && !IsValidPrefecture(dto.Prefecture) {
+		return nil, errors.New("invalid prefecture")
+	}
+
+	if utf8.RuneCountInString(dto.Bio) > USER_BIO_MAX_LENGTH {
+		return nil, errors.New("bio is too long")
+	}
+
+	// 指定されたdto.FavoriteDeckIdのDeckが自分のものか確認
+	if dto.FavoriteDeckId != "" {
+		deck, err := s.deckRepository.FindById(ctx, dto.FavoriteDeckId)
+		if err != nil {
+			return nil, err
+		}
+
+		if deck.UserId != uid {
+			return nil, errors.New("no authority")
+		}
+	}
+
+	dao, err := s.userRepository.FindById(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	dao.PTCGLName = dto.PTCGLName
+	dao.PlayerId = dto.PlayerId
+	dao.Prefecture = dto.Prefecture
+	dao.Bio = dto.Bio
+	dao.FavoriteDeckId = dto.FavoriteDeckId
+
+	if err := s.userRepository.Save(ctx, dao); err != nil {
+		return nil, err
+	}
+
+	return createUserModel(dao), nil
+}
+
+func (s *UserService) FindRecordsByIdWithUID(
 	ctx context.Context,
 	id string,
+	uid string,
+	startDate time.Time,
+	endDate time.Time,
 ) ([]*models.Record, error) {
-	daos, err := s.recordRepository.FindAllByUID(ctx, id)
+	ctx, span := tracer.Start(ctx, "UserService.FindRecordsByIdWithUID")
+	defer span.End()
+
+	visibilities, err := newViewer(uid, s.followRepository).visibilities(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	daos, err := s.recordRepository.FindByUIDAndVisibilities(ctx, id, visibilities, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
@@ -85,18 +313,61 @@ func (s *UserService) FindRecordsById(
 	return records, nil
 }
 
-func (s *UserService) FindGamesById(
+func (s *UserService) FindGamesByIdWithUID(
 	ctx context.Context,
 	id string,
+	uid string,
+	startDate time.Time,
+	endDate time.Time,
 ) ([]*models.Game, error) {
-	daos, err := s.gameRepository.FindAllByUID(ctx, id)
+	ctx, span := tracer.Start(ctx, "UserService.FindGamesByIdWithUID")
+	defer span.End()
+
+	viewer := newViewer(uid, s.followRepository)
+
+	visibilities, err := viewer.visibilities(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	daos, err := s.gameRepository.FindByUIDAndVisibilities(ctx, id, visibilities, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
 
+	// メモの公開範囲は所属するRecordに従うため、対象のRecordをまとめて取得する
+	recordIds := []string{}
+	for _, dao := range daos {
+		recordIds = append(recordIds, dao.RecordId)
+	}
+
+	recordDaos, err := s.recordRepository.FindByIds(ctx, recordIds)
+	if err != nil {
+		return nil, err
+	}
+
+	recordMap := map[string]*models.Record{}
+	for _, dao := range recordDaos {
+		recordMap[dao.ID] = createRecordModel(dao)
+	}
+
 	games := []*models.Game{}
 	for _, dao := range daos {
-		games = append(games, createGameModel(dao))
+		record, exists := recordMap[dao.RecordId]
+		if !exists {
+			continue
+		}
+
+		game := createGameModel(dao)
+
+		ok, err := viewer.viewGame(ctx, record, game)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			games = append(games, game)
+		}
 	}
 
 	return games, nil
@@ -106,18 +377,25 @@ func (s *UserService) FindDecksByIdWithUID(
 	ctx context.Context,
 	id string,
 	uid string,
+	startDate time.Time,
+	endDate time.Time,
 ) ([]*models.Deck, error) {
-	daos, err := s.deckRepository.FindAllByUID(ctx, id)
+	ctx, span := tracer.Start(ctx, "UserService.FindDecksByIdWithUID")
+	defer span.End()
+
+	daos, err := s.deckRepository.FindAllByUIDAndDateRange(ctx, id, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
 
+	viewer := newViewer(uid, s.followRepository)
+
 	decks := []*models.Deck{}
 	for _, dao := range daos {
 		deck := createDeckModel(dao)
 
-		if deck.PrivateCodeFlg && deck.UserId != uid {
-			deck.Code = "ZZZZZZ-YYYYYY-ZZZZZZ"
+		if err := viewer.maskDeck(ctx, deck); err != nil {
+			return nil, err
 		}
 
 		decks = append(decks, deck)
@@ -125,3 +403,109 @@ func (s *UserService) FindDecksByIdWithUID(
 
 	return decks, nil
 }
+
+func (s *UserService) FindSettingByUID(
+	ctx context.Context,
+	uid string,
+) (*models.UserSetting, error) {
+	ctx, span := tracer.Start(ctx, "UserService.FindSettingByUID")
+	defer span.End()
+
+	dao, err := s.userSettingRepository.FindByUID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return createUserSettingModel(dao), nil
+}
+
+func (s *UserService) UpdateSetting(
+	ctx context.Context,
+	uid string,
+	dto *dtos.UserSetting,
+) (*models.UserSetting, error) {
+	ctx, span := tracer.Start(ctx, "UserService.UpdateSetting")
+	defer span.End()
+
+	if !IsValidVisibility(dto.DefaultVisibility) || !IsValidVisibility(dto.DefaultMemoVisibility) {
+		return nil, errors.New("invalid visibility")
+	}
+
+	if dto.TimeZone != nil && *dto.TimeZone != "" {
+		if _, err := LoadTimeZone(*dto.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+
+	dao, err := s.userSettingRepository.FindByUID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	dao.DefaultVisibility = dto.DefaultVisibility
+	dao.DefaultMemoVisibility = dto.DefaultMemoVisibility
+
+	if dto.TimeZone != nil {
+		dao.TimeZone = *dto.TimeZone
+	}
+
+	if err := s.userSettingRepository.Save(ctx, dao); err != nil {
+		return nil, err
+	}
+
+	return createUserSettingModel(dao), nil
+}
+
+// 日付で絞り込むときに使うユーザのタイムゾーン
+// 設定していない場合は既定のタイムゾーンを返す
+func (s *UserService) FindTimeZoneByUID(
+	ctx context.Context,
+	uid string,
+) (*time.Location, error) {
+	ctx, span := tracer.Start(ctx, "UserService.FindTimeZoneByUID")
+	defer span.End()
+
+	dao, err := s.userSettingRepository.FindByUID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadTimeZone(dao.TimeZone)
+}
+
+// uidのユーザがidのユーザをフォローする
+func (s *UserService) Follow(
+	ctx context.Context,
+	id string,
+	uid string,
+) error {
+	ctx, span := tracer.Start(ctx, "UserService.Follow")
+	defer span.End()
+
+	if id == uid {
+		return errors.New("cannot follow yourself")
+	}
+
+	// 指定されたidのユーザが存在するか確認
+	if _, err := s.userRepository.FindById(ctx, id); errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+
+	return s.followRepository.Save(ctx, &daos.Follow{
+		UserId:     uid,
+		FolloweeId: id,
+	})
+}
+
+func (s *UserService) Unfollow(
+	ctx context.Context,
+	id string,
+	uid string,
+) error {
+	ctx, span := tracer.Start(ctx, "UserService.Unfollow")
+	defer span.End()
+
+	return s.followRepository.Delete(ctx, uid, id)
+}
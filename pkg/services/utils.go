@@ -2,13 +2,18 @@ package services
 
 import (
 	"math/rand"
+	"strings"
 	"time"
 
 	ulid "github.com/oklog/ulid/v2"
+	"github.com/vsrecorder/vsr-apiserver/pkg/tracing"
 )
 
 var (
 	entropy = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// サービスの各メソッドをスパンとして記録する
+	tracer = tracing.Tracer("services")
 )
 
 func generateId() (string, error) {
@@ -17,3 +22,17 @@ func generateId() (string, error) {
 
 	return id.String(), err
 }
+
+// 表記の揺れを減らすため、前後の空白を除き、連続する空白を1つにまとめる
+func normalizeArchetype(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// 割合は0〜1で返す
+func ratio[T int | int64](count T, total T) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(count) / float64(total)
+}
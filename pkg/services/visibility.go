@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+const (
+	VISIBILITY_PUBLIC    = "public"
+	VISIBILITY_FOLLOWERS = "followers"
+	VISIBILITY_PRIVATE   = "private"
+
+	// ユーザが設定を作成していない場合の既定値
+	DEFAULT_VISIBILITY      = VISIBILITY_PUBLIC
+	DEFAULT_MEMO_VISIBILITY = VISIBILITY_PRIVATE
+
+	MASKED_DECK_CODE = "ZZZZZZ-YYYYYY-ZZZZZZ"
+)
+
+var (
+	// 閲覧権限が無いことを知られないように存在しない場合と同じ扱いにする
+	ErrNotVisible = errors.New("not found")
+)
+
+func IsValidVisibility(visibility string) bool {
+	switch visibility {
+	case VISIBILITY_PUBLIC, VISIBILITY_FOLLOWERS, VISIBILITY_PRIVATE:
+		return true
+	default:
+		return false
+	}
+}
+
+// 閲覧者(uid)から見た公開範囲の判定を行う
+// uidが空の場合は未ログインの閲覧者として扱う
+type viewer struct {
+	uid              string
+	followRepository repositories.FollowRepositoryInterface
+	following        map[string]bool
+}
+
+func newViewer(
+	uid string,
+	followRepository repositories.FollowRepositoryInterface,
+) *viewer {
+	return &viewer{
+		uid:              uid,
+		followRepository: followRepository,
+		following:        map[string]bool{},
+	}
+}
+
+func (v *viewer) canView(
+	ctx context.Context,
+	ownerId string,
+	visibility string,
+) (bool, error) {
+	if v.uid != "" && v.uid == ownerId {
+		return true, nil
+	}
+
+	switch visibility {
+	case VISIBILITY_PUBLIC:
+		return true, nil
+	case VISIBILITY_FOLLOWERS:
+		if v.uid == "" || v.followRepository == nil {
+			return false, nil
+		}
+
+		// 一覧の取得時に同じユーザへの問い合わせを繰り返さないようにする
+		if following, ok := v.following[ownerId]; ok {
+			return following, nil
+		}
+
+		following, err := v.followRepository.Exists(ctx, v.uid, ownerId)
+		if err != nil {
+			return false, err
+		}
+
+		v.following[ownerId] = following
+
+		return following, nil
+	default:
+		return false, nil
+	}
+}
+
+// ownerIdのユーザのデータのうち閲覧できる公開範囲を返す
+// 一覧の取得時に公開範囲の条件をクエリで絞り込むために使う
+func (v *viewer) visibilities(
+	ctx context.Context,
+	ownerId string,
+) ([]string, error) {
+	ret := []string{}
+	for _, visibility := range []string{VISIBILITY_PUBLIC, VISIBILITY_FOLLOWERS, VISIBILITY_PRIVATE} {
+		ok, err := v.canView(ctx, ownerId, visibility)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			ret = append(ret, visibility)
+		}
+	}
+
+	return ret, nil
+}
+
+func (v *viewer) canViewRecord(
+	ctx context.Context,
+	record *models.Record,
+) (bool, error) {
+	return v.canView(ctx, record.UserId, record.Visibility)
+}
+
+func (v *viewer) filterRecords(
+	ctx context.Context,
+	records []*models.Record,
+) ([]*models.Record, error) {
+	ret := []*models.Record{}
+	for _, record := range records {
+		ok, err := v.canViewRecord(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			ret = append(ret, record)
+		}
+	}
+
+	return ret, nil
+}
+
+// Game/BattleはRecordの公開範囲に従い、メモはRecordのメモの公開範囲に従う
+func (v *viewer) maskMemo(
+	ctx context.Context,
+	record *models.Record,
+	memo *string,
+) error {
+	ok, err := v.canView(ctx, record.UserId, record.MemoVisibility)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		*memo = ""
+	}
+
+	return nil
+}
+
+// デッキコードの非公開フラグも公開範囲の一種として扱う
+func (v *viewer) maskDeck(
+	ctx context.Context,
+	deck *models.Deck,
+) error {
+	visibility := VISIBILITY_PUBLIC
+	if deck.PrivateCodeFlg {
+		visibility = VISIBILITY_PRIVATE
+	}
+
+	ok, err := v.canView(ctx, deck.UserId, visibility)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		deck.Code = MASKED_DECK_CODE
+	}
+
+	return nil
+}
+
+func (v *viewer) viewGame(
+	ctx context.Context,
+	record *models.Record,
+	game *models.Game,
+) (bool, error) {
+	ok, err := v.canViewRecord(ctx, record)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return true, v.maskMemo(ctx, record, &game.Memo)
+}
+
+func (v *viewer) viewBattle(
+	ctx context.Context,
+	record *models.Record,
+	battle *models.Battle,
+) (bool, error) {
+	ok, err := v.canViewRecord(ctx, record)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return true, v.maskMemo(ctx, record, &battle.Memo)
+}
+
+func createUserSettingModel(dao *daos.UserSetting) *models.UserSetting {
+	model := &models.UserSetting{}
+	model.UserId = dao.UserId
+	model.CreatedAt = dao.CreatedAt
+	model.UpdatedAt = dao.UpdatedAt
+	model.DefaultVisibility = dao.DefaultVisibility
+	model.DefaultMemoVisibility = dao.DefaultMemoVisibility
+	model.TimeZone = dao.TimeZone
+
+	if model.DefaultVisibility == "" {
+		model.DefaultVisibility = DEFAULT_VISIBILITY
+	}
+
+	if model.DefaultMemoVisibility == "" {
+		model.DefaultMemoVisibility = DEFAULT_MEMO_VISIBILITY
+	}
+
+	if model.TimeZone == "" {
+		model.TimeZone = DEFAULT_TIME_ZONE
+	}
+
+	return model
+}
+
+// 指定されていない公開範囲はユーザの既定値で補完する
+func resolveVisibility(
+	ctx context.Context,
+	userSettingRepository repositories.UserSettingRepositoryInterface,
+	uid string,
+	visibility string,
+	memoVisibility string,
+) (string, string, error) {
+	if visibility == "" || memoVisibility == "" {
+		dao, err := userSettingRepository.FindByUID(ctx, uid)
+		if err != nil {
+			return "", "", err
+		}
+
+		setting := createUserSettingModel(dao)
+
+		if visibility == "" {
+			visibility = setting.DefaultVisibility
+		}
+
+		if memoVisibility == "" {
+			memoVisibility = setting.DefaultMemoVisibility
+		}
+	}
+
+	if !IsValidVisibility(visibility) || !IsValidVisibility(memoVisibility) {
+		return "", "", errors.New("invalid visibility")
+	}
+
+	return visibility, memoVisibility, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories"
+	"github.com/vsrecorder/vsr-apiserver/pkg/repositories/daos"
+	"github.com/vsrecorder/vsr-apiserver/pkg/services/models"
+)
+
+type followRepositoryStub struct {
+	repositories.FollowRepositoryInterface
+	follows map[string]string
+}
+
+func (r *followRepositoryStub) Exists(ctx context.Context, uid string, followeeId string) (bool, error) {
+	return r.follows[uid] == followeeId, nil
+}
+
+func TestViewer(t *testing.T) {
+	followRepository := &followRepositoryStub{follows: map[string]string{"follower": "owner"}}
+
+	for scenario, tc := range map[string]struct {
+		uid        string
+		visibility string
+		expected   bool
+	}{
+		"owner can view private":        {"owner", VISIBILITY_PRIVATE, true},
+		"anonymous can view public":     {"", VISIBILITY_PUBLIC, true},
+		"anonymous cannot view follows": {"", VISIBILITY_FOLLOWERS, false},
+		"follower can view followers":   {"follower", VISIBILITY_FOLLOWERS, true},
+		"follower cannot view private":  {"follower", VISIBILITY_PRIVATE, false},
+		"other cannot view followers":   {"other", VISIBILITY_FOLLOWERS, false},
+	} {
+		tc := tc
+		t.Run(scenario, func(t *testing.T) {
+			ok, err := newViewer(tc.uid, followRepository).canView(context.Background(), "owner", tc.visibility)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ok)
+		})
+	}
+
+	// 一覧の取得時に絞り込む公開範囲
+	for uid, expected := range map[string][]string{
+		"owner":    {VISIBILITY_PUBLIC, VISIBILITY_FOLLOWERS, VISIBILITY_PRIVATE},
+		"follower": {VISIBILITY_PUBLIC, VISIBILITY_FOLLOWERS},
+		"":         {VISIBILITY_PUBLIC},
+	} {
+		visibilities, err := newViewer(uid, followRepository).visibilities(context.Background(), "owner")
+		require.NoError(t, err)
+		require.Equal(t, expected, visibilities)
+	}
+
+	// 公開のRecordでもメモの公開範囲が異なればメモのみ隠す
+	record := &models.Record{UserId: "owner", Visibility: VISIBILITY_PUBLIC, MemoVisibility: VISIBILITY_PRIVATE}
+	game := createGameModel(&daos.Game{UserId: "owner", Memo: "memo"})
+
+	ok, err := newViewer("", followRepository).viewGame(context.Background(), record, game)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "", game.Memo)
+
+	deck := &models.Deck{UserId: "owner", Code: "code", PrivateCodeFlg: true}
+	require.NoError(t, newViewer("other", followRepository).maskDeck(context.Background(), deck))
+	require.Equal(t, MASKED_DECK_CODE, deck.Code)
+}
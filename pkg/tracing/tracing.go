@@ -0,0 +1,164 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	EXPORTER_NONE   = "none"
+	EXPORTER_OTLP   = "otlp"
+	EXPORTER_STDOUT = "stdout"
+	EXPORTER_FILE   = "file"
+
+	DEFAULT_SERVICE_NAME = "vsr-apiserver"
+	DEFAULT_SAMPLE_RATIO = 0.1
+
+	INSTRUMENTATION_NAME = "github.com/vsrecorder/vsr-apiserver"
+)
+
+type Config struct {
+	// none, otlp, stdout, file のいずれか
+	Exporter    string `yaml:"exporter"`
+	ServiceName string `yaml:"service_name"`
+	// OTLP/HTTPの送信先("localhost:4318"など)
+	Endpoint string `yaml:"endpoint"`
+	Insecure bool   `yaml:"insecure"`
+	// exporterがfileの場合の出力先
+	FilePath string `yaml:"file_path"`
+	// 0から1の割合でサンプリングする(親スパンがあれば親の判定に従う)
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+func (c *Config) Validate() error {
+	errs := []error{}
+
+	switch c.Exporter {
+	case "", EXPORTER_NONE, EXPORTER_STDOUT:
+	case EXPORTER_OTLP:
+		if c.Endpoint == "" {
+			errs = append(errs, errors.New("tracing.endpoint is required"))
+		}
+	case EXPORTER_FILE:
+		if c.FilePath == "" {
+			errs = append(errs, errors.New("tracing.file_path is required"))
+		}
+	default:
+		errs = append(errs, errors.New("invalid tracing.exporter: "+c.Exporter))
+	}
+
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// グローバルのTracerProviderを設定し、終了時に未送信のスパンを送信する関数を返す
+// exporterがnoneの場合は何も記録しない
+func Setup(ctx context.Context, config *Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, closer, err := newExporter(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = DEFAULT_SERVICE_NAME
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, config *Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch config.Exporter {
+	case "", EXPORTER_NONE:
+		return nil, nil, nil
+	case EXPORTER_OTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+		if config.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		return exporter, nil, err
+	case EXPORTER_STDOUT:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exporter, nil, err
+	case EXPORTER_FILE:
+		f, err := os.OpenFile(config.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+
+		return exporter, f, nil
+	default:
+		return nil, nil, errors.New("invalid tracing exporter: " + config.Exporter)
+	}
+}
+
+// 各層で使うトレーサを返す
+// Setupより前に取得しても、Setup後に設定されたTracerProviderが使われる
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(INSTRUMENTATION_NAME + "/" + name)
+}
+
+// エラーをスパンに記録する
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestTracing(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"Validate": test_Validate,
+		"File":     test_File,
+	} {
+		fn := fn
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func test_Validate(t *testing.T) {
+	require.NoError(t, (&Config{Exporter: EXPORTER_NONE}).Validate())
+	require.NoError(t, (&Config{Exporter: EXPORTER_OTLP, Endpoint: "localhost:4318", SampleRatio: 1}).Validate())
+
+	for _, config := range []*Config{
+		{Exporter: "jaeger"},
+		{Exporter: EXPORTER_OTLP},
+		{Exporter: EXPORTER_FILE},
+		{Exporter: EXPORTER_STDOUT, SampleRatio: 1.5},
+	} {
+		require.Error(t, config.Validate())
+	}
+}
+
+func test_File(t *testing.T) {
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Setup(context.Background(), &Config{
+		Exporter:    EXPORTER_FILE,
+		FilePath:    path,
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	_, span := Tracer("test").Start(context.Background(), "span")
+	span.End()
+
+	require.NoError(t, shutdown(context.Background()))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"Name":"span"`)
+}